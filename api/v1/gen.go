@@ -6,29 +6,131 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/oapi-codegen/runtime"
 )
 
+const (
+	ApiKeyAuthScopes    = "ApiKeyAuth.Scopes"
+	BearerAuthScopes    = "BearerAuth.Scopes"
+	SignedRequestScopes = "SignedRequest.Scopes"
+)
+
+// Defines values for HealthCheckStatus.
+const (
+	HealthCheckStatusFailed HealthCheckStatus = "failed"
+	HealthCheckStatusOk     HealthCheckStatus = "ok"
+)
+
+// Defines values for HealthReportStatus.
+const (
+	HealthReportStatusDegraded     HealthReportStatus = "degraded"
+	HealthReportStatusFailed       HealthReportStatus = "failed"
+	HealthReportStatusOk           HealthReportStatus = "ok"
+	HealthReportStatusShuttingDown HealthReportStatus = "shutting_down"
+)
+
 // Defines values for TransferOperation.
 const (
 	Deposit  TransferOperation = "deposit"
 	Withdraw TransferOperation = "withdraw"
 )
 
+// Defines values for WalletStatus.
+const (
+	Active WalletStatus = "active"
+	Frozen WalletStatus = "frozen"
+)
+
+// Defines values for WebhookDeliveryStatus.
+const (
+	Dead      WebhookDeliveryStatus = "dead"
+	Delivered WebhookDeliveryStatus = "delivered"
+	Pending   WebhookDeliveryStatus = "pending"
+)
+
+// APIKey defines model for APIKey.
+type APIKey struct {
+	CreatedAt time.Time `json:"created_at"`
+	Id        string    `json:"id"`
+
+	// Key Value for the X-API-Key header, returned only on creation
+	Key     string   `json:"key"`
+	Name    string   `json:"name"`
+	OwnerId *string  `json:"owner_id,omitempty"`
+	Scopes  []string `json:"scopes"`
+
+	// TenantId Tenant of the key; platform keys without a tenant choose it with X-Tenant-ID
+	TenantId *string `json:"tenant_id,omitempty"`
+}
+
+// APIKeyRequest defines model for APIKeyRequest.
+type APIKeyRequest struct {
+	Name string `json:"name"`
+
+	// OwnerId Restrict the key to wallets of this owner; unrestricted if omitted
+	OwnerId *string `json:"owner_id,omitempty"`
+
+	// Scopes Any of read, deposit, withdraw, operator, admin; admin implies the rest
+	Scopes []string `json:"scopes"`
+}
+
+// AdminWallet defines model for AdminWallet.
+type AdminWallet struct {
+	Balance   int          `json:"balance"`
+	CreatedAt time.Time    `json:"created_at"`
+	Id        string       `json:"id"`
+	OwnerId   *string      `json:"owner_id,omitempty"`
+	Status    WalletStatus `json:"status"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	Version   int64        `json:"version"`
+}
+
+// AdminWalletDetail defines model for AdminWalletDetail.
+type AdminWalletDetail struct {
+	// Transactions Latest transactions, newest first
+	Transactions []WalletTransaction `json:"transactions"`
+	Wallet       AdminWallet         `json:"wallet"`
+}
+
+// AdminWalletList defines model for AdminWalletList.
+type AdminWalletList struct {
+	Wallets []AdminWallet `json:"wallets"`
+}
+
 // Balance defines model for Balance.
 type Balance struct {
-	Balance  int    `json:"balance"`
-	WalletId string `json:"wallet_id"`
+	Balance int `json:"balance"`
+
+	// Currency Currency of the tenant the wallet belongs to
+	Currency *string `json:"currency,omitempty"`
+	WalletId string  `json:"wallet_id"`
+}
+
+// BalanceAdjustment defines model for BalanceAdjustment.
+type BalanceAdjustment struct {
+	// Amount Signed amount added to the balance, not zero
+	Amount int `json:"amount"`
+
+	// Reason Why the balance is adjusted, recorded in the audit log
+	Reason string `json:"reason"`
 }
 
 // Error defines model for Error.
 type Error struct {
+	// Code Machine-readable reason - invalid_signature, clock_skew or replayed_request for signed requests, rate_limited for 429, wallet_frozen for transfers to a frozen wallet, wallet_busy for 503, invalid_consistency_token for a malformed X-Consistency-Token.
+	Code *string `json:"code,omitempty"`
+
 	// Detail A human-readable explanation specific to this occurrence of the problem.
 	Detail string `json:"detail"`
 
+	// RequestId X-Request-ID of the request that caused the problem.
+	RequestId *string `json:"request_id,omitempty"`
+
 	// Status The HTTP status code generated by the origin server for this occurrence of the problem.
 	Status int `json:"status"`
 
@@ -36,6 +138,26 @@ type Error struct {
 	Title string `json:"title"`
 }
 
+// HealthCheck defines model for HealthCheck.
+type HealthCheck struct {
+	Critical  bool              `json:"critical"`
+	Error     *string           `json:"error,omitempty"`
+	LatencyMs float32           `json:"latency_ms"`
+	Status    HealthCheckStatus `json:"status"`
+}
+
+// HealthCheckStatus defines model for HealthCheck.Status.
+type HealthCheckStatus string
+
+// HealthReport defines model for HealthReport.
+type HealthReport struct {
+	Checks *map[string]HealthCheck `json:"checks,omitempty"`
+	Status HealthReportStatus      `json:"status"`
+}
+
+// HealthReportStatus defines model for HealthReport.Status.
+type HealthReportStatus string
+
 // Transfer defines model for Transfer.
 type Transfer struct {
 	Amount    int               `json:"amount"`
@@ -46,11 +168,149 @@ type Transfer struct {
 // TransferOperation defines model for Transfer.Operation.
 type TransferOperation string
 
+// WalletStatus defines model for WalletStatus.
+type WalletStatus string
+
+// WalletStatusUpdate defines model for WalletStatusUpdate.
+type WalletStatusUpdate struct {
+	Reason string       `json:"reason"`
+	Status WalletStatus `json:"status"`
+}
+
+// WalletTransaction defines model for WalletTransaction.
+type WalletTransaction struct {
+	// Amount Signed for adjustments, positive for deposits and withdrawals
+	Amount    int       `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+	Id        string    `json:"id"`
+
+	// OperationType deposit, withdraw or adjustment
+	OperationType string  `json:"operation_type"`
+	RequestId     *string `json:"request_id,omitempty"`
+}
+
+// WebhookDeliveries defines model for WebhookDeliveries.
+type WebhookDeliveries struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+// WebhookDelivery defines model for WebhookDelivery.
+type WebhookDelivery struct {
+	Attempts       int                   `json:"attempts"`
+	CreatedAt      time.Time             `json:"created_at"`
+	EventId        int64                 `json:"event_id"`
+	EventType      string                `json:"event_type"`
+	Id             string                `json:"id"`
+	LastError      string                `json:"last_error"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	SubscriptionId string                `json:"subscription_id"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// WebhookDeliveryStatus defines model for WebhookDelivery.Status.
+type WebhookDeliveryStatus string
+
+// WebhookSubscription defines model for WebhookSubscription.
+type WebhookSubscription struct {
+	CreatedAt time.Time `json:"created_at"`
+	Id        string    `json:"id"`
+
+	// Secret HMAC-SHA256 key for X-Webhook-Signature, returned only on creation
+	Secret   string  `json:"secret"`
+	Url      string  `json:"url"`
+	WalletId *string `json:"wallet_id,omitempty"`
+}
+
+// WebhookSubscriptionRequest defines model for WebhookSubscriptionRequest.
+type WebhookSubscriptionRequest struct {
+	Url string `json:"url"`
+
+	// WalletId Subscribe to a single wallet; all wallets if omitted
+	WalletId *string `json:"wallet_id,omitempty"`
+}
+
+// Forbidden defines model for Forbidden.
+type Forbidden = Error
+
+// TooManyRequests defines model for TooManyRequests.
+type TooManyRequests = Error
+
+// Unauthorized defines model for Unauthorized.
+type Unauthorized = Error
+
+// AdminListWalletsParams defines parameters for AdminListWallets.
+type AdminListWalletsParams struct {
+	MinBalance *int `form:"min_balance,omitempty" json:"min_balance,omitempty"`
+	MaxBalance *int `form:"max_balance,omitempty" json:"max_balance,omitempty"`
+
+	// CreatedFrom Inclusive lower bound of created_at
+	CreatedFrom *time.Time `form:"created_from,omitempty" json:"created_from,omitempty"`
+
+	// CreatedTo Exclusive upper bound of created_at
+	CreatedTo *time.Time    `form:"created_to,omitempty" json:"created_to,omitempty"`
+	Status    *WalletStatus `form:"status,omitempty" json:"status,omitempty"`
+	Limit     *int          `form:"limit,omitempty" json:"limit,omitempty"`
+	Offset    *int          `form:"offset,omitempty" json:"offset,omitempty"`
+}
+
+// AdminExportWalletsParams defines parameters for AdminExportWallets.
+type AdminExportWalletsParams struct {
+	MinBalance *int `form:"min_balance,omitempty" json:"min_balance,omitempty"`
+	MaxBalance *int `form:"max_balance,omitempty" json:"max_balance,omitempty"`
+
+	// CreatedFrom Inclusive lower bound of created_at
+	CreatedFrom *time.Time `form:"created_from,omitempty" json:"created_from,omitempty"`
+
+	// CreatedTo Exclusive upper bound of created_at
+	CreatedTo *time.Time    `form:"created_to,omitempty" json:"created_to,omitempty"`
+	Status    *WalletStatus `form:"status,omitempty" json:"status,omitempty"`
+}
+
+// ListWebhookDeliveriesParams defines parameters for ListWebhookDeliveries.
+type ListWebhookDeliveriesParams struct {
+	// Limit Maximum number of deliveries, newest first
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// AdminAdjustBalanceJSONRequestBody defines body for AdminAdjustBalance for application/json ContentType.
+type AdminAdjustBalanceJSONRequestBody = BalanceAdjustment
+
+// AdminSetWalletStatusJSONRequestBody defines body for AdminSetWalletStatus for application/json ContentType.
+type AdminSetWalletStatusJSONRequestBody = WalletStatusUpdate
+
+// IssueAPIKeyJSONRequestBody defines body for IssueAPIKey for application/json ContentType.
+type IssueAPIKeyJSONRequestBody = APIKeyRequest
+
 // TransferJSONRequestBody defines body for Transfer for application/json ContentType.
 type TransferJSONRequestBody = Transfer
 
+// CreateWebhookSubscriptionJSONRequestBody defines body for CreateWebhookSubscription for application/json ContentType.
+type CreateWebhookSubscriptionJSONRequestBody = WebhookSubscriptionRequest
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// list wallets (operator scope)
+	// (GET /admin/v1/wallets)
+	AdminListWallets(w http.ResponseWriter, r *http.Request, params AdminListWalletsParams)
+	// export wallets as CSV (operator scope)
+	// (GET /admin/v1/wallets/export)
+	AdminExportWallets(w http.ResponseWriter, r *http.Request, params AdminExportWalletsParams)
+	// get wallet detail (operator scope)
+	// (GET /admin/v1/wallets/{wallet_id})
+	AdminGetWallet(w http.ResponseWriter, r *http.Request, walletId string)
+	// adjust wallet balance (operator scope)
+	// (POST /admin/v1/wallets/{wallet_id}/adjustments)
+	AdminAdjustBalance(w http.ResponseWriter, r *http.Request, walletId string)
+	// freeze or unfreeze wallet (operator scope)
+	// (PUT /admin/v1/wallets/{wallet_id}/status)
+	AdminSetWalletStatus(w http.ResponseWriter, r *http.Request, walletId string)
+	// issue API key (admin scope)
+	// (POST /api/v1/admin/keys)
+	IssueAPIKey(w http.ResponseWriter, r *http.Request)
+	// revoke API key (admin scope)
+	// (DELETE /api/v1/admin/keys/{key_id})
+	RevokeAPIKey(w http.ResponseWriter, r *http.Request, keyId string)
 	// transfer money
 	// (POST /api/v1/wallet)
 	Transfer(w http.ResponseWriter, r *http.Request)
@@ -60,6 +320,21 @@ type ServerInterface interface {
 	// get wallet balance
 	// (GET /api/v1/wallets/{wallet_uuid})
 	GetBalance(w http.ResponseWriter, r *http.Request, walletUuid string)
+	// create webhook subscription
+	// (POST /api/v1/webhooks)
+	CreateWebhookSubscription(w http.ResponseWriter, r *http.Request)
+	// replay webhook delivery
+	// (POST /api/v1/webhooks/deliveries/{delivery_id}/replay)
+	ReplayWebhookDelivery(w http.ResponseWriter, r *http.Request, deliveryId string)
+	// list webhook deliveries
+	// (GET /api/v1/webhooks/{subscription_id}/deliveries)
+	ListWebhookDeliveries(w http.ResponseWriter, r *http.Request, subscriptionId string, params ListWebhookDeliveriesParams)
+	// liveness probe
+	// (GET /healthz)
+	Healthz(w http.ResponseWriter, r *http.Request)
+	// readiness probe
+	// (GET /readyz)
+	Readyz(w http.ResponseWriter, r *http.Request)
 }
 
 // ServerInterfaceWrapper converts contexts to parameters.
@@ -71,9 +346,337 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc func(http.Handler) http.Handler
 
+// AdminListWallets operation middleware
+func (siw *ServerInterfaceWrapper) AdminListWallets(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, ApiKeyAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, SignedRequestScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params AdminListWalletsParams
+
+	// ------------- Optional query parameter "min_balance" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "min_balance", r.URL.Query(), &params.MinBalance)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "min_balance", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "max_balance" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "max_balance", r.URL.Query(), &params.MaxBalance)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "max_balance", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "created_from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "created_from", r.URL.Query(), &params.CreatedFrom)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "created_from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "created_to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "created_to", r.URL.Query(), &params.CreatedTo)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "created_to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", r.URL.Query(), &params.Status)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "status", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "offset" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "offset", r.URL.Query(), &params.Offset)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "offset", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.AdminListWallets(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// AdminExportWallets operation middleware
+func (siw *ServerInterfaceWrapper) AdminExportWallets(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, ApiKeyAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, SignedRequestScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params AdminExportWalletsParams
+
+	// ------------- Optional query parameter "min_balance" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "min_balance", r.URL.Query(), &params.MinBalance)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "min_balance", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "max_balance" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "max_balance", r.URL.Query(), &params.MaxBalance)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "max_balance", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "created_from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "created_from", r.URL.Query(), &params.CreatedFrom)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "created_from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "created_to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "created_to", r.URL.Query(), &params.CreatedTo)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "created_to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", r.URL.Query(), &params.Status)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "status", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.AdminExportWallets(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// AdminGetWallet operation middleware
+func (siw *ServerInterfaceWrapper) AdminGetWallet(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "wallet_id" -------------
+	var walletId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "wallet_id", r.PathValue("wallet_id"), &walletId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "wallet_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, ApiKeyAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, SignedRequestScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.AdminGetWallet(w, r, walletId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// AdminAdjustBalance operation middleware
+func (siw *ServerInterfaceWrapper) AdminAdjustBalance(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "wallet_id" -------------
+	var walletId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "wallet_id", r.PathValue("wallet_id"), &walletId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "wallet_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, ApiKeyAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, SignedRequestScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.AdminAdjustBalance(w, r, walletId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// AdminSetWalletStatus operation middleware
+func (siw *ServerInterfaceWrapper) AdminSetWalletStatus(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "wallet_id" -------------
+	var walletId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "wallet_id", r.PathValue("wallet_id"), &walletId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "wallet_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, ApiKeyAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, SignedRequestScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.AdminSetWalletStatus(w, r, walletId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// IssueAPIKey operation middleware
+func (siw *ServerInterfaceWrapper) IssueAPIKey(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, ApiKeyAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, SignedRequestScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.IssueAPIKey(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// RevokeAPIKey operation middleware
+func (siw *ServerInterfaceWrapper) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "key_id" -------------
+	var keyId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "key_id", r.PathValue("key_id"), &keyId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "key_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, ApiKeyAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, SignedRequestScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RevokeAPIKey(w, r, keyId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 // Transfer operation middleware
 func (siw *ServerInterfaceWrapper) Transfer(w http.ResponseWriter, r *http.Request) {
 
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, ApiKeyAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, SignedRequestScopes, []string{})
+
+	r = r.WithContext(ctx)
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.Transfer(w, r)
 	}))
@@ -88,6 +691,16 @@ func (siw *ServerInterfaceWrapper) Transfer(w http.ResponseWriter, r *http.Reque
 // CreateWallet operation middleware
 func (siw *ServerInterfaceWrapper) CreateWallet(w http.ResponseWriter, r *http.Request) {
 
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, ApiKeyAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, SignedRequestScopes, []string{})
+
+	r = r.WithContext(ctx)
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.CreateWallet(w, r)
 	}))
@@ -113,6 +726,16 @@ func (siw *ServerInterfaceWrapper) GetBalance(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, ApiKeyAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, SignedRequestScopes, []string{})
+
+	r = r.WithContext(ctx)
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.GetBalance(w, r, walletUuid)
 	}))
@@ -124,6 +747,139 @@ func (siw *ServerInterfaceWrapper) GetBalance(w http.ResponseWriter, r *http.Req
 	handler.ServeHTTP(w, r)
 }
 
+// CreateWebhookSubscription operation middleware
+func (siw *ServerInterfaceWrapper) CreateWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, ApiKeyAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, SignedRequestScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateWebhookSubscription(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ReplayWebhookDelivery operation middleware
+func (siw *ServerInterfaceWrapper) ReplayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "delivery_id" -------------
+	var deliveryId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "delivery_id", r.PathValue("delivery_id"), &deliveryId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "delivery_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, ApiKeyAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, SignedRequestScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ReplayWebhookDelivery(w, r, deliveryId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListWebhookDeliveries operation middleware
+func (siw *ServerInterfaceWrapper) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "subscription_id" -------------
+	var subscriptionId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "subscription_id", r.PathValue("subscription_id"), &subscriptionId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "subscription_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, ApiKeyAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, SignedRequestScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListWebhookDeliveriesParams
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListWebhookDeliveries(w, r, subscriptionId, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// Healthz operation middleware
+func (siw *ServerInterfaceWrapper) Healthz(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.Healthz(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// Readyz operation middleware
+func (siw *ServerInterfaceWrapper) Readyz(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.Readyz(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 type UnescapedCookieParamError struct {
 	ParamName string
 	Err       error
@@ -244,9 +1000,21 @@ func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.H
 		ErrorHandlerFunc:   options.ErrorHandlerFunc,
 	}
 
+	m.HandleFunc("GET "+options.BaseURL+"/admin/v1/wallets", wrapper.AdminListWallets)
+	m.HandleFunc("GET "+options.BaseURL+"/admin/v1/wallets/export", wrapper.AdminExportWallets)
+	m.HandleFunc("GET "+options.BaseURL+"/admin/v1/wallets/{wallet_id}", wrapper.AdminGetWallet)
+	m.HandleFunc("POST "+options.BaseURL+"/admin/v1/wallets/{wallet_id}/adjustments", wrapper.AdminAdjustBalance)
+	m.HandleFunc("PUT "+options.BaseURL+"/admin/v1/wallets/{wallet_id}/status", wrapper.AdminSetWalletStatus)
+	m.HandleFunc("POST "+options.BaseURL+"/api/v1/admin/keys", wrapper.IssueAPIKey)
+	m.HandleFunc("DELETE "+options.BaseURL+"/api/v1/admin/keys/{key_id}", wrapper.RevokeAPIKey)
 	m.HandleFunc("POST "+options.BaseURL+"/api/v1/wallet", wrapper.Transfer)
 	m.HandleFunc("POST "+options.BaseURL+"/api/v1/wallet/create", wrapper.CreateWallet)
 	m.HandleFunc("GET "+options.BaseURL+"/api/v1/wallets/{wallet_uuid}", wrapper.GetBalance)
+	m.HandleFunc("POST "+options.BaseURL+"/api/v1/webhooks", wrapper.CreateWebhookSubscription)
+	m.HandleFunc("POST "+options.BaseURL+"/api/v1/webhooks/deliveries/{delivery_id}/replay", wrapper.ReplayWebhookDelivery)
+	m.HandleFunc("GET "+options.BaseURL+"/api/v1/webhooks/{subscription_id}/deliveries", wrapper.ListWebhookDeliveries)
+	m.HandleFunc("GET "+options.BaseURL+"/healthz", wrapper.Healthz)
+	m.HandleFunc("GET "+options.BaseURL+"/readyz", wrapper.Readyz)
 
 	return m
 }
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/glekoz/test_itk/config"
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/service"
+)
+
+// createAdminKey выпускает первый ключ с правом admin: itkapp create-admin-key <name>.
+// Остальные ключи выпускаются через POST /api/v1/admin/keys
+func createAdminKey(cfg *config.Config, logger *slog.Logger, args []string) {
+	if len(args) != 1 {
+		fatal(logger, "usage: itkapp create-admin-key <name>", fmt.Errorf("expected 1 argument, got %d", len(args)))
+	}
+
+	repo, err := openRepository(cfg, logger)
+	if err != nil {
+		fatal(logger, "can not connect to DB", err)
+	}
+	defer repo.Close()
+
+	s := service.New(repo, nil, logger)
+	key, secret, err := s.IssueAPIKey(context.Background(), args[0], []string{string(auth.ScopeAdmin)}, "")
+	if err != nil {
+		repo.Close()
+		fatal(logger, "issuing admin key failed", err)
+	}
+	logger.Info("admin key issued", slog.String("key_id", key.ID))
+	fmt.Println(secret)
+}
+
+// revokeKey отзывает ключ любого арендатора, в том числе ключ платформы: itkapp revoke-key <key_id>.
+// Ключи арендаторов можно отозвать и через DELETE /api/v1/admin/keys/{key_id}
+func revokeKey(cfg *config.Config, logger *slog.Logger, args []string) {
+	if len(args) != 1 {
+		fatal(logger, "usage: itkapp revoke-key <key_id>", fmt.Errorf("expected 1 argument, got %d", len(args)))
+	}
+
+	repo, err := openRepository(cfg, logger)
+	if err != nil {
+		fatal(logger, "can not connect to DB", err)
+	}
+	defer repo.Close()
+
+	// от имени администратора платформы, как ключи из create-admin-key
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{
+		ID:     cliActor(),
+		Scopes: []auth.Scope{auth.ScopeAdmin},
+	})
+	s := service.New(repo, nil, logger)
+	if err := s.RevokeAPIKey(ctx, args[0]); err != nil {
+		repo.Close()
+		fatal(logger, "revoking key failed", err, slog.String("key_id", args[0]))
+	}
+	logger.Info("key revoked", slog.String("key_id", args[0]))
+}
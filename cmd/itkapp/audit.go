@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/glekoz/test_itk/config"
+	"github.com/glekoz/test_itk/internal/audit"
+)
+
+// verifyAudit пересчитывает хеш-цепочки audit_log: itkapp verify-audit.
+// Завершается с кодом 1 на первой записи, с которой цепочка не сходится
+func verifyAudit(cfg *config.Config, logger *slog.Logger) {
+	repo, err := openRepository(cfg, logger)
+	if err != nil {
+		fatal(logger, "can not connect to DB", err)
+	}
+	defer repo.Close()
+
+	v := audit.NewVerifier()
+	if err := repo.ListAuditEntries(context.Background(), v.Check); err != nil {
+		repo.Close()
+		fatal(logger, "audit log verification failed", err, slog.Int("checked", v.Checked()))
+	}
+	logger.Info("audit log verified", slog.Int("checked", v.Checked()))
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/glekoz/test_itk/config"
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/cache"
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/repository"
+	"github.com/glekoz/test_itk/internal/service"
+	"github.com/glekoz/test_itk/internal/shared/requestid"
+	"github.com/glekoz/test_itk/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// cliFlags - флаги, общие для команд, которые работают с кошельками
+type cliFlags struct {
+	*flag.FlagSet
+	tenant string
+	output string
+}
+
+func newFlags(name, args string) *cliFlags {
+	f := &cliFlags{FlagSet: flag.NewFlagSet(name, flag.ExitOnError)}
+	f.StringVar(&f.tenant, "tenant", tenant.DefaultID, "tenant id")
+	f.StringVar(&f.output, "output", outputTable, "output format: table or json")
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "usage: itkapp %s [flags] %s\n", name, args)
+		f.PrintDefaults()
+	}
+	return f
+}
+
+// parse разбирает флаги и проверяет число позиционных аргументов; флаги идут до аргументов
+func (f *cliFlags) parse(logger *slog.Logger, args []string, nargs int) {
+	f.Parse(args)
+	if f.output != outputTable && f.output != outputJSON {
+		fatal(logger, "invalid flags", fmt.Errorf("unknown output format %q", f.output))
+	}
+	if f.NArg() != nargs {
+		f.Usage()
+		fatal(logger, "invalid arguments", fmt.Errorf("expected %d arguments, got %d", nargs, f.NArg()))
+	}
+}
+
+// cli - сервис и репозиторий для команд, которые меняют кошельки напрямую, минуя HTTP.
+// Команды проходят те же проверки и пишутся в тот же журнал аудита, что и запросы к API
+type cli struct {
+	ctx     context.Context
+	repo    *repository.Repository
+	service *service.Service
+	tenant  tenant.Config
+	logger  *slog.Logger
+}
+
+// openCLI действует от имени оператора ОС с правом admin в арендаторе из флага --tenant
+func openCLI(cfg *config.Config, logger *slog.Logger, f *cliFlags, ownerID string) *cli {
+	tenants, err := loadTenants(cfg)
+	if err != nil {
+		fatal(logger, "can not load tenants", err)
+	}
+	t, ok := tenants.Get(f.tenant)
+	if !ok {
+		fatal(logger, "invalid flags", fmt.Errorf("unknown tenant %q", f.tenant))
+	}
+
+	repo, err := openRepository(cfg, logger)
+	if err != nil {
+		fatal(logger, "can not connect to DB", err)
+	}
+	balances, err := cache.New(cfg.CacheTTL, cfg.CacheSize)
+	if err != nil {
+		repo.Close()
+		fatal(logger, "can not create cache", err)
+	}
+
+	// у каждого запуска свой request ID, по нему запись аудита связывается с логами команды
+	id := uuid.NewString()
+	logger = logger.With(slog.String(logging.KeyRequestID, id))
+	ctx := requestid.WithID(context.Background(), id)
+	ctx = logging.WithLogger(ctx, logger)
+	ctx = tenant.WithConfig(ctx, t)
+	ctx = auth.WithPrincipal(ctx, auth.Principal{
+		ID:       cliActor(),
+		OwnerID:  ownerID,
+		TenantID: t.ID,
+		Scopes:   []auth.Scope{auth.ScopeAdmin},
+	})
+
+	return &cli{
+		ctx:     ctx,
+		repo:    repo,
+		service: service.New(repo, balances, logger),
+		tenant:  t,
+		logger:  logger,
+	}
+}
+
+func (c *cli) close() {
+	c.repo.Close()
+}
+
+// fatal закрывает пул до выхода: os.Exit не выполняет отложенные вызовы
+func (c *cli) fatal(msg string, err error, attrs ...any) {
+	c.close()
+	fatal(c.logger, msg, err, attrs...)
+}
+
+// cliActor попадает в actor журнала аудита
+func cliActor() string {
+	u, err := user.Current()
+	if err != nil {
+		return "cli"
+	}
+	return "cli:" + u.Username
+}
+
+func openRepository(cfg *config.Config, logger *slog.Logger) (*repository.Repository, error) {
+	return repository.New(context.Background(), cfg.DatabaseURL, repository.Options{
+		MaxConns:          int32(cfg.DBMaxConns),
+		MinConns:          int32(cfg.DBMinConns),
+		MaxConnLifetime:   time.Duration(cfg.DBMaxConnLifetime) * time.Second,
+		MaxConnIdleTime:   time.Duration(cfg.DBMaxConnIdleTime) * time.Second,
+		HealthCheckPeriod: time.Duration(cfg.DBHealthCheckPeriod) * time.Second,
+		ConnectAttempts:   cfg.DBConnectAttempts,
+		ConnectBackoff:    time.Duration(cfg.DBConnectBackoff) * time.Second,
+		StatementTimeout:  time.Duration(cfg.DBStatementTimeout) * time.Millisecond,
+		LockTimeout:       time.Duration(cfg.DBLockTimeout) * time.Millisecond,
+		Isolation:         pgx.TxIsoLevel(strings.ReplaceAll(cfg.DBIsolationLevel, "_", " ")),
+		Retry: repository.RetryPolicy{
+			MaxAttempts: cfg.DBTxMaxAttempts,
+			Backoff:     time.Duration(cfg.DBTxRetryBackoff) * time.Millisecond,
+		},
+		ReplicaURL:    cfg.DBReplicaURL,
+		ReplicaMaxLag: time.Duration(cfg.DBReplicaMaxLag) * time.Millisecond,
+	}, logger)
+}
+
+func loadTenants(cfg *config.Config) (*tenant.Registry, error) {
+	if cfg.TenantsPath != "" {
+		return tenant.Load(cfg.TenantsPath, cfg.DefaultCurrency)
+	}
+	return tenant.NewRegistry(cfg.DefaultCurrency)
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/glekoz/test_itk/config"
+)
+
+// configPrint печатает итоговую конфигурацию после всех слоев: itkapp [config flags] config print
+func configPrint(cfg *config.Config, logger *slog.Logger, args []string) {
+	f := newFlags("config print", "")
+	f.parse(logger, args, 0)
+
+	settings := cfg.Settings()
+	out := make(map[string]string, len(settings))
+	rows := make([][]string, 0, len(settings))
+	for _, s := range settings {
+		out[s.Key] = s.Value
+		rows = append(rows, []string{s.Key, s.Value})
+	}
+	if err := printResult(f.output, out, []string{"KEY", "VALUE"}, rows...); err != nil {
+		fatal(logger, "printing result failed", err)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/glekoz/test_itk/config"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+)
+
+type exportedWallet struct {
+	ID        string    `json:"id"`
+	Balance   int       `json:"balance"`
+	Status    string    `json:"status"`
+	OwnerID   string    `json:"owner_id,omitempty"`
+	Version   int64     `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// export выгружает кошельки арендатора с теми же фильтрами, что GET /admin/v1/wallets/export:
+// itkapp export [--status s] [--min-balance n] [--max-balance n] [--created-from t] [--created-to t]
+func export(cfg *config.Config, logger *slog.Logger, args []string) {
+	var filter models.WalletFilter
+	f := newFlags("export", "")
+	f.Func("status", "active or frozen", func(s string) error {
+		switch status := myvars.WalletStatus(s); status {
+		case myvars.WalletStatusActive, myvars.WalletStatusFrozen:
+			filter.Status = status
+			return nil
+		}
+		return fmt.Errorf("status must be either 'active' or 'frozen'")
+	})
+	f.Func("min-balance", "minimal balance, inclusive", intFlag(&filter.MinBalance))
+	f.Func("max-balance", "maximal balance, inclusive", intFlag(&filter.MaxBalance))
+	f.Func("created-from", "RFC 3339 timestamp, inclusive", timeFlag(&filter.CreatedFrom))
+	f.Func("created-to", "RFC 3339 timestamp, exclusive", timeFlag(&filter.CreatedTo))
+	f.parse(logger, args, 0)
+
+	c := openCLI(cfg, logger, f, "")
+	defer c.close()
+
+	// JSON-массив пишется по мере чтения страниц, чтобы не держать выгрузку в памяти
+	var (
+		t     *table
+		enc   = json.NewEncoder(os.Stdout)
+		count int
+	)
+	if f.output == outputTable {
+		t = newTable([]string{"ID", "BALANCE", "STATUS", "OWNER ID", "VERSION", "CREATED AT", "UPDATED AT"})
+	} else {
+		fmt.Print("[")
+	}
+	err := c.service.ExportWallets(c.ctx, filter, func(w models.Wallet) error {
+		count++
+		if t != nil {
+			t.row(w.ID, strconv.Itoa(w.Balance), string(w.Status), w.OwnerID, strconv.FormatInt(w.Version, 10),
+				w.CreatedAt.UTC().Format(time.RFC3339), w.UpdatedAt.UTC().Format(time.RFC3339))
+			return nil
+		}
+		if count > 1 {
+			fmt.Print(",")
+		}
+		return enc.Encode(exportedWallet{
+			ID:        w.ID,
+			Balance:   w.Balance,
+			Status:    string(w.Status),
+			OwnerID:   w.OwnerID,
+			Version:   w.Version,
+			CreatedAt: w.CreatedAt,
+			UpdatedAt: w.UpdatedAt,
+		})
+	})
+	if t != nil {
+		t.flush()
+	} else {
+		fmt.Println("]")
+	}
+	if err != nil {
+		c.fatal("export failed", err, slog.Int("exported", count))
+	}
+}
+
+func intFlag(dst **int) func(string) error {
+	return func(s string) error {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		*dst = &n
+		return nil
+	}
+}
+
+func timeFlag(dst **time.Time) func(string) error {
+	return func(s string) error {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return fmt.Errorf("must be an RFC 3339 timestamp")
+		}
+		*dst = &t
+		return nil
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/glekoz/test_itk/config"
+)
+
+// healthcheck нужен для HEALTHCHECK в docker-compose: в образе нет curl
+func healthcheck(cfg *config.Config) {
+	client := http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%s/healthz", cfg.Port))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "unexpected status %d\n", resp.StatusCode)
+		os.Exit(1)
+	}
+}
@@ -1,49 +1,127 @@
 package main
 
 import (
-	"context"
+	"flag"
 	"fmt"
-	"log"
-	"net/http"
+	"io"
+	"log/slog"
 	"os"
-	"time"
+	"text/tabwriter"
 
 	"github.com/glekoz/test_itk/config"
-	"github.com/glekoz/test_itk/internal/cache"
-	"github.com/glekoz/test_itk/internal/repository"
-	"github.com/glekoz/test_itk/internal/service"
-	"github.com/glekoz/test_itk/internal/web/v1"
+	"github.com/glekoz/test_itk/internal/logging"
+)
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(cfg *config.Config, logger *slog.Logger, args []string)
+}
+
+var commands = []command{
+	{"serve", "[--migrate-on-start]", "start the HTTP server (default)", serve},
+	{"migrate", "up|down|status|version", "manage the database schema", migrate},
+	{"wallet create", "[--owner id]", "create a wallet", walletCreate},
+	{"wallet balance", "<wallet_id>", "show a wallet balance", walletBalance},
+	{"deposit", "<wallet_id> <amount>", "deposit to a wallet", deposit},
+	{"withdraw", "<wallet_id> <amount>", "withdraw from a wallet", withdraw},
+	{"export", "[filters]", "export wallets", export},
+	{"reconcile", "", "compare wallet projections with their event streams", reconcile},
+	{"rebuild-projections", "", "rebuild wallet projections from event streams", func(cfg *config.Config, logger *slog.Logger, _ []string) {
+		rebuildProjections(cfg, logger)
+	}},
+	{"verify-audit", "", "verify audit log hash chains", func(cfg *config.Config, logger *slog.Logger, _ []string) {
+		verifyAudit(cfg, logger)
+	}},
+	{"create-admin-key", "<name>", "issue the first admin API key", createAdminKey},
+	{"revoke-key", "<key_id>", "revoke an API key of any tenant, including platform keys", revokeKey},
+	{"config print", "", "print the effective config with secrets redacted", configPrint},
+	{"healthcheck", "", "check the local server for the container healthcheck", func(cfg *config.Config, _ *slog.Logger, _ []string) {
+		healthcheck(cfg)
+	}},
+}
+
+// нужны serve для перезагрузки конфигурации на лету
+var (
+	configFlags *config.Flags
+	logLevel    slog.LevelVar
 )
 
 func main() {
-	cfg := config.MustLoad()
+	// флаги конфигурации идут до команды: itkapp [--config path] [--cache-ttl 60] <command> ...
+	global := flag.NewFlagSet("itkapp", flag.ExitOnError)
+	global.Usage = func() { usage(global.Output()) }
+	configFlags = config.RegisterFlags(global)
+	global.Parse(os.Args[1:])
 
-	infoLog := log.New(os.Stdout, "[INFO]\t", log.Ldate|log.Ltime)
-	errorLog := log.New(os.Stderr, "[ERROR]\t", log.Ldate|log.Ltime|log.Lshortfile)
+	// без подкоманды запускается сервер, как и раньше
+	name, args := "serve", global.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		usage(os.Stdout)
+		return
+	}
+	// у wallet и config подкоманда входит в имя
+	if len(args) > 0 && findCommand(name+" "+args[0]) != nil {
+		name, args = name+" "+args[0], args[1:]
+	}
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage(os.Stderr)
+		os.Exit(2)
+	}
 
-	repo, err := repository.New(context.Background(), cfg.DatabaseURL)
+	// логгер еще не настроен: уровень берется из конфигурации, поэтому ошибки печатаются как есть
+	cfg, err := configFlags.Load()
 	if err != nil {
-		log.Fatal("can not connect to DB")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// у остальных команд stdout занят результатом, поэтому их логи идут в stderr
+	logOutput := os.Stderr
+	if cmd.name == "serve" {
+		logOutput = os.Stdout
 	}
-	cache, err := cache.New(cfg.CacheTTL)
+	level, err := logging.ParseLevel(cfg.LogLevel)
 	if err != nil {
-		log.Fatal("can not create cache")
+		panic(err)
 	}
-	s := service.New(repo, cache, infoLog, errorLog)
-	server := web.New(s, cfg.Host, infoLog, errorLog)
+	logLevel.Set(level)
+	logger := logging.NewLeveled(logOutput, &logLevel)
+	slog.SetDefault(logger)
 
-	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%s", cfg.Port),
-		ErrorLog:     errorLog,
-		Handler:      server.Routes(),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-	}
+	cmd.run(cfg, logger, args)
+}
 
-	infoLog.Printf("listening :%s", cfg.Port)
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
 
-	if err := srv.ListenAndServe(); err != nil {
-		errorLog.Fatal(err)
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: itkapp [config flags] <command> [flags] [args]")
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s %s\t%s\n", c.name, c.args, c.summary)
 	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `run "itkapp <command> -h" for command flags`)
+	fmt.Fprintln(w, "config flags: --config path and one flag per config key, e.g. --cache-ttl 60 for CACHE_TTL;")
+	fmt.Fprintln(w, `they override the config file and the environment, see "itkapp config print"`)
+}
+
+func fatal(logger *slog.Logger, msg string, err error, attrs ...any) {
+	logger.Error(msg, append([]any{logging.Error(err)}, attrs...)...)
+	os.Exit(1)
 }
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/glekoz/test_itk/config"
+	"github.com/glekoz/test_itk/internal/repository"
+	"github.com/pressly/goose/v3"
+)
+
+// migrate управляет схемой встроенными миграциями: itkapp migrate up|down|status|version
+func migrate(cfg *config.Config, logger *slog.Logger, args []string) {
+	if len(args) != 1 {
+		fatal(logger, "usage: itkapp migrate up|down|status|version", fmt.Errorf("expected 1 argument, got %d", len(args)))
+	}
+
+	repo, err := openRepository(cfg, logger)
+	if err != nil {
+		fatal(logger, "can not connect to DB", err)
+	}
+	defer repo.Close()
+	m, err := repo.Migrator()
+	if err != nil {
+		repo.Close()
+		fatal(logger, "can not create migrator", err)
+	}
+	defer m.Close()
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		err = migrateUp(ctx, m, logger)
+	case "down":
+		var res *goose.MigrationResult
+		res, err = m.Down(ctx)
+		if err == nil {
+			logger.Info("migration rolled back", migrationAttrs(res)...)
+		}
+	case "status":
+		err = printMigrationStatus(ctx, m)
+	case "version":
+		var version int64
+		version, err = m.Version(ctx)
+		if err == nil {
+			fmt.Println(version)
+		}
+	default:
+		err = fmt.Errorf("unknown migrate command %q", args[0])
+	}
+	if err != nil {
+		m.Close()
+		repo.Close()
+		fatal(logger, "migrate "+args[0]+" failed", err)
+	}
+}
+
+// migrateUp применяет новые миграции; его же вызывает сервер при --migrate-on-start
+func migrateUp(ctx context.Context, m *repository.Migrator, logger *slog.Logger) error {
+	results, err := m.Up(ctx)
+	for _, res := range results {
+		logger.Info("migration applied", migrationAttrs(res)...)
+	}
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		logger.Info("no migrations to apply")
+	}
+	return nil
+}
+
+func migrationAttrs(res *goose.MigrationResult) []any {
+	return []any{
+		slog.Int64("version", res.Source.Version),
+		slog.String("direction", res.Direction),
+		slog.Duration("duration", res.Duration),
+	}
+}
+
+func printMigrationStatus(ctx context.Context, m *repository.Migrator) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATE\tAPPLIED AT\tFILE")
+	for _, s := range statuses {
+		appliedAt := "-"
+		if s.State == goose.StateApplied {
+			appliedAt = s.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Source.Version, s.State, appliedAt, s.Source.Path)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// Форматы вывода: таблица для людей, JSON для скриптов
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// printResult печатает v в JSON или rows таблицей с заголовком header
+func printResult(format string, v any, header []string, rows ...[]string) error {
+	if format == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	t := newTable(header)
+	for _, row := range rows {
+		t.row(row...)
+	}
+	return t.flush()
+}
+
+type table struct {
+	w *tabwriter.Writer
+}
+
+func newTable(header []string) *table {
+	t := &table{w: tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)}
+	t.row(header...)
+	return t
+}
+
+func (t *table) row(cells ...string) {
+	fmt.Fprintln(t.w, strings.Join(cells, "\t"))
+}
+
+func (t *table) flush() error {
+	return t.w.Flush()
+}
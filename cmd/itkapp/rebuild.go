@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/glekoz/test_itk/config"
+	"github.com/glekoz/test_itk/internal/events"
+)
+
+// rebuildProjections пересобирает wallets из потока событий: itkapp rebuild-projections
+func rebuildProjections(cfg *config.Config, logger *slog.Logger) {
+	repo, err := openRepository(cfg, logger)
+	if err != nil {
+		fatal(logger, "can not connect to DB", err)
+	}
+	defer repo.Close()
+
+	n, err := events.RebuildProjections(context.Background(), repo)
+	if err != nil {
+		repo.Close()
+		fatal(logger, "rebuilding projections failed", err, slog.Int("wallets", n))
+	}
+	logger.Info("rebuilt projections", slog.Int("wallets", n))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/glekoz/test_itk/config"
+	"github.com/glekoz/test_itk/internal/events"
+)
+
+type mismatchOutput struct {
+	WalletID         string `json:"wallet_id"`
+	Reason           string `json:"reason"`
+	ProjectedBalance int    `json:"projected_balance"`
+	ReplayedBalance  int    `json:"replayed_balance"`
+	ProjectedVersion int64  `json:"projected_version"`
+	ReplayedVersion  int64  `json:"replayed_version"`
+	Error            string `json:"error,omitempty"`
+}
+
+type reconcileOutput struct {
+	Checked    int              `json:"checked"`
+	Mismatches []mismatchOutput `json:"mismatches"`
+}
+
+// reconcile сверяет проекции всех арендаторов с потоками событий: itkapp reconcile.
+// Завершается с кодом 1, если нашлись расхождения; исправляет их rebuild-projections
+func reconcile(cfg *config.Config, logger *slog.Logger, args []string) {
+	f := newFlags("reconcile", "")
+	f.parse(logger, args, 0)
+
+	repo, err := openRepository(cfg, logger)
+	if err != nil {
+		fatal(logger, "can not connect to DB", err)
+	}
+	defer repo.Close()
+
+	checked, mismatches, err := events.Reconcile(context.Background(), repo)
+	if err != nil {
+		repo.Close()
+		fatal(logger, "reconciliation failed", err, slog.Int("checked", checked))
+	}
+
+	out := reconcileOutput{Checked: checked, Mismatches: make([]mismatchOutput, 0, len(mismatches))}
+	rows := make([][]string, 0, len(mismatches))
+	for _, m := range mismatches {
+		out.Mismatches = append(out.Mismatches, mismatchOutput{
+			WalletID:         m.WalletID,
+			Reason:           m.Reason,
+			ProjectedBalance: m.Projection.Balance,
+			ReplayedBalance:  m.Replayed.Balance,
+			ProjectedVersion: m.Projection.Sequence,
+			ReplayedVersion:  m.Replayed.Sequence,
+			Error:            m.Error,
+		})
+		rows = append(rows, []string{
+			m.WalletID,
+			m.Reason,
+			strconv.Itoa(m.Projection.Balance),
+			strconv.Itoa(m.Replayed.Balance),
+			strconv.FormatInt(m.Projection.Sequence, 10),
+			strconv.FormatInt(m.Replayed.Sequence, 10),
+			m.Error,
+		})
+	}
+	if f.output == outputJSON || len(rows) > 0 {
+		err = printResult(f.output, out,
+			[]string{"WALLET ID", "REASON", "PROJECTED BALANCE", "REPLAYED BALANCE", "PROJECTED VERSION", "REPLAYED VERSION", "ERROR"}, rows...)
+		if err != nil {
+			repo.Close()
+			fatal(logger, "printing result failed", err)
+		}
+	}
+	if len(mismatches) > 0 {
+		repo.Close()
+		fatal(logger, "projections differ from event streams", fmt.Errorf("%d of %d wallets mismatch", len(mismatches), checked))
+	}
+	logger.Info("projections match event streams", slog.Int("checked", checked))
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/glekoz/test_itk/config"
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/cache"
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/metrics"
+	"github.com/glekoz/test_itk/internal/outbox"
+	"github.com/glekoz/test_itk/internal/ratelimit"
+	"github.com/glekoz/test_itk/internal/service"
+	"github.com/glekoz/test_itk/internal/shutdown"
+	"github.com/glekoz/test_itk/internal/tracing"
+	"github.com/glekoz/test_itk/internal/web/v1"
+	"github.com/glekoz/test_itk/internal/webhook"
+)
+
+// serve запускает HTTP-сервер и фоновые обработчики: itkapp serve [--migrate-on-start].
+// Без подкоманды бинарник ведет себя так же
+func serve(cfg *config.Config, logger *slog.Logger, args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	migrateOnStart := flags.Bool("migrate-on-start", false, "apply pending migrations before serving")
+	flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracingExporter, cfg.TracingOTLPEndpoint, "itkapp")
+	if err != nil {
+		fatal(logger, "can not set up tracing", err)
+	}
+
+	repo, err := openRepository(cfg, logger)
+	if err != nil {
+		fatal(logger, "can not connect to DB", err)
+	}
+	if *migrateOnStart {
+		m, err := repo.Migrator()
+		if err != nil {
+			fatal(logger, "can not create migrator", err)
+		}
+		err = migrateUp(context.Background(), m, logger)
+		m.Close()
+		if err != nil {
+			fatal(logger, "applying migrations failed", err)
+		}
+	}
+	if err := metrics.RegisterPool(repo.Stat); err != nil {
+		fatal(logger, "can not register pool metrics", err)
+	}
+	balances, err := cache.New(cfg.CacheTTL, cfg.CacheSize)
+	if err != nil {
+		fatal(logger, "can not create cache", err)
+	}
+	balances.SetNegativeTTL(cfg.CacheNegativeTTL)
+	balances.SetStaleTTL(cfg.CacheStaleTTL)
+	invalidator := cache.NewInvalidator(balances, repo, time.Second, logger)
+	s := service.New(repo, balances, logger)
+	server := web.New(s, cfg.Host, logger)
+	var authenticator auth.Authenticator
+	var jwtAuth *auth.JWTAuthenticator
+	switch cfg.AuthMode {
+	case "apikey":
+		authenticator = auth.NewAPIKeyAuthenticator(repo)
+	case "jwt":
+		jwtAuth, err = auth.NewJWTAuthenticator(cfg.JWTJWKSPath, cfg.JWTAudience, cfg.JWTOwnerClaim, logger)
+		if err != nil {
+			fatal(logger, "can not load jwks", err)
+		}
+		authenticator = jwtAuth
+	}
+	// подписанные запросы партнеров принимаются наряду с основным способом
+	if cfg.HMACClientsPath != "" {
+		maxSkew := time.Duration(cfg.HMACMaxSkew) * time.Second
+		nonces, err := cache.NewNonceCache(2 * maxSkew)
+		if err != nil {
+			fatal(logger, "can not create nonce cache", err)
+		}
+		hmacAuth, err := auth.NewHMACAuthenticator(cfg.HMACClientsPath, maxSkew, nonces)
+		if err != nil {
+			fatal(logger, "can not load hmac clients", err)
+		}
+		authenticator = auth.Chain(hmacAuth, authenticator)
+	}
+	if authenticator != nil {
+		server.SetAuthenticator(authenticator)
+	}
+	tenants, err := loadTenants(cfg)
+	if err != nil {
+		fatal(logger, "can not load tenants", err)
+	}
+	server.SetTenants(tenants)
+	if jwtAuth != nil {
+		jwtAuth.SetRequireTenant(cfg.TenantsPath != "")
+	}
+	var pgLimits *ratelimit.PostgresStore
+	limits := rateLimits(cfg)
+	switch cfg.RateLimitStore {
+	case "memory":
+		server.SetRateLimiter(ratelimit.NewMemoryStore(), limits)
+	case "postgres":
+		pgLimits = ratelimit.NewPostgresStore(repo, logger)
+		server.SetRateLimiter(pgLimits, limits)
+	}
+
+	var outboxFile *os.File
+	publishers := outbox.MultiPublisher{webhook.NewPublisher(repo)}
+	switch cfg.OutboxPublisher {
+	case "stdout":
+		publishers = append(publishers, outbox.NewStdoutPublisher())
+	case "file":
+		var p *outbox.WriterPublisher
+		p, outboxFile, err = outbox.NewFilePublisher(cfg.OutboxFilePath)
+		if err != nil {
+			fatal(logger, "can not open outbox file", err)
+		}
+		publishers = append(publishers, p)
+	}
+	relay := outbox.NewRelay(repo, publishers, time.Duration(cfg.OutboxPollInterval)*time.Second, time.Duration(cfg.OutboxRetention)*time.Hour, logger)
+	worker := webhook.New(repo, time.Duration(cfg.WebhookPollInterval)*time.Second, cfg.WebhookMaxAttempts, logger)
+
+	// ключи с тегом reload применяются на лету, каждый компонент подменяет значение атомарно;
+	// лимиты арендаторов из TENANTS_PATH доходят до сервиса через реестр, который подставляет их в контекст запроса
+	reloader := config.NewReloader(cfg, configFlags.Load, func(c *config.Config) error {
+		level, err := logging.ParseLevel(c.LogLevel)
+		if err != nil {
+			return err
+		}
+		tenants, err := loadTenants(c)
+		if err != nil {
+			return err
+		}
+		logLevel.Set(level)
+		balances.SetTTL(c.CacheTTL)
+		balances.SetNegativeTTL(c.CacheNegativeTTL)
+		balances.SetStaleTTL(c.CacheStaleTTL)
+		server.SetRateLimits(rateLimits(c))
+		server.SetTenants(tenants)
+		if jwtAuth != nil {
+			jwtAuth.SetRequireTenant(c.TenantsPath != "")
+		}
+		return nil
+	}, logger)
+
+	server.RegisterCheck("postgres", true, repo.Ping)
+	server.RegisterCheck("migrations", true, repo.CheckMigrations)
+	// без реплики чтение идет с основного сервера, поэтому она не критична
+	server.RegisterCheck("replica", false, repo.CheckReplica)
+	server.RegisterCheck("cache", false, balances.Check)
+	server.RegisterCheck("cache invalidation", false, invalidator.Check)
+	server.RegisterCheck("outbox relay", false, relay.Check)
+
+	// фоновые обработчики живут в собственном контексте: при остановке они гасятся
+	// только после того, как HTTP-сервер дождется текущих запросов
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Add(2)
+	go func() {
+		defer workers.Done()
+		relay.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		worker.Run(workersCtx)
+	}()
+	if cfg.DBReplicaURL != "" {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			repo.MonitorReplica(workersCtx, time.Duration(cfg.DBReplicaCheckInterval)*time.Second)
+		}()
+	}
+	if pgLimits != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			pgLimits.Run(workersCtx, time.Minute)
+		}()
+	}
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		if err := reloader.Watch(workersCtx, configFlags.File()); err != nil {
+			logger.Error("config watcher stopped, config will not be reloaded", logging.Error(err))
+		}
+	}()
+	if jwtAuth != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			if err := jwtAuth.Watch(workersCtx); err != nil {
+				logger.Error("jwks watcher stopped, keys will not be reloaded", logging.Error(err))
+			}
+		}()
+	}
+
+	// подписка кэша останавливается отдельным этапом, после обработчиков, которые еще пишут в базу
+	cacheCtx, stopCache := context.WithCancel(context.Background())
+	invalidatorDone := make(chan struct{})
+	go func() {
+		defer close(invalidatorDone)
+		invalidator.Run(cacheCtx)
+	}()
+
+	srv := &http.Server{
+		Addr:         fmt.Sprintf(":%s", cfg.Port),
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
+		Handler:      server.Routes(),
+		IdleTimeout:  time.Duration(cfg.HTTPIdleTimeout) * time.Second,
+		ReadTimeout:  time.Duration(cfg.HTTPReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cfg.HTTPWriteTimeout) * time.Second,
+	}
+
+	sm := shutdown.New(srv, server, time.Duration(cfg.ShutdownReadinessDelay)*time.Second, time.Duration(cfg.ShutdownTimeout)*time.Second, logger)
+	// ждем обработчиков не дольше общего срока этапов: иначе оркестратор убьет процесс посреди остановки
+	sm.OnShutdown("background workers", func(ctx context.Context) error {
+		stopWorkers()
+		done := make(chan struct{})
+		go func() {
+			workers.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			logger.Warn("background workers are still running, closing the database pool anyway")
+			return ctx.Err()
+		}
+	})
+	sm.OnShutdown("cache", func(ctx context.Context) error {
+		stopCache()
+		select {
+		case <-invalidatorDone:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return s.WaitRefreshes(ctx)
+	})
+	if outboxFile != nil {
+		sm.OnShutdown("outbox file", func(ctx context.Context) error {
+			return outboxFile.Close()
+		})
+	}
+	// пул реплики закрывается вместе с основным. Подписка кэша на изменения кошельков держит
+	// отдельное соединение и закрывается раньше, на этапе cache
+	sm.OnShutdown("database pool", func(ctx context.Context) error {
+		repo.Close()
+		return nil
+	})
+	// последним, чтобы в экспорт попали спаны, завершенные на предыдущих этапах
+	sm.OnShutdown("tracing", shutdownTracing)
+
+	logger.Info("listening", slog.String("addr", srv.Addr))
+
+	if err := sm.Serve(ctx, nil); err != nil {
+		fatal(logger, "server stopped with error", err)
+	}
+}
+
+func rateLimits(cfg *config.Config) web.RateLimits {
+	return web.RateLimits{
+		Client: ratelimit.Limit{Rate: cfg.RateLimitClientRate, Burst: cfg.RateLimitClientBurst},
+		IP:     ratelimit.Limit{Rate: cfg.RateLimitIPRate, Burst: cfg.RateLimitIPBurst},
+		Wallet: ratelimit.Limit{Rate: cfg.RateLimitWalletRate, Burst: cfg.RateLimitWalletBurst},
+	}
+}
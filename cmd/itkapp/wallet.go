@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/glekoz/test_itk/config"
+)
+
+type walletOutput struct {
+	WalletID string `json:"wallet_id"`
+	OwnerID  string `json:"owner_id,omitempty"`
+}
+
+type balanceOutput struct {
+	WalletID string `json:"wallet_id"`
+	Balance  int    `json:"balance"`
+	Currency string `json:"currency"`
+}
+
+// walletCreate: itkapp wallet create [--owner id]
+func walletCreate(cfg *config.Config, logger *slog.Logger, args []string) {
+	f := newFlags("wallet create", "")
+	owner := f.String("owner", "", "wallet owner id; empty - no owner")
+	f.parse(logger, args, 0)
+
+	c := openCLI(cfg, logger, f, *owner)
+	defer c.close()
+	id, err := c.service.CreateWallet(c.ctx)
+	if err != nil {
+		c.fatal("creating wallet failed", err)
+	}
+	err = printResult(f.output, walletOutput{WalletID: id, OwnerID: *owner},
+		[]string{"WALLET ID", "OWNER ID"}, []string{id, *owner})
+	if err != nil {
+		c.fatal("printing result failed", err)
+	}
+}
+
+// walletBalance: itkapp wallet balance <wallet_id>
+func walletBalance(cfg *config.Config, logger *slog.Logger, args []string) {
+	f := newFlags("wallet balance", "<wallet_id>")
+	f.parse(logger, args, 1)
+
+	c := openCLI(cfg, logger, f, "")
+	defer c.close()
+	c.printBalance(f.output, f.Arg(0))
+}
+
+// deposit: itkapp deposit <wallet_id> <amount>
+func deposit(cfg *config.Config, logger *slog.Logger, args []string) {
+	transfer(cfg, logger, "deposit", args)
+}
+
+// withdraw: itkapp withdraw <wallet_id> <amount>
+func withdraw(cfg *config.Config, logger *slog.Logger, args []string) {
+	transfer(cfg, logger, "withdraw", args)
+}
+
+// transfer печатает баланс после операции так же, как wallet balance
+func transfer(cfg *config.Config, logger *slog.Logger, operation string, args []string) {
+	f := newFlags(operation, "<wallet_id> <amount>")
+	f.parse(logger, args, 2)
+	walletID := f.Arg(0)
+	amount, err := strconv.Atoi(f.Arg(1))
+	if err != nil || amount < 1 {
+		fatal(logger, "invalid arguments", fmt.Errorf("amount must be a positive integer, got %q", f.Arg(1)))
+	}
+
+	c := openCLI(cfg, logger, f, "")
+	defer c.close()
+	if operation == "deposit" {
+		err = c.service.Deposit(c.ctx, walletID, amount)
+	} else {
+		err = c.service.Withdraw(c.ctx, walletID, amount)
+	}
+	if err != nil {
+		c.fatal(operation+" failed", err, slog.String("wallet_id", walletID))
+	}
+	c.printBalance(f.output, walletID)
+}
+
+func (c *cli) printBalance(format, walletID string) {
+	balance, err := c.service.GetBalance(c.ctx, walletID)
+	if err != nil {
+		c.fatal("getting balance failed", err, slog.String("wallet_id", walletID))
+	}
+	err = printResult(format, balanceOutput{WalletID: walletID, Balance: balance, Currency: c.tenant.Currency},
+		[]string{"WALLET ID", "BALANCE", "CURRENCY"}, []string{walletID, strconv.Itoa(balance), c.tenant.Currency})
+	if err != nil {
+		c.fatal("printing result failed", err)
+	}
+}
@@ -1,38 +1,140 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 
 	"github.com/spf13/viper"
 )
 
+const (
+	// DefaultPath читается, если путь не задан; его отсутствие не ошибка - хватает значений по умолчанию и окружения
+	DefaultPath = "/etc/itkapp/config.env"
+	// PathEnv задает путь к файлу, если он не передан флагом --config
+	PathEnv = "ITKAPP_CONFIG"
+)
+
+// Config собирается слоями: значения по умолчанию (тег default) < файл < окружение < флаги.
+// Ключи одинаковы во всех слоях; флаг получается из ключа: CACHE_TTL - --cache-ttl.
+// Поля с тегом secret скрываются в itkapp config print, поля с тегом reload меняются без перезапуска (см. Reloader)
 type Config struct {
-	DatabaseURL string `mapstructure:"DATABASE_URL"`
-	Port        string `mapstructure:"ITKAPP_PORT"`
-	Host        string `mapstructure:"HOST"`
-	CacheTTL    int    `mapstructure:"CACHE_TTL"`
-}
+	DatabaseURL string `mapstructure:"DATABASE_URL" secret:"true"`
+	DBMaxConns  int    `mapstructure:"DB_MAX_CONNS" default:"0"` // 0 - значение pgx по умолчанию: max(4, число CPU)
+	DBMinConns  int    `mapstructure:"DB_MIN_CONNS" default:"0"`
+
+	DBMaxConnLifetime   int `mapstructure:"DB_MAX_CONN_LIFETIME" default:"0"`    // в секундах; 0 - значение pgx по умолчанию (1 час)
+	DBMaxConnIdleTime   int `mapstructure:"DB_MAX_CONN_IDLE_TIME" default:"0"`   // в секундах; 0 - 30 минут
+	DBHealthCheckPeriod int `mapstructure:"DB_HEALTH_CHECK_PERIOD" default:"0"`  // в секундах; 0 - 1 минута
+	DBConnectAttempts   int `mapstructure:"DB_CONNECT_ATTEMPTS" default:"5"`     // проверок подключения при запуске
+	DBConnectBackoff    int `mapstructure:"DB_CONNECT_BACKOFF" default:"1"`      // в секундах; пауза перед второй попыткой, дальше удваивается
+	DBStatementTimeout  int `mapstructure:"DB_STATEMENT_TIMEOUT" default:"5000"` // в миллисекундах; для операций с кошельками, 0 - без таймаута
+	DBLockTimeout       int `mapstructure:"DB_LOCK_TIMEOUT" default:"1000"`      // в миллисекундах; ожидание блокировки кошелька, 0 - без таймаута
+
+	DBIsolationLevel string `mapstructure:"DB_ISOLATION_LEVEL" default:"read_committed"` // read_committed, repeatable_read или serializable
+	DBTxMaxAttempts  int    `mapstructure:"DB_TX_MAX_ATTEMPTS" default:"3"`              // попыток транзакции при 40001/40P01; 1 - без повторов
+	DBTxRetryBackoff int    `mapstructure:"DB_TX_RETRY_BACKOFF" default:"20"`            // в миллисекундах; верхняя граница первой паузы, дальше удваивается
+
+	DBReplicaURL           string `mapstructure:"DB_REPLICA_URL" secret:"true"`          // реплика для чтения балансов и истории; пустой - все читается с основного сервера
+	DBReplicaMaxLag        int    `mapstructure:"DB_REPLICA_MAX_LAG" default:"1000"`     // в миллисекундах; при большем отставании чтение идет на основной сервер, 0 - без ограничения
+	DBReplicaCheckInterval int    `mapstructure:"DB_REPLICA_CHECK_INTERVAL" default:"1"` // в секундах; как часто проверяется отставание реплики
+
+	Port             string `mapstructure:"ITKAPP_PORT" default:"8080"`
+	Host             string `mapstructure:"HOST" default:"localhost"`
+	CacheTTL         int    `mapstructure:"CACHE_TTL" default:"30" reload:"true"`
+	CacheSize        int    `mapstructure:"CACHE_SIZE" default:"1024"`                    // число записей, при котором кэш вычищает просроченные
+	CacheNegativeTTL int    `mapstructure:"CACHE_NEGATIVE_TTL" default:"5" reload:"true"` // в секундах; сколько помнить несуществующий кошелек, 0 - не помнить
+	CacheStaleTTL    int    `mapstructure:"CACHE_STALE_TTL" default:"0" reload:"true"`    // в секундах; сколько после CACHE_TTL отдавать баланс, пока он перечитывается в фоне
+	LogLevel         string `mapstructure:"LOG_LEVEL" default:"info" reload:"true"`       // debug, info, warn или error
+
+	HTTPReadTimeout  int `mapstructure:"HTTP_READ_TIMEOUT" default:"5"` // в секундах
+	HTTPWriteTimeout int `mapstructure:"HTTP_WRITE_TIMEOUT" default:"10"`
+	HTTPIdleTimeout  int `mapstructure:"HTTP_IDLE_TIMEOUT" default:"60"`
+
+	WebhookPollInterval int `mapstructure:"WEBHOOK_POLL_INTERVAL" default:"2"`
+	WebhookMaxAttempts  int `mapstructure:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+
+	OutboxPollInterval int    `mapstructure:"OUTBOX_POLL_INTERVAL" default:"1"`
+	OutboxRetention    int    `mapstructure:"OUTBOX_RETENTION" default:"168"`  // в часах
+	OutboxPublisher    string `mapstructure:"OUTBOX_PUBLISHER" default:"none"` // none, stdout или file
+	OutboxFilePath     string `mapstructure:"OUTBOX_FILE_PATH"`                // для OUTBOX_PUBLISHER=file
+
+	ShutdownTimeout        int `mapstructure:"SHUTDOWN_TIMEOUT" default:"25"`        // в секундах
+	ShutdownReadinessDelay int `mapstructure:"SHUTDOWN_READINESS_DELAY" default:"5"` // в секундах
+
+	AuthMode      string `mapstructure:"AUTH_MODE" default:"none"` // none, apikey или jwt
+	JWTJWKSPath   string `mapstructure:"JWT_JWKS_PATH"`            // для AUTH_MODE=jwt; файл перечитывается при изменении
+	JWTAudience   string `mapstructure:"JWT_AUDIENCE"`             // ожидаемое значение aud
+	JWTOwnerClaim string `mapstructure:"JWT_OWNER_CLAIM"`          // claim с владельцем кошельков; пустой - без ограничения
+
+	HMACClientsPath string `mapstructure:"HMAC_CLIENTS_PATH"`           // JSON с клиентами подписанных запросов; пустой - подпись не принимается
+	HMACMaxSkew     int    `mapstructure:"HMAC_MAX_SKEW" default:"300"` // в секундах; допустимое расхождение часов клиента
 
-func MustLoad() *Config {
-	viper.SetConfigName("config")
-	viper.AddConfigPath("/etc/itkapp/")
+	RateLimitStore       string  `mapstructure:"RATE_LIMIT_STORE" default:"none"`                  // none, memory или postgres (общие корзины для нескольких реплик)
+	RateLimitClientRate  float64 `mapstructure:"RATE_LIMIT_CLIENT_RATE" default:"0" reload:"true"` // запросов в секунду; 0 - без ограничения
+	RateLimitClientBurst int     `mapstructure:"RATE_LIMIT_CLIENT_BURST" default:"0" reload:"true"`
+	RateLimitIPRate      float64 `mapstructure:"RATE_LIMIT_IP_RATE" default:"0" reload:"true"`
+	RateLimitIPBurst     int     `mapstructure:"RATE_LIMIT_IP_BURST" default:"0" reload:"true"`
+	RateLimitWalletRate  float64 `mapstructure:"RATE_LIMIT_WALLET_RATE" default:"0" reload:"true"` // только для переводов
+	RateLimitWalletBurst int     `mapstructure:"RATE_LIMIT_WALLET_BURST" default:"0" reload:"true"`
 
-	err := viper.ReadInConfig()
+	TenantsPath     string `mapstructure:"TENANTS_PATH" reload:"true"`     // JSON с арендаторами; пустой - только арендатор по умолчанию
+	DefaultCurrency string `mapstructure:"DEFAULT_CURRENCY" default:"RUB"` // валюта арендатора по умолчанию и арендаторов без своей валюты
 
-	if err != nil {
-		panic(fmt.Errorf("fatal error config file: %w", err))
+	TracingExporter     string `mapstructure:"TRACING_EXPORTER" default:"none"` // none, stdout или otlp
+	TracingOTLPEndpoint string `mapstructure:"TRACING_OTLP_ENDPOINT"`           // для TRACING_EXPORTER=otlp, например http://collector:4318
+}
+
+// Load собирает конфигурацию из всех слоев и возвращает все ошибки проверки сразу.
+// Пустой path означает $ITKAPP_CONFIG или DefaultPath
+func Load(path string, overrides map[string]string) (*Config, error) {
+	v := viper.New()
+	for _, f := range fields() {
+		v.SetDefault(f.key, f.def)
 	}
+	v.AutomaticEnv()
 
-	var config Config
+	if err := readFile(v, path); err != nil {
+		return nil, err
+	}
+	for key, value := range overrides {
+		v.Set(key, value)
+	}
 
-	// Преобразование в структуру
-	if err := viper.Unmarshal(&config); err != nil {
-		panic(fmt.Errorf("unable to decode into struct: %w", err))
+	var config Config
+	if err := v.Unmarshal(&config); err != nil {
+		return nil, fmt.Errorf("decoding config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
+	return &config, nil
+}
 
-	if config.CacheTTL < 1 {
-		panic(fmt.Errorf("cache ttl must be greater than 0"))
+// resolvePath возвращает файл, который будет прочитан; пустая строка - файла по умолчанию нет
+func resolvePath(path string) string {
+	if path == "" {
+		path = os.Getenv(PathEnv)
+	}
+	if path != "" {
+		return path
+	}
+	if _, err := os.Stat(DefaultPath); errors.Is(err, fs.ErrNotExist) {
+		return ""
 	}
+	return DefaultPath
+}
 
-	return &config
+func readFile(v *viper.Viper, path string) error {
+	path = resolvePath(path)
+	if path == "" {
+		return nil
+	}
+	v.SetConfigFile(path)
+	v.SetConfigType("env")
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("reading config file %s: %w", path, err)
+	}
+	return nil
 }
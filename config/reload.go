@@ -0,0 +1,141 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/glekoz/test_itk/internal/logging"
+)
+
+// Reloader перечитывает конфигурацию теми же слоями, что и при запуске, и применяет ключи с тегом reload.
+// Изменения остальных ключей требуют перезапуска: они попадают в лог и не применяются.
+// Невалидная конфигурация отклоняется целиком, действующая остается прежней
+type Reloader struct {
+	mu      sync.Mutex
+	current *Config
+	load    func() (*Config, error)
+	apply   func(*Config) error
+	logger  *slog.Logger
+}
+
+// NewReloader: apply получает новую конфигурацию целиком и должен либо применить ее, либо вернуть ошибку,
+// ничего не изменив, - тогда действующей остается прежняя
+func NewReloader(current *Config, load func() (*Config, error), apply func(*Config) error, logger *slog.Logger) *Reloader {
+	return &Reloader{
+		current: current,
+		load:    load,
+		apply:   apply,
+		logger:  logger,
+	}
+}
+
+// Current возвращает действующую конфигурацию
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload возвращает ключи, которые изменились и применены
+func (r *Reloader) Reload(ctx context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	merged := *r.current
+	cur, nv, mv := reflect.ValueOf(r.current).Elem(), reflect.ValueOf(next).Elem(), reflect.ValueOf(&merged).Elem()
+	var applied, restart []string
+	for _, f := range fields() {
+		if reflect.DeepEqual(cur.Field(f.index).Interface(), nv.Field(f.index).Interface()) {
+			continue
+		}
+		if !f.reload {
+			restart = append(restart, f.key)
+			continue
+		}
+		mv.Field(f.index).Set(nv.Field(f.index))
+		applied = append(applied, f.key)
+	}
+	if len(restart) > 0 {
+		r.logger.WarnContext(ctx, "config changes require restart, ignoring them", slog.Any("keys", restart))
+	}
+	// apply вызывается и без изменившихся ключей: файлы, на которые они указывают (TENANTS_PATH), тоже перечитываются.
+	// Ключи проверяются и вместе с неперезагружаемыми значениями, которые остаются прежними
+	if err := merged.Validate(); err != nil {
+		return nil, err
+	}
+	if err := r.apply(&merged); err != nil {
+		return nil, err
+	}
+	r.current = &merged
+	return applied, nil
+}
+
+// Watch перезагружает конфигурацию по SIGHUP и при изменении file, пока не будет отменен ctx;
+// пустой file - только по сигналу. Как и JWKS, файл отслеживается через каталог
+func (r *Reloader) Watch(ctx context.Context, file string) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if file != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return err
+		}
+		defer watcher.Close()
+		if err := watcher.Add(filepath.Dir(file)); err != nil {
+			return err
+		}
+		events, errs = watcher.Events, watcher.Errors
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			r.reload(ctx, "signal")
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(file) && !strings.HasPrefix(filepath.Base(event.Name), "..") {
+				continue
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+			r.reload(ctx, "file")
+		case err, ok := <-errs:
+			if !ok {
+				return nil
+			}
+			r.logger.ErrorContext(ctx, "config watcher failed", logging.Error(err))
+		}
+	}
+}
+
+func (r *Reloader) reload(ctx context.Context, trigger string) {
+	applied, err := r.Reload(ctx)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "config reload rejected, keeping previous config",
+			slog.String("trigger", trigger), logging.Error(err))
+		return
+	}
+	r.logger.InfoContext(ctx, "config reloaded", slog.String("trigger", trigger), slog.Any("keys", applied))
+}
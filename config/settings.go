@@ -0,0 +1,109 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+type field struct {
+	key    string
+	def    string
+	secret bool
+	reload bool
+	index  int
+}
+
+// fields перечисляет ключи в порядке объявления полей Config
+func fields() []field {
+	t := reflect.TypeFor[Config]()
+	out := make([]field, 0, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		out = append(out, field{
+			key:    f.Tag.Get("mapstructure"),
+			def:    f.Tag.Get("default"),
+			secret: f.Tag.Get("secret") == "true",
+			reload: f.Tag.Get("reload") == "true",
+			index:  i,
+		})
+	}
+	return out
+}
+
+// FlagName: CACHE_TTL - cache-ttl
+func FlagName(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
+
+// Flags - верхний слой конфигурации из командной строки
+type Flags struct {
+	path      string
+	overrides map[string]string
+}
+
+// RegisterFlags добавляет в fs флаг --config и по флагу на каждый ключ
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{overrides: make(map[string]string)}
+	fs.StringVar(&f.path, "config", "", "config file path (default $"+PathEnv+" or "+DefaultPath+")")
+	for _, fl := range fields() {
+		key := fl.key
+		usage := "overrides " + key
+		if fl.def != "" {
+			usage += " (default " + fl.def + ")"
+		}
+		fs.Func(FlagName(key), usage, func(s string) error {
+			f.overrides[key] = s
+			return nil
+		})
+	}
+	return f
+}
+
+// Load собирает конфигурацию с учетом разобранных флагов
+func (f *Flags) Load() (*Config, error) {
+	return Load(f.path, f.overrides)
+}
+
+// File - файл конфигурации, из которого читает Load; пустая строка - файла нет
+func (f *Flags) File() string {
+	return resolvePath(f.path)
+}
+
+// Setting - ключ и его итоговое значение для itkapp config print
+type Setting struct {
+	Key   string
+	Value string
+}
+
+// Settings возвращает все ключи в порядке объявления; секреты скрыты
+func (c *Config) Settings() []Setting {
+	v := reflect.ValueOf(c).Elem()
+	fs := fields()
+	out := make([]Setting, 0, len(fs))
+	for _, f := range fs {
+		value := fmt.Sprint(v.Field(f.index).Interface())
+		if f.secret {
+			value = redact(value)
+		}
+		out = append(out, Setting{Key: f.key, Value: value})
+	}
+	return out
+}
+
+// redact оставляет от URL все, кроме пароля, чтобы по выводу было видно, куда идет подключение;
+// остальные секреты скрываются целиком
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return redacted
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validate проверяет конфигурацию целиком и возвращает все ошибки сразу, каждая начинается с ключа
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if c.DBMaxConns < 0 {
+		add("DB_MAX_CONNS", "can't be negative")
+	}
+	if c.DBMinConns < 0 {
+		add("DB_MIN_CONNS", "can't be negative")
+	}
+	if c.DBMaxConns > 0 && c.DBMinConns > c.DBMaxConns {
+		add("DB_MIN_CONNS", "can't be greater than DB_MAX_CONNS")
+	}
+
+	for _, v := range []struct {
+		key   string
+		value int
+	}{
+		{"DB_MAX_CONN_LIFETIME", c.DBMaxConnLifetime},
+		{"DB_MAX_CONN_IDLE_TIME", c.DBMaxConnIdleTime},
+		{"DB_HEALTH_CHECK_PERIOD", c.DBHealthCheckPeriod},
+		{"DB_STATEMENT_TIMEOUT", c.DBStatementTimeout},
+		{"DB_LOCK_TIMEOUT", c.DBLockTimeout},
+	} {
+		if v.value < 0 {
+			add(v.key, "can't be negative")
+		}
+	}
+	if c.DBConnectAttempts < 1 {
+		add("DB_CONNECT_ATTEMPTS", "must be greater than 0")
+	}
+	if c.DBConnectBackoff < 1 {
+		add("DB_CONNECT_BACKOFF", "must be greater than 0")
+	}
+
+	switch c.DBIsolationLevel {
+	case "read_committed", "repeatable_read", "serializable":
+	default:
+		add("DB_ISOLATION_LEVEL", "unknown isolation level %q", c.DBIsolationLevel)
+	}
+	if c.DBTxMaxAttempts < 1 {
+		add("DB_TX_MAX_ATTEMPTS", "must be greater than 0")
+	}
+	if c.DBTxRetryBackoff < 0 {
+		add("DB_TX_RETRY_BACKOFF", "can't be negative")
+	}
+	if c.DBReplicaMaxLag < 0 {
+		add("DB_REPLICA_MAX_LAG", "can't be negative")
+	}
+	if c.DBReplicaCheckInterval < 1 {
+		add("DB_REPLICA_CHECK_INTERVAL", "must be greater than 0")
+	}
+
+	if c.Port == "" {
+		add("ITKAPP_PORT", "is required")
+	}
+
+	if c.CacheTTL < 1 {
+		add("CACHE_TTL", "must be greater than 0")
+	}
+	if c.CacheSize < 1 {
+		add("CACHE_SIZE", "must be greater than 0")
+	}
+	if c.CacheNegativeTTL < 0 {
+		add("CACHE_NEGATIVE_TTL", "can't be negative")
+	}
+	if c.CacheStaleTTL < 0 {
+		add("CACHE_STALE_TTL", "can't be negative")
+	}
+
+	switch c.LogLevel {
+	case "", "debug", "info", "warn", "error":
+	default:
+		add("LOG_LEVEL", "unknown log level %q", c.LogLevel)
+	}
+
+	for _, v := range []struct {
+		key   string
+		value int
+	}{
+		{"HTTP_READ_TIMEOUT", c.HTTPReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", c.HTTPWriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", c.HTTPIdleTimeout},
+	} {
+		if v.value < 1 {
+			add(v.key, "must be greater than 0")
+		}
+	}
+
+	if c.WebhookPollInterval < 1 {
+		add("WEBHOOK_POLL_INTERVAL", "must be greater than 0")
+	}
+	if c.WebhookMaxAttempts < 1 {
+		add("WEBHOOK_MAX_ATTEMPTS", "must be greater than 0")
+	}
+
+	if c.OutboxPollInterval < 1 {
+		add("OUTBOX_POLL_INTERVAL", "must be greater than 0")
+	}
+	if c.OutboxRetention < 1 {
+		add("OUTBOX_RETENTION", "must be greater than 0")
+	}
+	switch c.OutboxPublisher {
+	case "", "none", "stdout":
+	case "file":
+		if c.OutboxFilePath == "" {
+			add("OUTBOX_FILE_PATH", "is required for file publisher")
+		}
+	default:
+		add("OUTBOX_PUBLISHER", "unknown outbox publisher %q", c.OutboxPublisher)
+	}
+
+	if c.ShutdownTimeout < 1 {
+		add("SHUTDOWN_TIMEOUT", "must be greater than 0")
+	}
+	if c.ShutdownReadinessDelay < 0 {
+		add("SHUTDOWN_READINESS_DELAY", "can't be negative")
+	}
+
+	switch c.AuthMode {
+	case "", "none", "apikey":
+	case "jwt":
+		if c.JWTJWKSPath == "" {
+			add("JWT_JWKS_PATH", "is required for jwt auth mode")
+		}
+		if c.JWTAudience == "" {
+			add("JWT_AUDIENCE", "is required for jwt auth mode")
+		}
+	default:
+		add("AUTH_MODE", "unknown auth mode %q", c.AuthMode)
+	}
+
+	if c.HMACClientsPath != "" {
+		if c.AuthMode == "" || c.AuthMode == "none" {
+			add("HMAC_CLIENTS_PATH", "hmac signing requires auth mode apikey or jwt")
+		}
+		if c.HMACMaxSkew < 1 {
+			add("HMAC_MAX_SKEW", "must be greater than 0")
+		}
+	}
+
+	switch c.RateLimitStore {
+	case "", "none", "memory", "postgres":
+	default:
+		add("RATE_LIMIT_STORE", "unknown rate limit store %q", c.RateLimitStore)
+	}
+	for _, limit := range []struct {
+		name  string
+		rate  float64
+		burst int
+	}{
+		{"CLIENT", c.RateLimitClientRate, c.RateLimitClientBurst},
+		{"IP", c.RateLimitIPRate, c.RateLimitIPBurst},
+		{"WALLET", c.RateLimitWalletRate, c.RateLimitWalletBurst},
+	} {
+		if limit.rate < 0 {
+			add("RATE_LIMIT_"+limit.name+"_RATE", "can't be negative")
+		}
+		if limit.burst < 0 {
+			add("RATE_LIMIT_"+limit.name+"_BURST", "can't be negative")
+		} else if limit.rate > 0 && limit.burst < 1 {
+			add("RATE_LIMIT_"+limit.name+"_BURST", "must be greater than 0 when rate is set")
+		}
+	}
+
+	if c.DefaultCurrency == "" {
+		add("DEFAULT_CURRENCY", "is required")
+	}
+
+	switch c.TracingExporter {
+	case "", "none", "stdout", "otlp":
+	default:
+		add("TRACING_EXPORTER", "unknown tracing exporter %q", c.TracingExporter)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+}
@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Действия, меняющие состояние кошельков
+const (
+	ActionCreateWallet    = "wallet.create"
+	ActionDeposit         = "wallet.deposit"
+	ActionWithdraw        = "wallet.withdraw"
+	ActionAdjustBalance   = "wallet.adjust"
+	ActionSetWalletStatus = "wallet.status"
+)
+
+// AnonymousActor записывается, когда операция пришла без аутентификации
+const AnonymousActor = "anonymous"
+
+// State - значения кошелька до и после действия; пустые поля не пишутся
+type State struct {
+	Balance *int   `json:"balance,omitempty"`
+	Status  string `json:"status,omitempty"`
+	OwnerID string `json:"owner_id,omitempty"`
+}
+
+// Entry - запись журнала аудита. Before пустой у действий, создающих объект
+type Entry struct {
+	ID        int64
+	TenantID  string
+	Actor     string
+	Action    string
+	WalletID  string
+	RequestID string
+	Before    json.RawMessage
+	After     json.RawMessage
+	SourceIP  string
+	Reason    string
+	CreatedAt time.Time
+	PrevHash  string
+	Hash      string
+}
+
+// hashInput фиксирует порядок полей, по которым считается хеш
+type hashInput struct {
+	PrevHash  string          `json:"prev_hash"`
+	TenantID  string          `json:"tenant_id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	WalletID  string          `json:"wallet_id"`
+	RequestID string          `json:"request_id"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	SourceIP  string          `json:"source_ip"`
+	Reason    string          `json:"reason"`
+	CreatedAt string          `json:"created_at"`
+}
+
+// Canonical приводит JSON к виду, который не зависит от того, как его переформатировал JSONB:
+// ключи по алфавиту, без пробелов. Пустой raw остается пустым
+func Canonical(raw []byte) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// MarshalState возвращает канонический JSON состояния; nil означает, что состояния нет
+func MarshalState(s *State) (json.RawMessage, error) {
+	if s == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return Canonical(b)
+}
+
+// ComputeHash считает sha256 записи вместе с хешем предыдущей. ID и сам Hash в него не входят:
+// ID выдает база уже после подсчета
+func (e Entry) ComputeHash() (string, error) {
+	before, err := Canonical(e.Before)
+	if err != nil {
+		return "", fmt.Errorf("before: %w", err)
+	}
+	after, err := Canonical(e.After)
+	if err != nil {
+		return "", fmt.Errorf("after: %w", err)
+	}
+	b, err := json.Marshal(hashInput{
+		PrevHash:  e.PrevHash,
+		TenantID:  e.TenantID,
+		Actor:     e.Actor,
+		Action:    e.Action,
+		WalletID:  e.WalletID,
+		RequestID: e.RequestID,
+		Before:    before,
+		After:     after,
+		SourceIP:  e.SourceIP,
+		Reason:    e.Reason,
+		CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Seal связывает запись с предыдущей в ее цепочке; у первой записи prevHash пустой
+func Seal(e *Entry, prevHash string) error {
+	e.PrevHash = prevHash
+	hash, err := e.ComputeHash()
+	if err != nil {
+		return err
+	}
+	e.Hash = hash
+	return nil
+}
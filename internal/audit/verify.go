@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrChainBroken = errors.New("audit chain is broken")
+
+// BrokenChainError указывает на первую запись, с которой цепочка не сходится
+type BrokenChainError struct {
+	ID       int64
+	TenantID string
+	Reason   string
+}
+
+func (e *BrokenChainError) Error() string {
+	return fmt.Sprintf("%s at entry %d (tenant %s): %s", ErrChainBroken, e.ID, e.TenantID, e.Reason)
+}
+
+func (e *BrokenChainError) Unwrap() error {
+	return ErrChainBroken
+}
+
+// Verifier проверяет записи, поданные в порядке ID. Цепочки кошельков независимы,
+// поэтому записи разных кошельков могут идти вперемешку
+type Verifier struct {
+	wallets map[string]string
+	checked int
+}
+
+func NewVerifier() *Verifier {
+	return &Verifier{wallets: make(map[string]string)}
+}
+
+// Check сверяет ссылку на предыдущую запись и пересчитывает хеш. Измененная запись не сойдется
+// по хешу, удаленная или вставленная - по ссылке следующей записи
+func (v *Verifier) Check(e Entry) error {
+	if e.PrevHash != v.wallets[e.WalletID] {
+		return &BrokenChainError{ID: e.ID, TenantID: e.TenantID, Reason: "previous hash mismatch"}
+	}
+	hash, err := e.ComputeHash()
+	if err != nil {
+		return &BrokenChainError{ID: e.ID, TenantID: e.TenantID, Reason: err.Error()}
+	}
+	if hash != e.Hash {
+		return &BrokenChainError{ID: e.ID, TenantID: e.TenantID, Reason: "hash mismatch"}
+	}
+	v.wallets[e.WalletID] = e.Hash
+	v.checked++
+	return nil
+}
+
+// Checked возвращает число проверенных записей
+func (v *Verifier) Checked() int {
+	return v.checked
+}
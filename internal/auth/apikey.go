@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+	apiKeyPrefix = "itk_"
+	apiKeySize   = 32
+)
+
+type APIKeyStore interface {
+	GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)
+}
+
+type APIKeyAuthenticator struct {
+	store APIKeyStore
+}
+
+func NewAPIKeyAuthenticator(store APIKeyStore) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{store: store}
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return Principal{}, ErrUnauthenticated
+	}
+
+	k, err := a.store.GetAPIKeyByHash(r.Context(), HashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, myerrors.ErrNotFound) {
+			return Principal{}, ErrInvalidCredentials
+		}
+		return Principal{}, err
+	}
+	if k.RevokedAt != nil {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	p := Principal{ID: k.ID, Method: MethodAPIKey, OwnerID: k.OwnerID, TenantID: k.TenantID}
+	for _, s := range k.Scopes {
+		// неизвестные права (например, оставшиеся от удаленных возможностей) просто игнорируются
+		if scope, err := ParseScope(s); err == nil {
+			p.Scopes = append(p.Scopes, scope)
+		}
+	}
+	return p, nil
+}
+
+// GenerateAPIKey возвращает ключ для клиента и хеш для хранения.
+// Ключ случайный и длинный, поэтому медленный хеш вроде bcrypt не нужен и поиск идет по sha256
+func GenerateAPIKey() (key, hash string, err error) {
+	b := make([]byte, apiKeySize)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, HashAPIKey(key), nil
+}
+
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+type Scope string
+
+const (
+	ScopeRead     Scope = "read"
+	ScopeDeposit  Scope = "deposit"
+	ScopeWithdraw Scope = "withdraw"
+	ScopeAdmin    Scope = "admin"
+	// ScopeOperator открывает /admin/v1 для сотрудников поддержки, не давая права выпускать ключи
+	ScopeOperator Scope = "operator"
+)
+
+var (
+	ErrUnauthenticated    = errors.New("credentials are missing")
+	ErrInvalidCredentials = errors.New("credentials are invalid or revoked")
+
+	// ошибки подписанных запросов уточняют ErrInvalidCredentials, чтобы клиент видел причину
+	ErrInvalidSignature = fmt.Errorf("%w: signature mismatch", ErrInvalidCredentials)
+	ErrClockSkew        = fmt.Errorf("%w: request timestamp is outside the allowed window", ErrInvalidCredentials)
+	ErrReplay           = fmt.Errorf("%w: request nonce was already used", ErrInvalidCredentials)
+)
+
+func ParseScope(s string) (Scope, error) {
+	switch scope := Scope(s); scope {
+	case ScopeRead, ScopeDeposit, ScopeWithdraw, ScopeAdmin, ScopeOperator:
+		return scope, nil
+	}
+	return "", fmt.Errorf("unknown scope %q", s)
+}
+
+// Method - способ аутентификации. ID клиентов уникальны только внутри способа:
+// sub из JWT может совпасть с ID API-ключа
+type Method string
+
+const (
+	MethodAPIKey Method = "apikey"
+	MethodJWT    Method = "jwt"
+	MethodHMAC   Method = "hmac"
+)
+
+// Principal - аутентифицированный клиент. Пустой OwnerID - доступ ко всем кошелькам,
+// пустой TenantID - клиент платформы, который выбирает арендатора заголовком
+type Principal struct {
+	ID       string
+	Method   Method
+	OwnerID  string
+	TenantID string
+	Scopes   []Scope
+}
+
+// Has учитывает, что admin включает все остальные права
+func (p Principal) Has(scope Scope) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAdmin)
+}
+
+// CanAccess проверяет владение кошельком с владельцем ownerID
+func (p Principal) CanAccess(ownerID string) bool {
+	return p.OwnerID == "" || p.Has(ScopeAdmin) || p.OwnerID == ownerID
+}
+
+// Authenticator извлекает клиента из запроса. Отсутствие данных - ErrUnauthenticated,
+// неверные данные - ErrInvalidCredentials, прочие ошибки означают сбой проверки
+type Authenticator interface {
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+type chain []Authenticator
+
+// Chain пробует аутентификаторы по очереди. К следующему переходит только при ErrUnauthenticated:
+// неверные данные одного способа не должны проверяться другим
+func Chain(authenticators ...Authenticator) Authenticator {
+	return chain(authenticators)
+}
+
+func (c chain) Authenticate(r *http.Request) (Principal, error) {
+	for _, a := range c {
+		p, err := a.Authenticate(r)
+		if errors.Is(err, ErrUnauthenticated) {
+			continue
+		}
+		return p, err
+	}
+	return Principal{}, ErrUnauthenticated
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext возвращает false, если аутентификация выключена или операция запущена не из HTTP
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/glekoz/test_itk/pkg/signing"
+)
+
+// подписанные запросы - это переводы и управление подписками, большие тела не ожидаются
+const maxSignedBodySize = 1 << 20
+
+// NonceStore запоминает nonce; false - nonce уже использовался
+type NonceStore interface {
+	Use(nonce string) (bool, error)
+}
+
+// hmacClient - запись файла клиентов: секрет хранится открытым, потому что он нужен для проверки подписи
+type hmacClient struct {
+	ID       string   `json:"id"`
+	Secret   string   `json:"secret"`
+	OwnerID  string   `json:"owner_id"`
+	TenantID string   `json:"tenant_id"`
+	Scopes   []string `json:"scopes"`
+}
+
+// HMACAuthenticator проверяет запросы, подписанные пакетом signing
+type HMACAuthenticator struct {
+	clients map[string]hmacClient
+	maxSkew time.Duration
+	nonces  NonceStore
+}
+
+// NewHMACAuthenticator читает клиентов из JSON-файла вида {"clients": [...]}.
+// nonces должен помнить значения не меньше 2*maxSkew: столько длится окно, в котором запрос принимается
+func NewHMACAuthenticator(clientsPath string, maxSkew time.Duration, nonces NonceStore) (*HMACAuthenticator, error) {
+	data, err := os.ReadFile(clientsPath)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Clients []hmacClient `json:"clients"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decoding hmac clients: %w", err)
+	}
+
+	clients := make(map[string]hmacClient, len(file.Clients))
+	for i, c := range file.Clients {
+		if c.ID == "" {
+			return nil, fmt.Errorf("client %d: id is required", i)
+		}
+		if len(c.Secret) < 32 {
+			return nil, fmt.Errorf("client %s: secret must be at least 32 characters", c.ID)
+		}
+		if _, ok := clients[c.ID]; ok {
+			return nil, fmt.Errorf("client %s: duplicate id", c.ID)
+		}
+		clients[c.ID] = c
+	}
+	if len(clients) == 0 {
+		return nil, errors.New("hmac clients file has no clients")
+	}
+
+	return &HMACAuthenticator{
+		clients: clients,
+		maxSkew: maxSkew,
+		nonces:  nonces,
+	}, nil
+}
+
+func (a *HMACAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	clientID := r.Header.Get(signing.HeaderClientID)
+	signature := r.Header.Get(signing.HeaderSignature)
+	if clientID == "" && signature == "" {
+		return Principal{}, ErrUnauthenticated
+	}
+	nonce := r.Header.Get(signing.HeaderNonce)
+	if clientID == "" || signature == "" || nonce == "" {
+		return Principal{}, fmt.Errorf("%w: %s, %s and %s are required", ErrInvalidCredentials,
+			signing.HeaderClientID, signing.HeaderNonce, signing.HeaderSignature)
+	}
+	timestamp, err := strconv.ParseInt(r.Header.Get(signing.HeaderTimestamp), 10, 64)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: invalid %s", ErrInvalidCredentials, signing.HeaderTimestamp)
+	}
+
+	client, ok := a.clients[clientID]
+	if !ok {
+		return Principal{}, ErrInvalidCredentials
+	}
+	if skew := time.Since(time.Unix(timestamp, 0)); skew > a.maxSkew || skew < -a.maxSkew {
+		return Principal{}, ErrClockSkew
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		return Principal{}, err
+	}
+	stringToSign := signing.StringToSign(r.Method, r.URL.RequestURI(), timestamp, nonce, body)
+	if !signing.Verify([]byte(client.Secret), stringToSign, signature) {
+		return Principal{}, ErrInvalidSignature
+	}
+
+	// nonce запоминается только после проверки подписи, иначе чужие запросы могли бы занять его заранее
+	fresh, err := a.nonces.Use(clientID + ":" + nonce)
+	if err != nil {
+		return Principal{}, err
+	}
+	if !fresh {
+		return Principal{}, ErrReplay
+	}
+
+	p := Principal{ID: client.ID, Method: MethodHMAC, OwnerID: client.OwnerID, TenantID: client.TenantID}
+	for _, s := range client.Scopes {
+		if scope, err := ParseScope(s); err == nil {
+			p.Scopes = append(p.Scopes, scope)
+		}
+	}
+	return p, nil
+}
+
+// readBody вычитывает тело для хеша и возвращает его в запрос для обработчика
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxSignedBodySize {
+		return nil, fmt.Errorf("%w: body is too large to sign", ErrInvalidCredentials)
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// jwk - поля RFC 7517, нужные для ключей RSA и EC P-256
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type verificationKey struct {
+	kid string
+	alg string // RS256 или ES256
+	key crypto.PublicKey
+}
+
+// parseJWKS разбирает набор ключей. Ключи других типов и ключи для шифрования пропускаются,
+// но пустой итоговый набор считается ошибкой: с ним не пройдет ни один токен
+func parseJWKS(data []byte) ([]verificationKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("decoding jwks: %w", err)
+	}
+
+	var keys []verificationKey
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			key, err := parseRSAKey(k)
+			if err != nil {
+				return nil, fmt.Errorf("key %d: %w", i, err)
+			}
+			keys = append(keys, verificationKey{kid: k.Kid, alg: algRS256, key: key})
+		case "EC":
+			key, err := parseECKey(k)
+			if err != nil {
+				return nil, fmt.Errorf("key %d: %w", i, err)
+			}
+			keys = append(keys, verificationKey{kid: k.Kid, alg: algES256, key: key})
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks has no RS256 or ES256 signing keys")
+	}
+	return keys, nil
+}
+
+func parseRSAKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("decoding n: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("decoding e: %w", err)
+	}
+	exp := new(big.Int).SetBytes(e)
+	if !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid rsa exponent")
+	}
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}
+	if key.N.BitLen() < 2048 {
+		return nil, errors.New("rsa key is shorter than 2048 bits")
+	}
+	return key, nil
+}
+
+func parseECKey(k jwk) (*ecdsa.PublicKey, error) {
+	if k.Crv != "P-256" {
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("decoding x: %w", err)
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("decoding y: %w", err)
+	}
+	if len(x) > 32 || len(y) > 32 {
+		return nil, errors.New("invalid ec coordinates")
+	}
+	// несжатая точка 0x04 || X || Y; ecdh проверяет, что она лежит на кривой
+	point := make([]byte, 1, 1+2*32)
+	point[0] = 4
+	point = append(point, leftPad(x, 32)...)
+	point = append(point, leftPad(y, 32)...)
+	if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+		return nil, fmt.Errorf("invalid ec point: %w", err)
+	}
+	return &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
+
+func leftPad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
@@ -0,0 +1,278 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/glekoz/test_itk/internal/logging"
+)
+
+const (
+	algRS256 = "RS256"
+	algES256 = "ES256"
+
+	// допустимое расхождение часов с выпустившим токен шлюзом
+	clockLeeway = 30 * time.Second
+)
+
+// JWTAuthenticator проверяет Bearer-токены по ключам из JWKS-файла и перечитывает файл при изменении
+type JWTAuthenticator struct {
+	path          string
+	audience      string
+	ownerClaim    string
+	requireTenant atomic.Bool
+	keys          atomic.Pointer[[]verificationKey]
+	logger        *slog.Logger
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims: права берутся из scope (через пробел, как в RFC 8693) или из массива scopes,
+// владелец кошельков - из claim с именем ownerClaim, арендатор - из tenant_id
+type jwtClaims struct {
+	Sub      string   `json:"sub"`
+	Aud      audience `json:"aud"`
+	Exp      *int64   `json:"exp"`
+	Nbf      *int64   `json:"nbf"`
+	Scope    string   `json:"scope"`
+	Scopes   []string `json:"scopes"`
+	TenantID string   `json:"tenant_id"`
+}
+
+// audience в JWT бывает строкой или массивом строк
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+func NewJWTAuthenticator(jwksPath, audience, ownerClaim string, logger *slog.Logger) (*JWTAuthenticator, error) {
+	a := &JWTAuthenticator{
+		path:       jwksPath,
+		audience:   audience,
+		ownerClaim: ownerClaim,
+		logger:     logger,
+	}
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// SetRequireTenant требует claim tenant_id. Включается, когда заданы арендаторы: токен без арендатора
+// стал бы клиентом платформы, а шлюз, выпускающий токены, всегда знает бренд клиента
+func (a *JWTAuthenticator) SetRequireTenant(require bool) {
+	a.requireTenant.Store(require)
+}
+
+// Reload перечитывает JWKS; при ошибке продолжают действовать прежние ключи
+func (a *JWTAuthenticator) Reload() error {
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+	a.keys.Store(&keys)
+	return nil
+}
+
+// Watch перечитывает JWKS при изменении файла, пока не будет отменен ctx.
+// Следит за каталогом, а не за файлом: при атомарной замене (rename, симлинки ConfigMap)
+// наблюдение за самим файлом теряется
+func (a *JWTAuthenticator) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	dir := filepath.Dir(a.path)
+	if err := watcher.Add(dir); err != nil {
+		return err
+	}
+	// файл мог измениться между загрузкой в конструкторе и началом наблюдения
+	if err := a.Reload(); err != nil {
+		a.logger.ErrorContext(ctx, "jwks reload failed, keeping previous keys", slog.String("path", a.path), logging.Error(err))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(a.path) && !strings.HasPrefix(filepath.Base(event.Name), "..") {
+				continue
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+			if err := a.Reload(); err != nil {
+				a.logger.ErrorContext(ctx, "jwks reload failed, keeping previous keys", slog.String("path", a.path), logging.Error(err))
+				continue
+			}
+			a.logger.InfoContext(ctx, "jwks reloaded", slog.String("path", a.path))
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			a.logger.ErrorContext(ctx, "jwks watcher failed", logging.Error(err))
+		}
+	}
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return Principal{}, ErrUnauthenticated
+	}
+	return a.verify(token, time.Now())
+}
+
+func (a *JWTAuthenticator) verify(token string, now time.Time) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, fmt.Errorf("%w: malformed header", ErrInvalidCredentials)
+	}
+	// алгоритм проверяется до выбора ключа, чтобы токен не мог навязать none или HS256
+	if header.Alg != algRS256 && header.Alg != algES256 {
+		return Principal{}, fmt.Errorf("%w: unsupported alg %q", ErrInvalidCredentials, header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: malformed signature", ErrInvalidCredentials)
+	}
+	if !a.verifySignature(header, parts[0]+"."+parts[1], sig) {
+		return Principal{}, fmt.Errorf("%w: invalid signature", ErrInvalidCredentials)
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, fmt.Errorf("%w: malformed claims", ErrInvalidCredentials)
+	}
+	if claims.Exp == nil || now.After(time.Unix(*claims.Exp, 0).Add(clockLeeway)) {
+		return Principal{}, fmt.Errorf("%w: token expired", ErrInvalidCredentials)
+	}
+	if claims.Nbf != nil && now.Add(clockLeeway).Before(time.Unix(*claims.Nbf, 0)) {
+		return Principal{}, fmt.Errorf("%w: token not valid yet", ErrInvalidCredentials)
+	}
+	if !slices.Contains(claims.Aud, a.audience) {
+		return Principal{}, fmt.Errorf("%w: unexpected audience", ErrInvalidCredentials)
+	}
+	if claims.Sub == "" {
+		return Principal{}, fmt.Errorf("%w: sub is required", ErrInvalidCredentials)
+	}
+	if claims.TenantID == "" && a.requireTenant.Load() {
+		return Principal{}, fmt.Errorf("%w: tenant_id is required", ErrInvalidCredentials)
+	}
+
+	p := Principal{ID: claims.Sub, Method: MethodJWT, TenantID: claims.TenantID}
+	for _, s := range append(strings.Fields(claims.Scope), claims.Scopes...) {
+		if scope, err := ParseScope(s); err == nil && !slices.Contains(p.Scopes, scope) {
+			p.Scopes = append(p.Scopes, scope)
+		}
+	}
+	owner, err := a.owner(parts[1])
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %s", ErrInvalidCredentials, err)
+	}
+	// пустой OwnerID означает доступ ко всем кошелькам, поэтому без владельца пускаем только администратора
+	if a.ownerClaim != "" && owner == "" && !p.Has(ScopeAdmin) {
+		return Principal{}, fmt.Errorf("%w: %s is required", ErrInvalidCredentials, a.ownerClaim)
+	}
+	p.OwnerID = owner
+	return p, nil
+}
+
+// owner читается отдельно, потому что имя claim задается в конфигурации
+func (a *JWTAuthenticator) owner(segment string) (string, error) {
+	if a.ownerClaim == "" {
+		return "", nil
+	}
+	var raw map[string]json.RawMessage
+	if err := decodeSegment(segment, &raw); err != nil {
+		return "", err
+	}
+	v, ok := raw[a.ownerClaim]
+	if !ok {
+		return "", nil
+	}
+	var owner string
+	if err := json.Unmarshal(v, &owner); err != nil {
+		return "", fmt.Errorf("%s must be a string", a.ownerClaim)
+	}
+	return owner, nil
+}
+
+func (a *JWTAuthenticator) verifySignature(header jwtHeader, signed string, sig []byte) bool {
+	digest := sha256.Sum256([]byte(signed))
+	for _, k := range *a.keys.Load() {
+		if k.alg != header.Alg || (header.Kid != "" && k.kid != header.Kid) {
+			continue
+		}
+		switch key := k.key.(type) {
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil {
+				return true
+			}
+		case *ecdsa.PublicKey:
+			// ES256 - это r || s по 32 байта, а не ASN.1
+			if len(sig) != 64 {
+				return false
+			}
+			r := new(big.Int).SetBytes(sig[:32])
+			s := new(big.Int).SetBytes(sig[32:])
+			if ecdsa.Verify(key, digest[:], r, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -1,35 +1,243 @@
 package cache
 
 import (
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/glekoz/cache"
+	"github.com/glekoz/test_itk/internal/metrics"
+	"github.com/glekoz/test_itk/internal/shared/consistency"
 )
 
+// Entry - результат Lookup
+type Entry struct {
+	Balance  int
+	NotFound bool // закэшировано отсутствие кошелька
+	Stale    bool // ttl истек, но запись еще в окне stale-while-revalidate
+}
+
+type entry struct {
+	balance    int
+	notFound   bool
+	freshUntil time.Time
+}
+
+// число корзин, по которым ведутся поколения ключей: память не растет с числом кошельков,
+// а совпадение корзин у двух ключей лишь отменяет лишнее заполнение кэша
+const generationStripes = 256
+
+// stripe - поколение ключей корзины; растет при каждом изменении баланса или удалении записи.
+// lsn - наибольшая позиция WAL, с которой ключи корзины вытеснялись по уведомлениям
+type stripe struct {
+	mu  sync.Mutex
+	gen uint64
+	lsn consistency.LSN
+}
+
 type Cache struct {
-	c   *cache.Cache[string, int]
-	ttl time.Duration
+	c    atomic.Pointer[cache.Cache[string, entry]] // заменяется целиком при Flush
+	size int
+
+	// поколения не дают чтению, начатому до удаления записи, вернуть в кэш прочитанное им старое значение.
+	// Flush берет flushMu на запись, чтобы заполнение не попало в новый кэш, проверив поколение по старому
+	flushMu sync.RWMutex
+	epoch   uint64          // под flushMu; растет при Flush
+	flushed consistency.LSN // под flushMu; позиция WAL последнего Flush
+	seed    maphash.Seed
+	stripes [generationStripes]stripe
+
+	// time.Duration; меняются при перезагрузке конфигурации
+	ttl         atomic.Int64
+	negativeTTL atomic.Int64
+	staleTTL    atomic.Int64
 }
 
-func New(ttl int) (*Cache, error) {
-	c, err := cache.New[string, int]()
+// New: ttl в секундах; size - число записей, при котором кэш вычищает просроченные.
+// Отсутствие кошельков и устаревшие записи не кэшируются, пока не заданы SetNegativeTTL и SetStaleTTL
+func New(ttl, size int) (*Cache, error) {
+	c, err := cache.New[string, entry](cache.WithCacheSize(size))
 	if err != nil {
 		return nil, err
 	}
-	return &Cache{
-		c:   c,
-		ttl: time.Duration(ttl) * time.Second,
-	}, nil
+	cc := &Cache{size: size, seed: maphash.MakeSeed()}
+	cc.c.Store(c)
+	cc.SetTTL(ttl)
+	return cc, nil
+}
+
+// SetTTL действует на новые записи; уже закэшированные доживают со старым ttl
+func (c *Cache) SetTTL(ttl int) {
+	c.ttl.Store(int64(time.Duration(ttl) * time.Second))
+}
+
+func (c *Cache) TTL() time.Duration {
+	return time.Duration(c.ttl.Load())
+}
+
+// SetNegativeTTL - сколько секунд помнить, что кошелька нет; 0 - не помнить
+func (c *Cache) SetNegativeTTL(ttl int) {
+	c.negativeTTL.Store(int64(time.Duration(ttl) * time.Second))
+}
+
+// SetStaleTTL - сколько секунд после ttl баланс еще отдается, пока перечитывается в фоне; 0 - не отдается
+func (c *Cache) SetStaleTTL(ttl int) {
+	c.staleTTL.Store(int64(time.Duration(ttl) * time.Second))
+}
+
+// Add кладет баланс после записи в базу. Чтения, начатые до нее, свой результат в кэш уже не положат
+func (c *Cache) Add(tenantID, walletID string, balance int) error {
+	k := key(tenantID, walletID)
+	c.flushMu.RLock()
+	defer c.flushMu.RUnlock()
+	s := c.stripe(k)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.gen++
+	return c.addBalance(k, balance)
+}
+
+// Generation запоминается до чтения баланса из базы и передается в Fill или AddNotFound
+func (c *Cache) Generation(tenantID, walletID string) uint64 {
+	k := key(tenantID, walletID)
+	c.flushMu.RLock()
+	defer c.flushMu.RUnlock()
+	s := c.stripe(k)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// оба счетчика только растут, поэтому сумма не меняется, только если не изменился ни один
+	return c.epoch + s.gen
+}
+
+// Fill кладет прочитанный из базы баланс, если с момента Generation запись не удалялась и не заменялась:
+// иначе прочитанное значение могло устареть раньше, чем попало в кэш
+func (c *Cache) Fill(tenantID, walletID string, balance int, gen uint64) error {
+	return c.fill(key(tenantID, walletID), gen, func(k string) error {
+		return c.addBalance(k, balance)
+	})
+}
+
+// AddNotFound запоминает, что кошелька нет, чтобы запросы несуществующих кошельков не доходили до базы.
+// gen - как у Fill: кошелек, созданный после Generation, не будет записан отсутствующим
+func (c *Cache) AddNotFound(tenantID, walletID string, gen uint64) error {
+	ttl := time.Duration(c.negativeTTL.Load())
+	if ttl <= 0 {
+		return nil
+	}
+	return c.fill(key(tenantID, walletID), gen, func(k string) error {
+		return c.c.Load().Add(k, entry{
+			notFound:   true,
+			freshUntil: time.Now().Add(ttl),
+		}, ttl)
+	})
+}
+
+func (c *Cache) fill(k string, gen uint64, add func(k string) error) error {
+	c.flushMu.RLock()
+	defer c.flushMu.RUnlock()
+	s := c.stripe(k)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c.epoch+s.gen != gen {
+		metrics.CacheDroppedFills.Inc()
+		return nil
+	}
+	return add(k)
 }
 
-func (c *Cache) Add(walletID string, balance int) error {
-	return c.c.Add(walletID, balance, c.ttl)
+func (c *Cache) addBalance(k string, balance int) error {
+	ttl := c.TTL()
+	return c.c.Load().Add(k, entry{
+		balance:    balance,
+		freshUntil: time.Now().Add(ttl),
+	}, ttl+time.Duration(c.staleTTL.Load()))
+}
+
+// Lookup возвращает false, если записи нет или она уже не годится даже как устаревшая
+func (c *Cache) Lookup(tenantID, walletID string) (Entry, bool) {
+	e, ok := c.c.Load().Get(key(tenantID, walletID))
+	switch {
+	case !ok:
+		metrics.CacheRequests.WithLabelValues(metrics.CacheMiss).Inc()
+		return Entry{}, false
+	case e.notFound:
+		metrics.CacheRequests.WithLabelValues(metrics.CacheNegativeHit).Inc()
+		return Entry{NotFound: true}, true
+	case time.Now().After(e.freshUntil):
+		metrics.CacheRequests.WithLabelValues(metrics.CacheStale).Inc()
+		return Entry{Balance: e.balance, Stale: true}, true
+	}
+	metrics.CacheRequests.WithLabelValues(metrics.CacheHit).Inc()
+	return Entry{Balance: e.balance}, true
+}
+
+// Get возвращает только свежий баланс
+func (c *Cache) Get(tenantID, walletID string) (int, bool) {
+	e, ok := c.Lookup(tenantID, walletID)
+	if !ok || e.NotFound || e.Stale {
+		return 0, false
+	}
+	return e.Balance, true
+}
+
+func (c *Cache) Delete(tenantID, walletID string) {
+	c.Evict(tenantID, walletID, 0)
+}
+
+// Evict удаляет запись, измененную в базе на позиции WAL lsn. Реплика, которая до нее не дошла,
+// вернула бы старый баланс, поэтому FillLSN для ключа будет не меньше lsn
+func (c *Cache) Evict(tenantID, walletID string, lsn consistency.LSN) {
+	k := key(tenantID, walletID)
+	c.flushMu.RLock()
+	defer c.flushMu.RUnlock()
+	s := c.stripe(k)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.gen++
+	s.lsn = max(s.lsn, lsn)
+	c.c.Load().Delete(k)
+	metrics.CacheEvictions.Inc()
+}
+
+// FillLSN - позиция WAL, до которой должна дойти реплика, чтобы прочитанный с нее баланс можно было положить
+// в кэш. Берется после Generation: вытеснение после нее все равно отменит заполнение
+func (c *Cache) FillLSN(tenantID, walletID string) consistency.LSN {
+	k := key(tenantID, walletID)
+	c.flushMu.RLock()
+	defer c.flushMu.RUnlock()
+	s := c.stripe(k)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return max(c.flushed, s.lsn)
+}
+
+// Flush сбрасывает все записи: после обрыва подписки на изменения неизвестно, какие из них устарели.
+// lsn - позиция WAL, которую не превышают пропущенные изменения; 0 - позиция неизвестна
+func (c *Cache) Flush(lsn consistency.LSN) error {
+	fresh, err := cache.New[string, entry](cache.WithCacheSize(c.size))
+	if err != nil {
+		return err
+	}
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+	c.c.Store(fresh)
+	c.epoch++
+	c.flushed = max(c.flushed, lsn)
+	metrics.CacheFlushes.Inc()
+	return nil
 }
 
-func (c *Cache) Get(walletID string) (int, bool) {
-	return c.c.Get(walletID)
+func (c *Cache) stripe(k string) *stripe {
+	return &c.stripes[maphash.String(c.seed, k)%generationStripes]
 }
 
-func (c *Cache) Delete(walletID string) {
-	c.c.Delete(walletID)
+// key разделяет записи арендаторов; "/" не встречается ни в идентификаторе арендатора, ни в UUID кошелька
+func key(tenantID, walletID string) string {
+	return tenantID + "/" + walletID
 }
@@ -0,0 +1,21 @@
+package cache
+
+import (
+	"context"
+	"errors"
+)
+
+const healthKey = "__healthcheck__"
+
+// Check записывает и читает служебный ключ, проверяя, что кэш принимает записи
+func (c *Cache) Check(ctx context.Context) error {
+	cc := c.c.Load()
+	if err := cc.Add(healthKey, entry{}, c.TTL()); err != nil {
+		return err
+	}
+	defer cc.Delete(healthKey)
+	if _, ok := cc.Get(healthKey); !ok {
+		return errors.New("cache lost a freshly written key")
+	}
+	return nil
+}
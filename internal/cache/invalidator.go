@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/shared/consistency"
+)
+
+// maxReconnectBackoff ограничивает паузу между попытками переподключения, которая удваивается с каждой неудачей
+const maxReconnectBackoff = 30 * time.Second
+
+// ListenerAPI - подписка на изменения кошельков, которые сделали другие экземпляры сервиса
+type ListenerAPI interface {
+	ListenWalletChanges(ctx context.Context, ready func(lsn consistency.LSN), handle func(tenantID, walletID string, lsn consistency.LSN)) error
+}
+
+// Invalidator удаляет из кэша балансы кошельков, измененных через другие экземпляры.
+// Пока подписки нет, уведомления теряются, поэтому после каждого (пере)подключения кэш сбрасывается целиком.
+// Вместе с записью кэш запоминает позицию WAL изменения, чтобы не заполниться с отстающей реплики
+type Invalidator struct {
+	cache     *Cache
+	listener  ListenerAPI
+	backoff   time.Duration
+	connected atomic.Bool
+	logger    *slog.Logger
+}
+
+// NewInvalidator: backoff - пауза перед первой попыткой переподключения
+func NewInvalidator(cache *Cache, listener ListenerAPI, backoff time.Duration, logger *slog.Logger) *Invalidator {
+	return &Invalidator{
+		cache:    cache,
+		listener: listener,
+		backoff:  backoff,
+		logger:   logger,
+	}
+}
+
+// Run держит подписку, пока не отменен ctx, и переподключается после обрыва
+func (i *Invalidator) Run(ctx context.Context) {
+	backoff := i.backoff
+	for {
+		err := i.listener.ListenWalletChanges(ctx, func(lsn consistency.LSN) {
+			if err := i.cache.Flush(lsn); err != nil {
+				i.logger.ErrorContext(ctx, "flushing balance cache failed", logging.Error(err))
+			}
+			i.connected.Store(true)
+			backoff = i.backoff
+			i.logger.InfoContext(ctx, "listening for wallet changes, balance cache flushed")
+		}, func(tenantID, walletID string, lsn consistency.LSN) {
+			i.cache.Evict(tenantID, walletID, lsn)
+		})
+		i.connected.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+		i.logger.WarnContext(ctx, "wallet change listener disconnected, reconnecting",
+			slog.Duration("backoff", backoff), logging.Error(err))
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, maxReconnectBackoff)
+	}
+}
+
+// Check сообщает, что подписки нет: балансы, измененные другими экземплярами, могут отдаваться из кэша до истечения ttl
+func (i *Invalidator) Check(ctx context.Context) error {
+	if !i.connected.Load() {
+		return errors.New("wallet change listener is not connected")
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/glekoz/cache"
+)
+
+// NonceCache запоминает nonce подписанных запросов, чтобы повтор в пределах ttl отклонялся
+type NonceCache struct {
+	mu  sync.Mutex // Get и Add в библиотеке не атомарны вместе
+	c   *cache.Cache[string, struct{}]
+	ttl time.Duration
+}
+
+func NewNonceCache(ttl time.Duration) (*NonceCache, error) {
+	c, err := cache.New[string, struct{}]()
+	if err != nil {
+		return nil, err
+	}
+	return &NonceCache{
+		c:   c,
+		ttl: ttl,
+	}, nil
+}
+
+// Use запоминает nonce и возвращает false, если он уже встречался
+func (n *NonceCache) Use(nonce string) (bool, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.c.Get(nonce); ok {
+		return false, nil
+	}
+	if err := n.c.Add(nonce, struct{}{}, n.ttl); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,123 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+)
+
+type Type string
+
+const (
+	TypeWalletCreated Type = "WalletCreated"
+	TypeDeposited     Type = "Deposited"
+	TypeWithdrawn     Type = "Withdrawn"
+	TypeAdjusted      Type = "Adjusted"
+	TypeStatusChanged Type = "StatusChanged"
+)
+
+// Event - доменное событие кошелька. При изменении схемы payload конкретного события
+// нужно поднять его версию и научить Decode читать старые версии
+type Event interface {
+	Type() Type
+	Version() int
+}
+
+// WalletCreated v2 несет владельца кошелька; в v1 payload пустой, владелец у таких кошельков не восстанавливается
+type WalletCreated struct {
+	OwnerID string `json:"owner_id,omitempty"`
+}
+
+func (WalletCreated) Type() Type   { return TypeWalletCreated }
+func (WalletCreated) Version() int { return 2 }
+
+type Deposited struct {
+	TransactionID string `json:"transaction_id"`
+	Amount        int    `json:"amount"`
+}
+
+func (Deposited) Type() Type   { return TypeDeposited }
+func (Deposited) Version() int { return 1 }
+
+type Withdrawn struct {
+	TransactionID string `json:"transaction_id"`
+	Amount        int    `json:"amount"`
+}
+
+func (Withdrawn) Type() Type   { return TypeWithdrawn }
+func (Withdrawn) Version() int { return 1 }
+
+// Adjusted - корректировка баланса оператором; Amount со знаком
+type Adjusted struct {
+	TransactionID string `json:"transaction_id"`
+	Amount        int    `json:"amount"`
+	Reason        string `json:"reason"`
+}
+
+func (Adjusted) Type() Type   { return TypeAdjusted }
+func (Adjusted) Version() int { return 1 }
+
+// StatusChanged - заморозка или разморозка кошелька оператором
+type StatusChanged struct {
+	Status myvars.WalletStatus `json:"status"`
+	Reason string              `json:"reason,omitempty"`
+}
+
+func (StatusChanged) Type() Type   { return TypeStatusChanged }
+func (StatusChanged) Version() int { return 1 }
+
+// Record - событие вместе с положением в потоке кошелька
+type Record struct {
+	WalletID   string
+	Sequence   int64
+	Event      Event
+	OccurredAt time.Time
+}
+
+func Encode(e Event) ([]byte, error) {
+	return json.Marshal(e)
+}
+
+func Decode(t Type, version int, payload []byte) (Event, error) {
+	var e Event
+	switch t {
+	case TypeWalletCreated:
+		e = &WalletCreated{}
+	case TypeDeposited:
+		e = &Deposited{}
+	case TypeWithdrawn:
+		e = &Withdrawn{}
+	case TypeAdjusted:
+		e = &Adjusted{}
+	case TypeStatusChanged:
+		e = &StatusChanged{}
+	default:
+		return nil, fmt.Errorf("unknown event type %q", t)
+	}
+	if version != e.Version() && !(t == TypeWalletCreated && version == 1) {
+		return nil, fmt.Errorf("unsupported version %d of event %q", version, t)
+	}
+	if err := json.Unmarshal(payload, e); err != nil {
+		return nil, fmt.Errorf("decoding event %q: %w", t, err)
+	}
+	return deref(e), nil
+}
+
+// deref возвращает событие по значению, чтобы type switch в Apply не зависел от способа декодирования
+func deref(e Event) Event {
+	switch v := e.(type) {
+	case *WalletCreated:
+		return *v
+	case *Deposited:
+		return *v
+	case *Withdrawn:
+		return *v
+	case *Adjusted:
+		return *v
+	case *StatusChanged:
+		return *v
+	}
+	return e
+}
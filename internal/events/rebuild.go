@@ -0,0 +1,28 @@
+package events
+
+import "context"
+
+type ProjectionStore interface {
+	ListEventStreamIDs(ctx context.Context) ([]string, error)
+	// RebuildWalletProjection под блокировкой кошелька читает его поток событий,
+	// передает в replay и записывает результат в проекцию
+	RebuildWalletProjection(ctx context.Context, walletID string, replay func(records []Record) (Wallet, error)) error
+}
+
+// RebuildProjections пересобирает проекции всех кошельков, у которых есть события,
+// и возвращает количество пересобранных кошельков
+func RebuildProjections(ctx context.Context, store ProjectionStore) (int, error) {
+	ids, err := store.ListEventStreamIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, id := range ids {
+		err := store.RebuildWalletProjection(ctx, id, func(records []Record) (Wallet, error) {
+			return Replay(id, records)
+		})
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(ids), nil
+}
@@ -0,0 +1,62 @@
+package events
+
+import (
+	"context"
+	"errors"
+
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+)
+
+type ReconcileStore interface {
+	ListEventStreamIDs(ctx context.Context) ([]string, error)
+	// LoadWalletState читает поток событий и проекцию кошелька из одного снимка базы,
+	// чтобы параллельные переводы не выглядели как расхождение. Нет проекции - ErrNotFound
+	LoadWalletState(ctx context.Context, walletID string) ([]Record, Wallet, error)
+}
+
+// Причины расхождения проекции с потоком событий
+const (
+	MismatchMissingProjection = "missing projection"
+	MismatchBalance           = "balance mismatch"
+	MismatchVersion           = "version mismatch"
+	MismatchReplayFailed      = "replay failed"
+)
+
+// Mismatch - кошелек, проекция которого разошлась с потоком событий
+type Mismatch struct {
+	WalletID   string
+	Reason     string
+	Projection Wallet // пустая, если проекции нет
+	Replayed   Wallet // пустой, если поток не воспроизводится
+	Error      string // причина, по которой поток не воспроизводится
+}
+
+// Reconcile сверяет проекции всех кошельков, у которых есть события, с потоком событий
+// и ничего не исправляет: исправляет RebuildProjections. Возвращает количество проверенных кошельков
+func Reconcile(ctx context.Context, store ReconcileStore) (int, []Mismatch, error) {
+	ids, err := store.ListEventStreamIDs(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	var mismatches []Mismatch
+	for i, id := range ids {
+		records, projection, err := store.LoadWalletState(ctx, id)
+		if err != nil {
+			if !errors.Is(err, myerrors.ErrNotFound) {
+				return i, mismatches, err
+			}
+			mismatches = append(mismatches, Mismatch{WalletID: id, Reason: MismatchMissingProjection})
+			continue
+		}
+		replayed, err := Replay(id, records)
+		switch {
+		case err != nil:
+			mismatches = append(mismatches, Mismatch{WalletID: id, Reason: MismatchReplayFailed, Projection: projection, Error: err.Error()})
+		case replayed.Balance != projection.Balance:
+			mismatches = append(mismatches, Mismatch{WalletID: id, Reason: MismatchBalance, Projection: projection, Replayed: replayed})
+		case replayed.Sequence != projection.Sequence:
+			mismatches = append(mismatches, Mismatch{WalletID: id, Reason: MismatchVersion, Projection: projection, Replayed: replayed})
+		}
+	}
+	return len(ids), mismatches, nil
+}
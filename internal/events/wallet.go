@@ -0,0 +1,68 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+)
+
+var ErrSequenceGap = errors.New("event sequence is broken")
+
+// Wallet - агрегат кошелька, восстановленный из потока событий
+type Wallet struct {
+	ID        string
+	Balance   int
+	OwnerID   string
+	Status    myvars.WalletStatus
+	Sequence  int64 // номер последнего примененного события, он же версия проекции
+	CreatedAt time.Time
+}
+
+func (w *Wallet) Apply(r Record) error {
+	if r.Sequence != w.Sequence+1 {
+		return fmt.Errorf("%w: wallet %s expected %d, got %d", ErrSequenceGap, w.ID, w.Sequence+1, r.Sequence)
+	}
+
+	switch e := r.Event.(type) {
+	case WalletCreated:
+		if w.Sequence != 0 {
+			return fmt.Errorf("%w: wallet %s created twice", ErrSequenceGap, w.ID)
+		}
+		w.ID = r.WalletID
+		w.OwnerID = e.OwnerID
+		w.Status = myvars.WalletStatusActive
+		w.CreatedAt = r.OccurredAt
+	case Deposited:
+		w.Balance += e.Amount
+	case Withdrawn:
+		if w.Balance < e.Amount {
+			return myerrors.ErrNegativeAmount
+		}
+		w.Balance -= e.Amount
+	case Adjusted:
+		if w.Balance+e.Amount < 0 {
+			return myerrors.ErrNegativeAmount
+		}
+		w.Balance += e.Amount
+	case StatusChanged:
+		w.Status = e.Status
+	default:
+		return fmt.Errorf("unexpected event %T", r.Event)
+	}
+	w.Sequence = r.Sequence
+	return nil
+}
+
+// Replay собирает состояние кошелька с нуля
+func Replay(walletID string, records []Record) (Wallet, error) {
+	w := Wallet{ID: walletID}
+	for _, r := range records {
+		if err := w.Apply(r); err != nil {
+			return Wallet{}, err
+		}
+	}
+	return w, nil
+}
@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // упала некритичная проверка, экземпляр продолжает принимать трафик
+	StatusFailed   = "failed"
+
+	StatusShuttingDown = "shutting_down"
+)
+
+type CheckFunc func(ctx context.Context) error
+
+type CheckResult struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	critical bool
+}
+
+// Registry хранит проверки зависимостей; подсистемы регистрируют свои проверки при старте
+type Registry struct {
+	mu      sync.RWMutex
+	checks  []check
+	timeout time.Duration
+}
+
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{timeout: timeout}
+}
+
+// Register добавляет проверку; при ошибке критичной проверки экземпляр считается неготовым
+func (r *Registry) Register(name string, critical bool, fn CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check{name: name, fn: fn, critical: critical})
+}
+
+// Run выполняет все проверки параллельно, каждую - не дольше timeout
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make([]check, len(r.checks))
+	copy(checks, r.checks)
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+	for i, c := range checks {
+		res := results[i]
+		report.Checks[c.name] = res
+		if res.Status == StatusOK {
+			continue
+		}
+		if c.critical {
+			report.Status = StatusFailed
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, c check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.fn(ctx)
+	res := CheckResult{
+		Status:    StatusOK,
+		Critical:  c.critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		res.Status = StatusFailed
+		res.Error = err.Error()
+	}
+	return res
+}
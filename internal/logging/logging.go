@@ -0,0 +1,124 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Имена полей общие для всех компонентов, чтобы по ним можно было фильтровать логи
+const (
+	KeyRequestID = "request_id"
+	KeyTenantID  = "tenant_id"
+	KeyWalletID  = "wallet_id"
+	KeyOperation = "operation"
+	KeyAmount    = "amount"
+	KeyStatus    = "status"
+	KeyDuration  = "duration"
+	KeyError     = "error"
+)
+
+// New создает JSON-логгер с минимальным уровнем level (debug, info, warn, error)
+func New(w io.Writer, level string) (*slog.Logger, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lvl})), nil
+}
+
+// NewLeveled создает JSON-логгер, уровень которого можно менять на лету через level
+func NewLeveled(w io.Writer, level *slog.LevelVar) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", level)
+}
+
+func WalletID(id string) slog.Attr {
+	return slog.String(KeyWalletID, id)
+}
+
+func Operation(op string) slog.Attr {
+	return slog.String(KeyOperation, op)
+}
+
+func Amount(amount int) slog.Attr {
+	return slog.Int(KeyAmount, amount)
+}
+
+func Status(status int) slog.Attr {
+	return slog.Int(KeyStatus, status)
+}
+
+// Duration пишется в миллисекундах: так его проще агрегировать, чем строку time.Duration
+func Duration(d time.Duration) slog.Attr {
+	return slog.Float64(KeyDuration, float64(d.Microseconds())/1000)
+}
+
+func Error(err error) slog.Attr {
+	return slog.String(KeyError, err.Error())
+}
+
+type loggerKey struct{}
+
+type attrsKey struct{}
+
+// attrs копит поля запроса, которые обработчик добавляет по ходу работы;
+// middleware пишет их одной строкой после ответа
+type attrs struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext возвращает логгер запроса, а вне запроса - fallback
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+func WithAttrs(ctx context.Context) context.Context {
+	return context.WithValue(ctx, attrsKey{}, &attrs{})
+}
+
+// AddAttrs дополняет итоговую строку лога запроса; вне запроса ничего не делает
+func AddAttrs(ctx context.Context, a ...slog.Attr) {
+	holder, ok := ctx.Value(attrsKey{}).(*attrs)
+	if !ok {
+		return
+	}
+	holder.mu.Lock()
+	holder.attrs = append(holder.attrs, a...)
+	holder.mu.Unlock()
+}
+
+func Attrs(ctx context.Context) []slog.Attr {
+	holder, ok := ctx.Value(attrsKey{}).(*attrs)
+	if !ok {
+		return nil
+	}
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	return append([]slog.Attr(nil), holder.attrs...)
+}
@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "itkapp"
+
+// Registry - собственный реестр вместо глобального, чтобы в /metrics попадало только то, что мы явно регистрируем
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route pattern, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route pattern, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	WalletOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "wallet",
+		Name:      "operations_total",
+		Help:      "Deposits and withdrawals by outcome.",
+	}, []string{"operation", "outcome"})
+
+	WalletOperationAmount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "wallet",
+		Name:      "operation_amount_total",
+		Help:      "Sum of requested amounts in minor currency units by operation and outcome.",
+	}, []string{"operation", "outcome"})
+
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Balance cache lookups by result (hit, miss, stale or negative).",
+	}, []string{"result"})
+
+	CacheEvictions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "evictions_total",
+		Help:      "Entries explicitly removed from the balance cache.",
+	})
+
+	CacheCoalescedLoads = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "coalesced_loads_total",
+		Help:      "Balance cache misses that waited for a database read already in flight for the same wallet.",
+	})
+
+	CacheRefreshes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "refreshes_total",
+		Help:      "Background reloads of stale balances by outcome.",
+	}, []string{"outcome"})
+
+	CacheFlushes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "flushes_total",
+		Help:      "Full balance cache flushes after the invalidation listener (re)connected.",
+	})
+
+	CacheDroppedFills = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "dropped_fills_total",
+		Help:      "Balances read from the database that were not cached because the entry changed during the read.",
+	})
+
+	RateLimitedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected with 429 by rate limit dimension (client, ip or wallet).",
+	}, []string{"dimension"})
+
+	// транзакции с кошельками, повторенные после конфликта сериализации или взаимной блокировки (repository.InTx)
+	TxRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "transaction_retries_total",
+		Help:      "Database transactions retried after a transient failure, by reason.",
+	}, []string{"reason"})
+
+	DBReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "reads_total",
+		Help:      "Balance and history reads by server that served them (primary or replica).",
+	}, []string{"target"})
+
+	ReplicaLag = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "replica_lag_seconds",
+		Help:      "Replication lag of the read replica at the last check.",
+	})
+)
+
+const (
+	OutcomeSuccess           = "success"
+	OutcomeNotFound          = "not_found"
+	OutcomeInsufficientFunds = "insufficient_funds"
+	OutcomeConflict          = "conflict"
+	OutcomeTimeout           = "timeout"
+	OutcomeError             = "error"
+
+	CacheHit         = "hit"
+	CacheMiss        = "miss"
+	CacheStale       = "stale"
+	CacheNegativeHit = "negative"
+
+	ReadPrimary = "primary"
+	ReadReplica = "replica"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		RateLimitedRequests,
+		WalletOperations,
+		WalletOperationAmount,
+		CacheRequests,
+		CacheEvictions,
+		CacheCoalescedLoads,
+		CacheRefreshes,
+		CacheFlushes,
+		CacheDroppedFills,
+		TxRetries,
+		DBReads,
+		ReplicaLag,
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
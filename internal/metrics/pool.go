@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector снимает pgxpool.Stat() в момент каждого scrape
+type poolCollector struct {
+	stat func() *pgxpool.Stat
+
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	newConnsCount        *prometheus.Desc
+	maxLifetimeDestroy   *prometheus.Desc
+	maxIdleDestroy       *prometheus.Desc
+}
+
+func poolDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+}
+
+// RegisterPool добавляет в реестр статистику пула соединений
+func RegisterPool(stat func() *pgxpool.Stat) error {
+	return Registry.Register(&poolCollector{
+		stat:                 stat,
+		acquiredConns:        poolDesc("acquired_conns", "Connections currently in use."),
+		idleConns:            poolDesc("idle_conns", "Idle connections."),
+		constructingConns:    poolDesc("constructing_conns", "Connections being established."),
+		totalConns:           poolDesc("total_conns", "All connections in the pool."),
+		maxConns:             poolDesc("max_conns", "Maximum pool size."),
+		acquireCount:         poolDesc("acquire_total", "Successful connection acquires."),
+		acquireDuration:      poolDesc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+		canceledAcquireCount: poolDesc("canceled_acquire_total", "Acquires canceled by context."),
+		emptyAcquireCount:    poolDesc("empty_acquire_total", "Acquires that had to wait for a connection."),
+		newConnsCount:        poolDesc("new_conns_total", "Connections opened."),
+		maxLifetimeDestroy:   poolDesc("max_lifetime_destroy_total", "Connections closed because of MaxConnLifetime."),
+		maxIdleDestroy:       poolDesc("max_idle_destroy_total", "Connections closed because of MaxConnIdleTime."),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stat()
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.constructingConns, prometheus.GaugeValue, float64(s.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, s.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquireCount, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.newConnsCount, prometheus.CounterValue, float64(s.NewConnsCount()))
+	ch <- prometheus.MustNewConstMetric(c.maxLifetimeDestroy, prometheus.CounterValue, float64(s.MaxLifetimeDestroyCount()))
+	ch <- prometheus.MustNewConstMetric(c.maxIdleDestroy, prometheus.CounterValue, float64(s.MaxIdleDestroyCount()))
+}
@@ -0,0 +1,81 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/shared/models"
+)
+
+// EventPublisher доставляет событие во внешнюю систему. Одно и то же событие может прийти
+// повторно (at-least-once), поэтому получатели должны быть идемпотентны по ID
+type EventPublisher interface {
+	Publish(ctx context.Context, event models.OutboxEvent) error
+}
+
+type envelope struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	WalletID  string          `json:"wallet_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// WriterPublisher пишет события построчно в формате JSON; нужен для отладки и тестов без брокера
+type WriterPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+func NewStdoutPublisher() *WriterPublisher {
+	return NewWriterPublisher(os.Stdout)
+}
+
+// NewFilePublisher дописывает события в конец файла; файл закрывается вызывающей стороной
+func NewFilePublisher(path string) (*WriterPublisher, *os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewWriterPublisher(f), f, nil
+}
+
+func (p *WriterPublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	line, err := json.Marshal(envelope{
+		ID:        event.ID,
+		Type:      event.EventType,
+		WalletID:  event.WalletID,
+		CreatedAt: event.CreatedAt,
+		Payload:   event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err = p.w.Write(line)
+	return err
+}
+
+// MultiPublisher передает событие всем публикаторам по очереди и останавливается на первой ошибке.
+// Событие при этом будет опубликовано повторно, в том числе теми, кто уже его получил
+type MultiPublisher []EventPublisher
+
+func (m MultiPublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	for _, p := range m {
+		if err := p.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/shared/models"
+)
+
+const (
+	batchSize       = 100
+	cleanupInterval = time.Hour
+)
+
+type RepoAPI interface {
+	ProcessOutboxEvents(ctx context.Context, limit int, handle func(ctx context.Context, event models.OutboxEvent) error) (int, error)
+	DeleteProcessedOutboxEvents(ctx context.Context, processedBefore time.Time) (int, error)
+}
+
+// Relay читает outbox по порядку и передает события публикатору
+type Relay struct {
+	repo         RepoAPI
+	publisher    EventPublisher
+	pollInterval time.Duration
+	retention    time.Duration
+	lastCleanup  time.Time
+	lastSuccess  atomic.Int64 // unix nano последней итерации без ошибок
+	logger       *slog.Logger
+}
+
+func NewRelay(repo RepoAPI, publisher EventPublisher, pollInterval, retention time.Duration, logger *slog.Logger) *Relay {
+	return &Relay{
+		repo:         repo,
+		publisher:    publisher,
+		pollInterval: pollInterval,
+		retention:    retention,
+		logger:       logger,
+	}
+}
+
+// Run публикует события, пока не будет отменен ctx
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.Tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick публикует накопившиеся события пачками, пока они не закончатся или публикатор не вернет ошибку,
+// и раз в час удаляет обработанные события старше retention
+func (r *Relay) Tick(ctx context.Context) {
+	for {
+		n, err := r.repo.ProcessOutboxEvents(ctx, batchSize, r.publisher.Publish)
+		if err != nil {
+			r.logger.ErrorContext(ctx, "outbox relay failed", slog.Int("published", n), logging.Error(err))
+			break
+		}
+		if n < batchSize {
+			r.lastSuccess.Store(time.Now().UnixNano())
+			break
+		}
+	}
+
+	if time.Since(r.lastCleanup) < cleanupInterval {
+		return
+	}
+	r.lastCleanup = time.Now()
+	n, err := r.repo.DeleteProcessedOutboxEvents(ctx, time.Now().UTC().Add(-r.retention))
+	if err != nil {
+		r.logger.ErrorContext(ctx, "outbox cleanup failed", logging.Error(err))
+		return
+	}
+	if n > 0 {
+		r.logger.InfoContext(ctx, "outbox cleanup finished", slog.Int("removed", n))
+	}
+}
+
+// Check сообщает об ошибке, если релей давно не доходил до конца outbox
+func (r *Relay) Check(ctx context.Context) error {
+	last := r.lastSuccess.Load()
+	if last == 0 {
+		return fmt.Errorf("outbox relay has not completed a run yet")
+	}
+	if since := time.Since(time.Unix(0, last)); since > 10*r.pollInterval {
+		return fmt.Errorf("outbox relay is stalled for %s", since.Round(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/logging"
+)
+
+type BucketRepo interface {
+	TakeRateLimitToken(ctx context.Context, key string, limit Limit) (Result, error)
+	DeleteFullRateLimitBuckets(ctx context.Context) (int64, error)
+}
+
+// PostgresStore делит корзины между репликами через таблицу rate_limit_buckets
+type PostgresStore struct {
+	repo   BucketRepo
+	logger *slog.Logger
+}
+
+func NewPostgresStore(repo BucketRepo, logger *slog.Logger) *PostgresStore {
+	return &PostgresStore{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	return s.repo.TakeRateLimitToken(ctx, key, limit)
+}
+
+// Run периодически удаляет полные корзины, пока не будет отменен ctx
+func (s *PostgresStore) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.repo.DeleteFullRateLimitBuckets(ctx)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "deleting full rate limit buckets failed", logging.Error(err))
+				continue
+			}
+			if n > 0 {
+				s.logger.DebugContext(ctx, "full rate limit buckets deleted", slog.Int64("count", n))
+			}
+		}
+	}
+}
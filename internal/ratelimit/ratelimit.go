@@ -0,0 +1,59 @@
+// Package ratelimit реализует token bucket с подключаемым хранилищем корзин
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit: корзина вмещает Burst токенов и пополняется на Rate токенов в секунду
+type Limit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// Enabled: нулевой лимит означает, что измерение не ограничивается
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // до полной корзины
+	RetryAfter time.Duration // до следующего токена; 0, если запрос пропущен
+}
+
+// Store хранит корзины. Take должен быть атомарным для ключа: реплики делят одну корзину
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Apply пополняет корзину с tokens токенами за elapsed и пытается взять один токен.
+// Возвращает новое число токенов; новая корзина начинается с tokens = Burst и elapsed = 0
+func Apply(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	burst := float64(limit.Burst)
+	tokens = math.Min(burst, tokens+elapsed.Seconds()*limit.Rate)
+
+	res := Result{Limit: limit.Burst}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	res.Remaining = int(math.Floor(tokens))
+	res.Reset = seconds((burst - tokens) / limit.Rate)
+	return tokens, res
+}
+
+// FullIn - время, через которое корзина с tokens токенами станет полной
+func FullIn(tokens float64, limit Limit) time.Duration {
+	return seconds((float64(limit.Burst) - tokens) / limit.Rate)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
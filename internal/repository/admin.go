@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/audit"
+	"github.com/glekoz/test_itk/internal/events"
+	"github.com/glekoz/test_itk/internal/repository/db"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+	"github.com/glekoz/test_itk/internal/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (r *Repository) ListWallets(ctx context.Context, filter models.WalletFilter) ([]models.Wallet, error) {
+	params := db.ListWalletsParams{
+		TenantID:   tenant.ID(ctx),
+		Status:     pgtype.Text{String: string(filter.Status), Valid: filter.Status != ""},
+		PageSize:   int32(filter.Limit),
+		PageOffset: int32(filter.Offset),
+	}
+	if filter.MinBalance != nil {
+		params.MinBalance = pgtype.Int4{Int32: int32(*filter.MinBalance), Valid: true}
+	}
+	if filter.MaxBalance != nil {
+		params.MaxBalance = pgtype.Int4{Int32: int32(*filter.MaxBalance), Valid: true}
+	}
+	if filter.CreatedFrom != nil {
+		params.CreatedFrom = pgtype.Timestamp{Time: filter.CreatedFrom.UTC(), Valid: true}
+	}
+	if filter.CreatedTo != nil {
+		params.CreatedTo = pgtype.Timestamp{Time: filter.CreatedTo.UTC(), Valid: true}
+	}
+	if filter.After != nil {
+		params.AfterCreatedAt = pgtype.Timestamp{Time: filter.After.CreatedAt, Valid: true}
+		params.AfterID = filter.After.ID
+	}
+
+	rows, err := r.q.ListWallets(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	wallets := make([]models.Wallet, 0, len(rows))
+	for _, row := range rows {
+		wallets = append(wallets, models.Wallet{
+			ID:        row.ID,
+			Balance:   int(row.Amount),
+			Status:    myvars.WalletStatus(row.Status),
+			OwnerID:   row.OwnerID.String,
+			Version:   row.Version,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		})
+	}
+	return wallets, nil
+}
+
+func (r *Repository) GetWallet(ctx context.Context, id string) (models.Wallet, error) {
+	var row db.GetWalletRow
+	err := r.read(ctx, func(q *db.Queries) error {
+		var err error
+		row, err = q.GetWallet(ctx, db.GetWalletParams{
+			ID:       id,
+			TenantID: tenant.ID(ctx),
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Wallet{}, myerrors.ErrNotFound
+		}
+		return models.Wallet{}, err
+	}
+	return models.Wallet{
+		ID:        row.ID,
+		Balance:   int(row.Amount),
+		Status:    myvars.WalletStatus(row.Status),
+		OwnerID:   row.OwnerID.String,
+		Version:   row.Version,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}, nil
+}
+
+// ListWalletTransactions возвращает последние операции кошелька, новые сначала
+func (r *Repository) ListWalletTransactions(ctx context.Context, walletID string, limit int) ([]models.WalletTransaction, error) {
+	var rows []db.ListWalletTransactionsRow
+	err := r.read(ctx, func(q *db.Queries) error {
+		var err error
+		rows, err = q.ListWalletTransactions(ctx, db.ListWalletTransactionsParams{
+			WalletID: walletID,
+			TenantID: tenant.ID(ctx),
+			Limit:    int32(limit),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	transactions := make([]models.WalletTransaction, 0, len(rows))
+	for _, row := range rows {
+		transactions = append(transactions, models.WalletTransaction{
+			ID:            row.ID,
+			Amount:        int(row.Amount),
+			OperationType: myvars.OperationType(row.OperationType),
+			RequestID:     row.RequestID.String,
+			CreatedAt:     row.CreatedAt,
+		})
+	}
+	return transactions, nil
+}
+
+// AdjustBalance меняет баланс на amount со знаком так же, как перевод: транзакция, событие и outbox
+// пишутся атомарно. Причина попадает в событие, чтобы ее было видно при разборе истории кошелька
+func (r *Repository) AdjustBalance(ctx context.Context, walletID, transactionID string, amount int, reason string) (int, error) {
+	var balance int
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		wallet, err := qtx.AdjustBalance(ctx, db.AdjustBalanceParams{
+			ID:       walletID,
+			Amount:   int32(amount),
+			TenantID: tenant.ID(ctx),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return myerrors.ErrNotFound
+			}
+			var errp *pgconn.PgError
+			if errors.As(err, &errp) {
+				if errp.Code == CheckViolationCode {
+					return myerrors.ErrNegativeAmount
+				}
+			}
+			return err
+		}
+
+		err = qtx.CreateTransaction(ctx, db.CreateTransactionParams{
+			ID:            transactionID,
+			WalletID:      walletID,
+			Amount:        int32(amount),
+			OperationType: string(myvars.OperationTypeAdjustment),
+			RequestID:     requestIDParam(ctx),
+			TenantID:      tenant.ID(ctx),
+		})
+		if err != nil {
+			var errp *pgconn.PgError
+			if errors.As(err, &errp) {
+				if errp.Code == UniqueViolationCode {
+					return myerrors.ErrAlreadyExists
+				}
+			}
+			return err
+		}
+
+		err = appendEvent(ctx, qtx, walletID, wallet.Version, events.Adjusted{
+			TransactionID: transactionID,
+			Amount:        amount,
+			Reason:        reason,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = writeOutboxEvent(ctx, qtx, models.WalletEvent{
+			Type:          myvars.EventTypeAdjusted,
+			WalletID:      walletID,
+			TransactionID: transactionID,
+			Amount:        amount,
+			Balance:       int(wallet.Amount),
+			OccurredAt:    time.Now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+
+		err = writeAudit(ctx, qtx, audit.ActionAdjustBalance, walletID, balanceState(int(wallet.Amount)-amount), balanceState(int(wallet.Amount)), reason)
+		if err != nil {
+			return err
+		}
+
+		balance = int(wallet.Amount)
+		return nil
+	})
+	return balance, err
+}
+
+// SetWalletStatus блокирует строку кошелька, чтобы в журнал аудита попал статус, который действительно был до изменения
+func (r *Repository) SetWalletStatus(ctx context.Context, walletID string, status myvars.WalletStatus, reason string) error {
+	return r.inTx(ctx, func(qtx *db.Queries) error {
+		before, err := qtx.LockWalletStatus(ctx, db.LockWalletStatusParams{
+			ID:       walletID,
+			TenantID: tenant.ID(ctx),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return myerrors.ErrNotFound
+			}
+			return err
+		}
+
+		version, err := qtx.SetWalletStatus(ctx, db.SetWalletStatusParams{
+			ID:       walletID,
+			Status:   string(status),
+			TenantID: tenant.ID(ctx),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return myerrors.ErrNotFound
+			}
+			return err
+		}
+
+		// без события пересборка проекции вернула бы замороженный кошелек в active
+		err = appendEvent(ctx, qtx, walletID, version, events.StatusChanged{
+			Status: status,
+			Reason: reason,
+		})
+		if err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, qtx, audit.ActionSetWalletStatus, walletID, &audit.State{Status: before}, &audit.State{Status: string(status)}, reason)
+	})
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/glekoz/test_itk/internal/repository/db"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (r *Repository) CreateAPIKey(ctx context.Context, key models.APIKey, hash string) error {
+	err := r.q.CreateAPIKey(ctx, db.CreateAPIKeyParams{
+		ID:        key.ID,
+		Name:      key.Name,
+		KeyHash:   hash,
+		Scopes:    key.Scopes,
+		OwnerID:   pgtype.Text{String: key.OwnerID, Valid: key.OwnerID != ""},
+		CreatedAt: key.CreatedAt,
+		TenantID:  pgtype.Text{String: key.TenantID, Valid: key.TenantID != ""},
+	})
+	if err != nil {
+		var errp *pgconn.PgError
+		if errors.As(err, &errp) {
+			if errp.Code == UniqueViolationCode {
+				return myerrors.ErrAlreadyExists
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	row, err := r.q.GetAPIKeyByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.APIKey{}, myerrors.ErrNotFound
+		}
+		return models.APIKey{}, err
+	}
+
+	key := models.APIKey{
+		ID:        row.ID,
+		Name:      row.Name,
+		Scopes:    row.Scopes,
+		OwnerID:   row.OwnerID.String,
+		CreatedAt: row.CreatedAt,
+		TenantID:  row.TenantID.String,
+	}
+	if row.RevokedAt.Valid {
+		key.RevokedAt = &row.RevokedAt.Time
+	}
+	return key, nil
+}
+
+func (r *Repository) RevokeAPIKey(ctx context.Context, id string) error {
+	// ключи платформы (без арендатора) отзывает только RevokeAnyAPIKey
+	n, err := r.q.RevokeAPIKey(ctx, db.RevokeAPIKeyParams{
+		ID:       id,
+		TenantID: pgtype.Text{String: tenant.ID(ctx), Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return myerrors.ErrNotFound
+	}
+	return nil
+}
+
+// RevokeAnyAPIKey отзывает ключ независимо от арендатора
+func (r *Repository) RevokeAnyAPIKey(ctx context.Context, id string) error {
+	n, err := r.q.RevokeAnyAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return myerrors.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/audit"
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/repository/db"
+	"github.com/glekoz/test_itk/internal/shared/clientip"
+	"github.com/glekoz/test_itk/internal/shared/requestid"
+	"github.com/glekoz/test_itk/internal/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// страница, которой проверка читает журнал
+const auditPageSize = 1000
+
+// writeAudit дописывает запись в цепочку кошелька в той же транзакции, что и само изменение.
+// Отдельная блокировка не нужна: к этому моменту транзакция держит строку кошелька в wallets,
+// поэтому записи одного кошелька выстраиваются по очереди, а разных кошельков пишутся параллельно
+func writeAudit(ctx context.Context, qtx *db.Queries, action, walletID string, before, after *audit.State, reason string) error {
+	e := audit.Entry{
+		TenantID:  tenant.ID(ctx),
+		Actor:     audit.AnonymousActor,
+		Action:    action,
+		WalletID:  walletID,
+		RequestID: requestid.FromContext(ctx),
+		SourceIP:  clientip.FromContext(ctx),
+		Reason:    reason,
+		// точность как у TIMESTAMP, иначе хеш прочитанной записи не сойдется
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if p, ok := auth.FromContext(ctx); ok {
+		e.Actor = p.ID
+	}
+	var err error
+	if e.Before, err = audit.MarshalState(before); err != nil {
+		return err
+	}
+	if e.After, err = audit.MarshalState(after); err != nil {
+		return err
+	}
+
+	prev, err := qtx.GetLastAuditHash(ctx, textParam(e.WalletID))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if err := audit.Seal(&e, prev); err != nil {
+		return err
+	}
+
+	return qtx.CreateAuditEntry(ctx, db.CreateAuditEntryParams{
+		TenantID:  e.TenantID,
+		Actor:     e.Actor,
+		Action:    e.Action,
+		WalletID:  textParam(e.WalletID),
+		RequestID: textParam(e.RequestID),
+		Before:    e.Before,
+		After:     e.After,
+		SourceIP:  textParam(e.SourceIP),
+		Reason:    textParam(e.Reason),
+		CreatedAt: e.CreatedAt,
+		PrevHash:  e.PrevHash,
+		Hash:      e.Hash,
+	})
+}
+
+// balanceState - состояние кошелька для операций, меняющих только баланс
+func balanceState(balance int) *audit.State {
+	return &audit.State{Balance: &balance}
+}
+
+func textParam(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+// ListAuditEntries передает в fn весь журнал по возрастанию ID, по всем арендаторам и кошелькам.
+// Страницы читаются из одного снимка, иначе запись, закоммиченная между страницами, выглядела бы как разрыв цепочки
+func (r *Repository) ListAuditEntries(ctx context.Context, fn func(audit.Entry) error) error {
+	tx, err := r.p.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	qtx := r.q.WithTx(tx)
+
+	var after int64
+	for {
+		rows, err := qtx.ListAuditEntries(ctx, db.ListAuditEntriesParams{
+			ID:    after,
+			Limit: auditPageSize,
+		})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			err := fn(audit.Entry{
+				ID:        row.ID,
+				TenantID:  row.TenantID,
+				Actor:     row.Actor,
+				Action:    row.Action,
+				WalletID:  row.WalletID.String,
+				RequestID: row.RequestID.String,
+				Before:    row.Before,
+				After:     row.After,
+				SourceIP:  row.SourceIP.String,
+				Reason:    row.Reason.String,
+				CreatedAt: row.CreatedAt,
+				PrevHash:  row.PrevHash,
+				Hash:      row.Hash,
+			})
+			if err != nil {
+				return err
+			}
+			after = row.ID
+		}
+		if len(rows) < auditPageSize {
+			return nil
+		}
+	}
+}
@@ -7,11 +7,13 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createTransaction = `-- name: CreateTransaction :exec
-INSERT INTO transactions (id, wallet_id, amount, operation_type)
-VALUES ($1, $2, $3, $4)
+INSERT INTO transactions (id, wallet_id, amount, operation_type, request_id, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6)
 `
 
 type CreateTransactionParams struct {
@@ -19,6 +21,8 @@ type CreateTransactionParams struct {
 	WalletID      string
 	Amount        int32
 	OperationType string
+	RequestID     pgtype.Text
+	TenantID      string
 }
 
 func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) error {
@@ -27,67 +31,125 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 		arg.WalletID,
 		arg.Amount,
 		arg.OperationType,
+		arg.RequestID,
+		arg.TenantID,
 	)
 	return err
 }
 
 const createWallet = `-- name: CreateWallet :exec
-INSERT INTO wallets (id, amount)
-VALUES ($1, 0)
+INSERT INTO wallets (id, amount, version, owner_id, tenant_id)
+VALUES ($1, 0, 1, $2, $3)
 `
 
-func (q *Queries) CreateWallet(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, createWallet, id)
+type CreateWalletParams struct {
+	ID       string
+	OwnerID  pgtype.Text
+	TenantID string
+}
+
+func (q *Queries) CreateWallet(ctx context.Context, arg CreateWalletParams) error {
+	_, err := q.db.Exec(ctx, createWallet, arg.ID, arg.OwnerID, arg.TenantID)
 	return err
 }
 
 const deposit = `-- name: Deposit :one
 UPDATE wallets
-SET amount = amount + $2, updated_at = CURRENT_TIMESTAMP
-WHERE id = $1
-RETURNING amount
+SET amount = amount + $2, version = version + 1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND tenant_id = $3 AND status = 'active'
+RETURNING amount, version
 `
 
 type DepositParams struct {
-	ID     string
-	Amount int32
+	ID       string
+	Amount   int32
+	TenantID string
 }
 
-func (q *Queries) Deposit(ctx context.Context, arg DepositParams) (int32, error) {
-	row := q.db.QueryRow(ctx, deposit, arg.ID, arg.Amount)
-	var amount int32
-	err := row.Scan(&amount)
-	return amount, err
+type DepositRow struct {
+	Amount  int32
+	Version int64
+}
+
+func (q *Queries) Deposit(ctx context.Context, arg DepositParams) (DepositRow, error) {
+	row := q.db.QueryRow(ctx, deposit, arg.ID, arg.Amount, arg.TenantID)
+	var i DepositRow
+	err := row.Scan(&i.Amount, &i.Version)
+	return i, err
 }
 
 const getBalance = `-- name: GetBalance :one
 SELECT amount 
 FROM wallets
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $2
 `
 
-func (q *Queries) GetBalance(ctx context.Context, id string) (int32, error) {
-	row := q.db.QueryRow(ctx, getBalance, id)
+type GetBalanceParams struct {
+	ID       string
+	TenantID string
+}
+
+func (q *Queries) GetBalance(ctx context.Context, arg GetBalanceParams) (int32, error) {
+	row := q.db.QueryRow(ctx, getBalance, arg.ID, arg.TenantID)
 	var amount int32
 	err := row.Scan(&amount)
 	return amount, err
 }
 
+const getWalletOwner = `-- name: GetWalletOwner :one
+SELECT owner_id
+FROM wallets
+WHERE id = $1 AND tenant_id = $2
+`
+
+type GetWalletOwnerParams struct {
+	ID       string
+	TenantID string
+}
+
+func (q *Queries) GetWalletOwner(ctx context.Context, arg GetWalletOwnerParams) (pgtype.Text, error) {
+	row := q.db.QueryRow(ctx, getWalletOwner, arg.ID, arg.TenantID)
+	var owner_id pgtype.Text
+	err := row.Scan(&owner_id)
+	return owner_id, err
+}
+
+const setLocalTimeouts = `-- name: SetLocalTimeouts :exec
+SELECT set_config('lock_timeout', $1::text, true), set_config('statement_timeout', $2::text, true)
+`
+
+type SetLocalTimeoutsParams struct {
+	LockTimeout      string
+	StatementTimeout string
+}
+
+// таймауты действуют до конца транзакции; '0' отключает таймаут
+func (q *Queries) SetLocalTimeouts(ctx context.Context, arg SetLocalTimeoutsParams) error {
+	_, err := q.db.Exec(ctx, setLocalTimeouts, arg.LockTimeout, arg.StatementTimeout)
+	return err
+}
+
 const withdraw = `-- name: Withdraw :one
 UPDATE wallets
-SET amount = amount - $2, updated_at = CURRENT_TIMESTAMP
-WHERE id = $1
-RETURNING amount
+SET amount = amount - $2, version = version + 1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND tenant_id = $3 AND status = 'active'
+RETURNING amount, version
 `
 
 type WithdrawParams struct {
-	ID     string
-	Amount int32
+	ID       string
+	Amount   int32
+	TenantID string
 }
 
-func (q *Queries) Withdraw(ctx context.Context, arg WithdrawParams) (int32, error) {
-	row := q.db.QueryRow(ctx, withdraw, arg.ID, arg.Amount)
-	var amount int32
-	err := row.Scan(&amount)
-	return amount, err
+type WithdrawRow struct {
+	Amount  int32
+	Version int64
+}
+
+func (q *Queries) Withdraw(ctx context.Context, arg WithdrawParams) (WithdrawRow, error) {
+	row := q.db.QueryRow(ctx, withdraw, arg.ID, arg.Amount, arg.TenantID)
+	var i WithdrawRow
+	err := row.Scan(&i.Amount, &i.Version)
+	return i, err
 }
@@ -6,14 +6,61 @@ package db
 
 import (
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ApiKey struct {
+	ID        string
+	Name      string
+	KeyHash   string
+	Scopes    []string
+	OwnerID   pgtype.Text
+	CreatedAt time.Time
+	RevokedAt pgtype.Timestamp
+	TenantID  pgtype.Text
+}
+
+type AuditLog struct {
+	ID        int64
+	TenantID  string
+	Actor     string
+	Action    string
+	WalletID  pgtype.Text
+	RequestID pgtype.Text
+	Before    []byte
+	After     []byte
+	SourceIP  pgtype.Text
+	Reason    pgtype.Text
+	CreatedAt time.Time
+	PrevHash  string
+	Hash      string
+}
+
+type Outbox struct {
+	ID          int64
+	WalletID    string
+	EventType   string
+	Payload     []byte
+	CreatedAt   time.Time
+	ProcessedAt pgtype.Timestamp
+}
+
+type RateLimitBucket struct {
+	Key       string
+	Tokens    float64
+	UpdatedAt time.Time
+	FullAt    time.Time
+}
+
 type Transaction struct {
 	ID            string
 	WalletID      string
 	Amount        int32
 	OperationType string
 	CreatedAt     time.Time
+	RequestID     pgtype.Text
+	TenantID      string
 }
 
 type Wallet struct {
@@ -21,4 +68,43 @@ type Wallet struct {
 	Amount    int32
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	Version   int64
+	OwnerID   pgtype.Text
+	TenantID  string
+	Status    string
+}
+
+type WalletEvent struct {
+	Position     int64
+	WalletID     string
+	Sequence     int64
+	EventType    string
+	EventVersion int32
+	Payload      []byte
+	CreatedAt    time.Time
+	TenantID     string
+}
+
+type WebhookDelivery struct {
+	ID             string
+	SubscriptionID string
+	EventID        int64
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int32
+	NextAttemptAt  time.Time
+	LastError      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	ClaimedUntil   pgtype.Timestamp
+}
+
+type WebhookSubscription struct {
+	ID        string
+	WalletID  pgtype.Text
+	Url       string
+	Secret    string
+	CreatedAt time.Time
+	TenantID  string
 }
@@ -4,4 +4,9 @@ const (
 	ForeignKeyViolationCode = "23503"
 	UniqueViolationCode     = "23505"
 	CheckViolationCode      = "23514"
+	LockNotAvailableCode    = "55P03" // сработал lock_timeout
+	QueryCanceledCode       = "57014" // сработал statement_timeout или запрос отменен
+
+	SerializationFailureCode = "40001"
+	DeadlockDetectedCode     = "40P01"
 )
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/glekoz/test_itk/internal/events"
+	"github.com/glekoz/test_itk/internal/repository/db"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// appendEvent дописывает событие в поток кошелька. Номер sequence должен быть на единицу больше
+// последнего записанного, иначе первичный ключ (wallet_id, sequence) отклонит запись
+func appendEvent(ctx context.Context, qtx *db.Queries, walletID string, sequence int64, e events.Event) error {
+	payload, err := events.Encode(e)
+	if err != nil {
+		return err
+	}
+	err = qtx.AppendWalletEvent(ctx, db.AppendWalletEventParams{
+		WalletID:     walletID,
+		Sequence:     sequence,
+		EventType:    string(e.Type()),
+		EventVersion: int32(e.Version()),
+		Payload:      payload,
+		TenantID:     tenant.ID(ctx),
+	})
+	if err != nil {
+		var errp *pgconn.PgError
+		if errors.As(err, &errp) {
+			if errp.Code == UniqueViolationCode {
+				return myerrors.ErrConflict
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+func loadEvents(ctx context.Context, q *db.Queries, walletID string) ([]events.Record, error) {
+	rows, err := q.GetWalletEvents(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]events.Record, 0, len(rows))
+	for _, row := range rows {
+		e, err := events.Decode(events.Type(row.EventType), int(row.EventVersion), row.Payload)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, events.Record{
+			WalletID:   row.WalletID,
+			Sequence:   row.Sequence,
+			Event:      e,
+			OccurredAt: row.CreatedAt,
+		})
+	}
+	return records, nil
+}
+
+func (r *Repository) GetWalletEvents(ctx context.Context, walletID string) ([]events.Record, error) {
+	return loadEvents(ctx, r.q, walletID)
+}
+
+// LoadWalletState читает поток и проекцию из одного снимка: перевод, закоммиченный между
+// двумя чтениями, иначе выглядел бы как расхождение
+func (r *Repository) LoadWalletState(ctx context.Context, walletID string) ([]events.Record, events.Wallet, error) {
+	tx, err := r.p.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, events.Wallet{}, err
+	}
+	defer tx.Rollback(ctx)
+	qtx := r.q.WithTx(tx)
+
+	records, err := loadEvents(ctx, qtx, walletID)
+	if err != nil {
+		return nil, events.Wallet{}, err
+	}
+	row, err := qtx.GetWalletProjection(ctx, walletID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return records, events.Wallet{}, myerrors.ErrNotFound
+		}
+		return nil, events.Wallet{}, err
+	}
+	return records, events.Wallet{
+		ID:        row.ID,
+		Balance:   int(row.Amount),
+		Sequence:  row.Version,
+		CreatedAt: row.CreatedAt,
+	}, nil
+}
+
+func (r *Repository) ListEventStreamIDs(ctx context.Context) ([]string, error) {
+	return r.q.ListEventStreamIDs(ctx)
+}
+
+func (r *Repository) RebuildWalletProjection(ctx context.Context, walletID string, replay func(records []events.Record) (events.Wallet, error)) error {
+	tx, err := r.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	qtx := r.q.WithTx(tx)
+
+	// блокируем строку проекции, чтобы параллельные операции не потерялись между чтением потока и записью
+	if err := qtx.LockWallet(ctx, walletID); err != nil {
+		return err
+	}
+	records, err := loadEvents(ctx, qtx, walletID)
+	if err != nil {
+		return err
+	}
+	w, err := replay(records)
+	if err != nil {
+		return err
+	}
+	err = qtx.UpsertWalletProjection(ctx, db.UpsertWalletProjectionParams{
+		ID:        w.ID,
+		Amount:    int32(w.Balance),
+		Version:   w.Sequence,
+		CreatedAt: w.CreatedAt,
+		OwnerID:   pgtype.Text{String: w.OwnerID, Valid: w.OwnerID != ""},
+		Status:    string(w.Status),
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
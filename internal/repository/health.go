@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/glekoz/test_itk/internal/repository/migrations"
+)
+
+// таблица goose не описана в схеме sqlc, поэтому запрос написан вручную
+const migrationVersionQuery = `SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`
+
+func (r *Repository) Ping(ctx context.Context) error {
+	return r.p.Ping(ctx)
+}
+
+func (r *Repository) MigrationVersion(ctx context.Context) (int64, error) {
+	var version int64
+	err := r.p.QueryRow(ctx, migrationVersionQuery).Scan(&version)
+	return version, err
+}
+
+// CheckMigrations убеждается, что база находится ровно на версии последней миграции в бинарнике
+func (r *Repository) CheckMigrations(ctx context.Context) error {
+	expected, err := migrations.LatestVersion()
+	if err != nil {
+		return err
+	}
+	actual, err := r.MigrationVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if actual != expected {
+		return fmt.Errorf("database is at migration %d, expected %d", actual, expected)
+	}
+	return nil
+}
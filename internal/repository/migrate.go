@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/glekoz/test_itk/internal/repository/migrations"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// Migrator применяет миграции, собранные в бинарник. Реплики, стартующие одновременно,
+// выстраиваются на advisory lock и не применяют одну миграцию дважды
+type Migrator struct {
+	provider *goose.Provider
+}
+
+func (r *Repository) Migrator() (*Migrator, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	// соединения берутся из пула репозитория, отдельного подключения к базе не нужно
+	db := stdlib.OpenDBFromPool(r.p)
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, migrations.FS, goose.WithSessionLocker(locker))
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Migrator{provider: provider}, nil
+}
+
+// Up применяет все новые миграции
+func (m *Migrator) Up(ctx context.Context) ([]*goose.MigrationResult, error) {
+	return m.provider.Up(ctx)
+}
+
+// Down откатывает одну последнюю миграцию
+func (m *Migrator) Down(ctx context.Context) (*goose.MigrationResult, error) {
+	return m.provider.Down(ctx)
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	return m.provider.Status(ctx)
+}
+
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	return m.provider.GetDBVersion(ctx)
+}
+
+// Close не закрывает пул репозитория
+func (m *Migrator) Close() error {
+	return m.provider.Close()
+}
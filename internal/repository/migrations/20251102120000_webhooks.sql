@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS outbox ( -- события, записанные в той же транзакции, что и изменение кошелька
+    id BIGSERIAL PRIMARY KEY, -- порядковый номер события
+    wallet_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    processed_at TIMESTAMP -- NULL, пока событие не разослано подписчикам
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unprocessed_idx ON outbox (id) WHERE processed_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id TEXT PRIMARY KEY,
+    wallet_id TEXT REFERENCES wallets(id) ON DELETE CASCADE, -- NULL означает подписку на все кошельки
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL, -- ключ для подписи HMAC-SHA256
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS webhook_subscriptions_wallet_idx ON webhook_subscriptions (wallet_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id TEXT PRIMARY KEY,
+    subscription_id TEXT NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL, -- без внешнего ключа: обработанные события из outbox могут быть удалены
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL, -- копия тела события, чтобы доставку можно было повторить
+    status TEXT NOT NULL DEFAULT 'pending', -- pending, delivered, dead
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    claimed_until TIMESTAMP, -- аренда воркера, который сейчас отправляет доставку; NULL - доставку никто не отправляет
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_subscriptions;
+DROP TABLE outbox;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS wallet_events ( -- неизменяемый поток событий; wallets - проекция этого потока
+    position BIGSERIAL NOT NULL UNIQUE, -- глобальный порядок для будущих read-моделей
+    wallet_id TEXT NOT NULL, -- без внешнего ключа: события первичны, строка в wallets может быть пересоздана
+    sequence BIGINT NOT NULL CHECK (sequence > 0), -- номер события внутри кошелька, начиная с 1
+    event_type TEXT NOT NULL,
+    event_version INTEGER NOT NULL, -- версия схемы payload
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (wallet_id, sequence) -- оптимистичная блокировка: второй append с тем же номером упадет
+);
+
+CREATE OR REPLACE FUNCTION wallet_events_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'wallet_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER wallet_events_no_update_delete
+    BEFORE UPDATE OR DELETE ON wallet_events
+    FOR EACH ROW EXECUTE FUNCTION wallet_events_immutable();
+
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0; -- номер последнего примененного события
+
+-- переносим существующие данные в поток событий
+INSERT INTO wallet_events (wallet_id, sequence, event_type, event_version, payload, created_at)
+SELECT id, 1, 'WalletCreated', 1, '{}'::jsonb, created_at
+FROM wallets;
+
+INSERT INTO wallet_events (wallet_id, sequence, event_type, event_version, payload, created_at)
+SELECT wallet_id,
+       1 + ROW_NUMBER() OVER (PARTITION BY wallet_id ORDER BY created_at, id),
+       CASE operation_type WHEN 'deposit' THEN 'Deposited' ELSE 'Withdrawn' END,
+       1,
+       jsonb_build_object('transaction_id', id, 'amount', amount),
+       created_at
+FROM transactions;
+
+UPDATE wallets w
+SET version = (SELECT MAX(e.sequence) FROM wallet_events e WHERE e.wallet_id = w.id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE wallets DROP COLUMN version;
+DROP TABLE wallet_events;
+DROP FUNCTION wallet_events_immutable();
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transactions ADD COLUMN request_id TEXT; -- X-Request-ID запроса, создавшего операцию; NULL для операций вне HTTP
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions DROP COLUMN request_id;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS api_keys (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE, -- sha256 от ключа; сам ключ показывается один раз при выпуске и нигде не хранится
+    scopes TEXT[] NOT NULL,
+    owner_id TEXT, -- NULL - ключ не ограничен конкретными кошельками
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+ALTER TABLE wallets ADD COLUMN owner_id TEXT; -- владелец кошелька; NULL у кошельков, созданных без ограниченного ключа
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE wallets DROP COLUMN owner_id;
+DROP TABLE api_keys;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- UNLOGGED: после аварийного перезапуска базы лимиты просто начинаются заново, зато запись не идет в WAL
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    key TEXT PRIMARY KEY, -- измерение и значение, например ip:10.0.0.1
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    full_at TIMESTAMP NOT NULL -- после этого момента корзина полна и строку можно удалить без потери состояния
+);
+
+CREATE INDEX IF NOT EXISTS rate_limit_buckets_full_at_idx ON rate_limit_buckets (full_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE rate_limit_buckets;
+-- +goose StatementEnd
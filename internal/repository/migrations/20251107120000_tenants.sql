@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- существующие данные принадлежат арендатору по умолчанию
+ALTER TABLE wallets ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE transactions ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE wallet_events ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default'; -- нужен, чтобы пересобрать удаленную проекцию в правильного арендатора
+ALTER TABLE webhook_subscriptions ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default'; -- подписка на все кошельки - это все кошельки арендатора
+ALTER TABLE api_keys ADD COLUMN tenant_id TEXT; -- NULL - ключ платформы, арендатор выбирается заголовком X-Tenant-ID
+-- выбирать арендатора заголовком может только администратор платформы, поэтому остальные
+-- существующие ключи остаются в арендаторе по умолчанию, где они и работали
+UPDATE api_keys SET tenant_id = 'default' WHERE NOT ('admin' = ANY(scopes));
+
+CREATE INDEX IF NOT EXISTS wallets_tenant_id_idx ON wallets (tenant_id);
+CREATE INDEX IF NOT EXISTS transactions_tenant_id_idx ON transactions (tenant_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE api_keys DROP COLUMN tenant_id;
+ALTER TABLE webhook_subscriptions DROP COLUMN tenant_id;
+ALTER TABLE wallet_events DROP COLUMN tenant_id;
+ALTER TABLE transactions DROP COLUMN tenant_id;
+ALTER TABLE wallets DROP COLUMN tenant_id;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- замороженный кошелек не принимает переводы клиентов, но его баланс может скорректировать оператор.
+-- Статус - часть проекции: каждая смена пишется событием StatusChanged, до миграции все кошельки активны
+ALTER TABLE wallets ADD COLUMN status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'frozen'));
+
+-- поиск кошельков в админке идет в пределах арендатора, новые сначала
+CREATE INDEX IF NOT EXISTS wallets_tenant_id_created_at_idx ON wallets (tenant_id, created_at DESC, id);
+DROP INDEX IF EXISTS wallets_tenant_id_idx;
+CREATE INDEX IF NOT EXISTS transactions_wallet_id_created_at_idx ON transactions (wallet_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS transactions_wallet_id_created_at_idx;
+CREATE INDEX IF NOT EXISTS wallets_tenant_id_idx ON wallets (tenant_id);
+DROP INDEX IF EXISTS wallets_tenant_id_created_at_idx;
+ALTER TABLE wallets DROP COLUMN status;
+-- +goose StatementEnd
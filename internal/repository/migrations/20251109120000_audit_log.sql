@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+-- журнал аудита только дописывается; каждая запись хранит хеш предыдущей записи своего кошелька,
+-- поэтому операции с разными кошельками не ждут друг друга
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    wallet_id TEXT,
+    request_id TEXT,
+    before JSONB, -- NULL у действий, создающих кошелек
+    after JSONB NOT NULL,
+    source_ip TEXT,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL,
+    prev_hash TEXT NOT NULL, -- пустой у первой записи кошелька
+    hash TEXT NOT NULL UNIQUE
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_wallet_id_id_idx ON audit_log (wallet_id, id);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE audit_log;
+DROP FUNCTION audit_log_append_only();
+-- +goose StatementEnd
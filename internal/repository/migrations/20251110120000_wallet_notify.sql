@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- уведомление уходит при коммите транзакции и не уходит при откате; полезная нагрузка - "арендатор/кошелек@LSN",
+-- где "арендатор/кошелек" устроен так же, как ключи кэша балансов, а LSN - позиция WAL после изменения строки:
+-- вытеснивший запись экземпляр перечитывает баланс только с реплики, которая до нее дошла.
+-- Триггер ловит и записи, сделанные в обход сервиса: переигрывание событий, CLI
+CREATE OR REPLACE FUNCTION wallets_notify_changed() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM pg_notify('wallet_changed', OLD.tenant_id || '/' || OLD.id || '@' || pg_current_wal_insert_lsn());
+    ELSE
+        PERFORM pg_notify('wallet_changed', NEW.tenant_id || '/' || NEW.id || '@' || pg_current_wal_insert_lsn());
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER wallets_changed
+    AFTER INSERT OR UPDATE OR DELETE ON wallets
+    FOR EACH ROW EXECUTE FUNCTION wallets_notify_changed();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER wallets_changed ON wallets;
+DROP FUNCTION wallets_notify_changed();
+-- +goose StatementEnd
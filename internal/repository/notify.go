@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/repository/db"
+	"github.com/glekoz/test_itk/internal/shared/consistency"
+	"github.com/jackc/pgx/v5"
+)
+
+// WalletChangedChannel - канал, в который триггер wallets_changed пишет "арендатор/кошелек@LSN" при коммите
+const WalletChangedChannel = "wallet_changed"
+
+// ListenWalletChanges открывает отдельное соединение с основным сервером и подписывается на изменения кошельков.
+// ready вызывается, когда подписка установлена: уведомления о коммитах до этого момента потеряны,
+// и ready получает позицию WAL, которую эти коммиты уже не превышают. handle получает позицию WAL изменения.
+// Блокируется, пока не отменен ctx или не оборвалось соединение, и возвращает причину
+func (r *Repository) ListenWalletChanges(ctx context.Context, ready func(lsn consistency.LSN), handle func(tenantID, walletID string, lsn consistency.LSN)) error {
+	// соединение из пула не подходит: пул может закрыть его по MaxConnLifetime, а подписка живет, пока живо соединение
+	conn, err := pgx.ConnectConfig(ctx, r.p.Config().ConnConfig.Copy())
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+WalletChangedChannel); err != nil {
+		return err
+	}
+	// позиция берется после LISTEN: коммиты, уведомления о которых не дошли, записаны до нее
+	current, err := db.New(conn).CurrentLSN(ctx)
+	if err != nil {
+		return err
+	}
+	lsn, err := consistency.ParseLSN(current)
+	if err != nil {
+		return err
+	}
+	ready(lsn)
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		tenantID, walletID, lsn, err := parseWalletChange(n.Payload)
+		if err != nil {
+			r.logger.WarnContext(ctx, "unexpected wallet change notification", slog.String("payload", n.Payload), logging.Error(err))
+			continue
+		}
+		handle(tenantID, walletID, lsn)
+	}
+}
+
+func parseWalletChange(payload string) (tenantID, walletID string, lsn consistency.LSN, err error) {
+	k, position, ok := strings.Cut(payload, "@")
+	if !ok {
+		return "", "", 0, errors.New("wal position is missing")
+	}
+	tenantID, walletID, ok = strings.Cut(k, "/")
+	if !ok {
+		return "", "", 0, errors.New("wallet id is missing")
+	}
+	lsn, err = consistency.ParseLSN(position)
+	return tenantID, walletID, lsn, err
+}
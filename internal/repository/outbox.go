@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/repository/db"
+	"github.com/glekoz/test_itk/internal/shared/models"
+)
+
+// ключ advisory-блокировки, под которой работает ровно один релей outbox на кластер,
+// иначе несколько экземпляров приложения публиковали бы события не по порядку
+const outboxRelayLockKey int64 = 0x6f7574626f78
+
+// writeOutboxEvent должна вызываться внутри той же транзакции, что и изменение баланса,
+// чтобы событие появлялось только для закоммиченных операций
+func writeOutboxEvent(ctx context.Context, qtx *db.Queries, event models.WalletEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return qtx.CreateOutboxEvent(ctx, db.CreateOutboxEventParams{
+		WalletID:  event.WalletID,
+		EventType: string(event.Type),
+		Payload:   payload,
+	})
+}
+
+// ProcessOutboxEvents передает необработанные события в handle строго по порядку id.
+// На первой ошибке обработка останавливается: успешно переданные события отмечаются обработанными,
+// остальные будут переданы повторно на следующей итерации (at-least-once).
+// Если блокировку держит другой экземпляр, возвращает 0 без ошибки.
+func (r *Repository) ProcessOutboxEvents(ctx context.Context, limit int, handle func(ctx context.Context, event models.OutboxEvent) error) (int, error) {
+	tx, err := r.p.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	qtx := r.q.WithTx(tx)
+
+	acquired, err := qtx.TryOutboxRelayLock(ctx, outboxRelayLockKey)
+	if err != nil {
+		return 0, err
+	}
+	if !acquired {
+		return 0, nil
+	}
+
+	rows, err := qtx.GetUnprocessedOutboxEvents(ctx, int32(limit))
+	if err != nil {
+		return 0, err
+	}
+
+	var processed int
+	var handleErr error
+	for _, row := range rows {
+		handleErr = handle(ctx, models.OutboxEvent{
+			ID:        row.ID,
+			WalletID:  row.WalletID,
+			EventType: row.EventType,
+			Payload:   row.Payload,
+			CreatedAt: row.CreatedAt,
+		})
+		if handleErr != nil {
+			break
+		}
+		if err := qtx.MarkOutboxEventProcessed(ctx, row.ID); err != nil {
+			return 0, err
+		}
+		processed++
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return processed, handleErr
+}
+
+func (r *Repository) DeleteProcessedOutboxEvents(ctx context.Context, processedBefore time.Time) (int, error) {
+	n, err := r.q.DeleteProcessedOutboxEvents(ctx, processedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
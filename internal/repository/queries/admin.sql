@@ -0,0 +1,52 @@
+-- name: ListWallets :many
+SELECT id, amount, status, owner_id, version, created_at, updated_at
+FROM wallets
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND (sqlc.narg(min_balance)::int IS NULL OR amount >= sqlc.narg(min_balance)::int)
+  AND (sqlc.narg(max_balance)::int IS NULL OR amount <= sqlc.narg(max_balance)::int)
+  AND (sqlc.narg(created_from)::timestamp IS NULL OR created_at >= sqlc.narg(created_from)::timestamp)
+  AND (sqlc.narg(created_to)::timestamp IS NULL OR created_at < sqlc.narg(created_to)::timestamp)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+  -- курсор выгрузки: строки после (after_created_at, after_id) в порядке сортировки
+  AND (sqlc.narg(after_created_at)::timestamp IS NULL
+       OR created_at < sqlc.narg(after_created_at)::timestamp
+       OR (created_at = sqlc.narg(after_created_at)::timestamp AND id > sqlc.arg(after_id)::text))
+ORDER BY created_at DESC, id
+LIMIT sqlc.arg(page_size) OFFSET sqlc.arg(page_offset);
+
+-- name: GetWallet :one
+SELECT id, amount, status, owner_id, version, created_at, updated_at
+FROM wallets
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: GetWalletStatus :one
+SELECT status
+FROM wallets
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: LockWalletStatus :one
+SELECT status
+FROM wallets
+WHERE id = $1 AND tenant_id = $2
+FOR UPDATE;
+
+-- name: ListWalletTransactions :many
+SELECT id, amount, operation_type, request_id, created_at
+FROM transactions
+WHERE wallet_id = $1 AND tenant_id = $2
+ORDER BY created_at DESC
+LIMIT $3;
+
+-- name: AdjustBalance :one
+-- корректировка не смотрит на статус: оператор правит и замороженные кошельки
+UPDATE wallets
+SET amount = amount + $2, version = version + 1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND tenant_id = $3
+RETURNING amount, version;
+
+-- name: SetWalletStatus :one
+-- смена статуса - событие потока, поэтому версия растет, как при движении денег
+UPDATE wallets
+SET status = $2, version = version + 1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND tenant_id = $3
+RETURNING version;
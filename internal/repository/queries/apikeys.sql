@@ -0,0 +1,19 @@
+-- name: CreateAPIKey :exec
+INSERT INTO api_keys (id, name, key_hash, scopes, owner_id, created_at, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: GetAPIKeyByHash :one
+SELECT id, name, key_hash, scopes, owner_id, created_at, revoked_at, tenant_id
+FROM api_keys
+WHERE key_hash = $1;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND tenant_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeAnyAPIKey :execrows
+-- отзыв ключом платформы или из CLI: ключ любого арендатора, в том числе ключ платформы
+UPDATE api_keys
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND revoked_at IS NULL;
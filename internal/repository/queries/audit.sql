@@ -0,0 +1,19 @@
+-- name: GetLastAuditHash :one
+-- последняя запись в цепочке кошелька
+SELECT hash
+FROM audit_log
+WHERE wallet_id = $1
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: CreateAuditEntry :exec
+INSERT INTO audit_log (tenant_id, actor, action, wallet_id, request_id, before, after, source_ip, reason, created_at, prev_hash, hash)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
+
+-- name: ListAuditEntries :many
+-- проверка цепочек читает журнал всех арендаторов страницами
+SELECT id, tenant_id, actor, action, wallet_id, request_id, before, after, source_ip, reason, created_at, prev_hash, hash
+FROM audit_log
+WHERE id > $1
+ORDER BY id
+LIMIT $2;
@@ -1,24 +1,33 @@
 -- name: CreateWallet :exec
-INSERT INTO wallets (id, amount)
-VALUES ($1, 0);
+INSERT INTO wallets (id, amount, version, owner_id, tenant_id)
+VALUES ($1, 0, 1, $2, $3);
 
 -- name: GetBalance :one
 SELECT amount 
 FROM wallets
-WHERE id = $1;
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: GetWalletOwner :one
+SELECT owner_id
+FROM wallets
+WHERE id = $1 AND tenant_id = $2;
 
 -- name: Deposit :one
 UPDATE wallets
-SET amount = amount + $2, updated_at = CURRENT_TIMESTAMP
-WHERE id = $1
-RETURNING amount;
+SET amount = amount + $2, version = version + 1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND tenant_id = $3 AND status = 'active'
+RETURNING amount, version;
 
 -- name: Withdraw :one
 UPDATE wallets
-SET amount = amount - $2, updated_at = CURRENT_TIMESTAMP
-WHERE id = $1
-RETURNING amount;
+SET amount = amount - $2, version = version + 1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND tenant_id = $3 AND status = 'active'
+RETURNING amount, version;
 
 -- name: CreateTransaction :exec
-INSERT INTO transactions (id, wallet_id, amount, operation_type)
-VALUES ($1, $2, $3, $4);
\ No newline at end of file
+INSERT INTO transactions (id, wallet_id, amount, operation_type, request_id, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: SetLocalTimeouts :exec
+-- таймауты действуют до конца транзакции; '0' отключает таймаут
+SELECT set_config('lock_timeout', sqlc.arg(lock_timeout)::text, true), set_config('statement_timeout', sqlc.arg(statement_timeout)::text, true);
@@ -0,0 +1,34 @@
+-- name: AppendWalletEvent :exec
+INSERT INTO wallet_events (wallet_id, sequence, event_type, event_version, payload, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- запросы ниже, кроме AppendWalletEvent, обслуживают пересборку проекций и намеренно идут по всем арендаторам
+
+-- name: GetWalletEvents :many
+SELECT wallet_id, sequence, event_type, event_version, payload, created_at
+FROM wallet_events
+WHERE wallet_id = $1
+ORDER BY sequence;
+
+-- name: ListEventStreamIDs :many
+SELECT DISTINCT wallet_id
+FROM wallet_events
+ORDER BY wallet_id;
+
+-- name: LockWallet :exec
+SELECT id
+FROM wallets
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpsertWalletProjection :exec
+-- у кошельков, созданных событием WalletCreated v1, владельца в потоке нет, поэтому он не затирается
+INSERT INTO wallets (id, amount, version, created_at, owner_id, status, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6, (SELECT tenant_id FROM wallet_events WHERE wallet_id = $1 AND sequence = 1))
+ON CONFLICT (id) DO UPDATE
+SET amount = EXCLUDED.amount, version = EXCLUDED.version, owner_id = COALESCE(EXCLUDED.owner_id, wallets.owner_id), status = EXCLUDED.status, updated_at = CURRENT_TIMESTAMP;
+
+-- name: GetWalletProjection :one
+SELECT id, amount, version, created_at
+FROM wallets
+WHERE id = $1;
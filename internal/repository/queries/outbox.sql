@@ -0,0 +1,23 @@
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox (wallet_id, event_type, payload)
+VALUES ($1, $2, $3);
+
+-- name: TryOutboxRelayLock :one
+SELECT pg_try_advisory_xact_lock(@lock_key::bigint) AS acquired;
+
+-- name: GetUnprocessedOutboxEvents :many
+SELECT id, wallet_id, event_type, payload, created_at
+FROM outbox
+WHERE processed_at IS NULL
+ORDER BY id
+LIMIT $1
+FOR UPDATE;
+
+-- name: MarkOutboxEventProcessed :exec
+UPDATE outbox
+SET processed_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: DeleteProcessedOutboxEvents :execrows
+DELETE FROM outbox
+WHERE processed_at IS NOT NULL AND processed_at < @processed_before::timestamp;
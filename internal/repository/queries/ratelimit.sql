@@ -0,0 +1,22 @@
+-- время берется из clock_timestamp(), а не CURRENT_TIMESTAMP: время начала транзакции, простоявшей
+-- в очереди на FOR UPDATE, раньше updated_at, записанного предыдущей транзакцией
+
+-- name: CreateRateLimitBucket :exec
+INSERT INTO rate_limit_buckets (key, tokens, updated_at, full_at)
+VALUES ($1, $2, clock_timestamp(), clock_timestamp())
+ON CONFLICT (key) DO NOTHING;
+
+-- name: LockRateLimitBucket :one
+SELECT tokens, GREATEST(EXTRACT(EPOCH FROM clock_timestamp() - updated_at), 0)::float8 AS elapsed
+FROM rate_limit_buckets
+WHERE key = $1
+FOR UPDATE;
+
+-- name: UpdateRateLimitBucket :exec
+UPDATE rate_limit_buckets
+SET tokens = $2, updated_at = clock_timestamp(), full_at = clock_timestamp() + sqlc.arg(full_in)::float8 * INTERVAL '1 second'
+WHERE key = $1;
+
+-- name: DeleteFullRateLimitBuckets :execrows
+DELETE FROM rate_limit_buckets
+WHERE full_at < clock_timestamp();
@@ -0,0 +1,11 @@
+-- name: CurrentLSN :one
+-- позиция WAL на основном сервере; после коммита она не меньше позиции записи коммита
+SELECT pg_current_wal_lsn()::text AS lsn;
+
+-- name: GetReplicaStatus :one
+-- если реплика воспроизвела все, что получила, отставания нет, даже когда на основном сервере давно не было записей.
+-- Пустой replay_lsn - сервер не в режиме восстановления, то есть это не реплика
+SELECT COALESCE(pg_last_wal_replay_lsn()::text, '')::text AS replay_lsn,
+       (CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+             ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+        END)::float8 AS lag_seconds;
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/ratelimit"
+	"github.com/glekoz/test_itk/internal/repository/db"
+)
+
+// TakeRateLimitToken берет токен из корзины key под блокировкой строки.
+// Время считается по часам базы в момент запроса, чтобы расхождение часов реплик и ожидание блокировки не влияли на пополнение
+func (r *Repository) TakeRateLimitToken(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	tx, err := r.p.Begin(ctx)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	defer tx.Rollback(ctx)
+	qtx := r.q.WithTx(tx)
+
+	err = qtx.CreateRateLimitBucket(ctx, db.CreateRateLimitBucketParams{
+		Key:    key,
+		Tokens: float64(limit.Burst),
+	})
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	row, err := qtx.LockRateLimitBucket(ctx, key)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	tokens, res := ratelimit.Apply(row.Tokens, time.Duration(row.Elapsed*float64(time.Second)), limit)
+	err = qtx.UpdateRateLimitBucket(ctx, db.UpdateRateLimitBucketParams{
+		Key:    key,
+		Tokens: tokens,
+		FullIn: ratelimit.FullIn(tokens, limit).Seconds(),
+	})
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	return res, tx.Commit(ctx)
+}
+
+func (r *Repository) DeleteFullRateLimitBuckets(ctx context.Context) (int64, error) {
+	return r.q.DeleteFullRateLimitBuckets(ctx)
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/metrics"
+	"github.com/glekoz/test_itk/internal/repository/db"
+	"github.com/glekoz/test_itk/internal/shared/consistency"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReplicaState - состояние реплики на момент последней проверки, по нему чтение выбирает сервер
+type ReplicaState struct {
+	maxLag time.Duration
+
+	mu       sync.RWMutex
+	err      error
+	lag      time.Duration
+	replayed consistency.LSN
+}
+
+// NewReplicaState: реплика считается недоступной до первой успешной проверки.
+// maxLag 0 - отставание не ограничено, остается только требование токена согласованности
+func NewReplicaState(maxLag time.Duration) *ReplicaState {
+	return &ReplicaState{maxLag: maxLag, err: errors.New("replica has not been checked yet")}
+}
+
+// Update запоминает результат успешной проверки: сколько WAL реплика воспроизвела и насколько отстает
+func (s *ReplicaState) Update(replayed consistency.LSN, lag time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = nil
+	s.lag = lag
+	s.replayed = replayed
+}
+
+// Fail помечает реплику недоступной до следующей успешной проверки
+func (s *ReplicaState) Fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Check возвращает nil, если с реплики можно читать; подходит для проверки готовности
+func (s *ReplicaState) Check() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.check()
+}
+
+// Serves сообщает, увидит ли чтение с реплики все записи до позиции required
+func (s *ReplicaState) Serves(required consistency.LSN) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.check() == nil && s.replayed >= required
+}
+
+func (s *ReplicaState) check() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.maxLag > 0 && s.lag > s.maxLag {
+		return fmt.Errorf("replication lag %s exceeds %s", s.lag, s.maxLag)
+	}
+	return nil
+}
+
+type replica struct {
+	q     *db.Queries
+	p     *pgxpool.Pool
+	state *ReplicaState
+}
+
+// read выполняет чтение на реплике, если она здорова, отстает не больше допустимого и уже воспроизвела
+// записи из токена согласованности. Иначе, а также если реплика ответила ошибкой, читает с основного сервера
+func (r *Repository) read(ctx context.Context, fn func(q *db.Queries) error) error {
+	if r.replica != nil && r.replica.state.Serves(consistency.FromContext(ctx).Required()) {
+		err := fn(r.replica.q)
+		if err == nil {
+			metrics.DBReads.WithLabelValues(metrics.ReadReplica).Inc()
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		// кошелек мог появиться после позиции, до которой дошла реплика, поэтому отсутствие строки перепроверяется
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logging.FromContext(ctx, r.logger).WarnContext(ctx, "replica read failed, falling back to primary", logging.Error(err))
+		}
+	}
+	metrics.DBReads.WithLabelValues(metrics.ReadPrimary).Inc()
+	return fn(r.q)
+}
+
+// rememberWrite сообщает клиенту позицию WAL после коммита записи, чтобы следующее чтение с реплики ее дождалось.
+// Без реплики и вне HTTP-запроса позиция не нужна, и лишний запрос не делается
+func (r *Repository) rememberWrite(ctx context.Context) {
+	token := consistency.FromContext(ctx)
+	if r.replica == nil || token == nil {
+		return
+	}
+	lsn, err := r.q.CurrentLSN(ctx)
+	if err == nil {
+		var parsed consistency.LSN
+		if parsed, err = consistency.ParseLSN(lsn); err == nil {
+			token.Wrote(parsed)
+			return
+		}
+	}
+	// запись уже закоммичена, поэтому ошибка не возвращается: клиент лишь не получит токен
+	logging.FromContext(ctx, r.logger).WarnContext(ctx, "can not get wal position after commit", logging.Error(err))
+}
+
+// MonitorReplica проверяет реплику каждые interval, пока не отменен ctx. Без реплики сразу возвращается
+func (r *Repository) MonitorReplica(ctx context.Context, interval time.Duration) {
+	if r.replica == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		before := r.replica.state.Check()
+		r.checkReplica(ctx)
+		after := r.replica.state.Check()
+		switch {
+		case before == nil && after != nil:
+			r.logger.Warn("replica is unavailable, reading from primary", logging.Error(after))
+		case before != nil && after == nil:
+			r.logger.Info("replica is available again")
+		}
+	}
+}
+
+func (r *Repository) checkReplica(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	status, err := r.replica.q.GetReplicaStatus(ctx)
+	if err == nil && status.ReplayLsn == "" {
+		err = errors.New("replica server is not in recovery")
+	}
+	var replayed consistency.LSN
+	if err == nil {
+		replayed, err = consistency.ParseLSN(status.ReplayLsn)
+	}
+	if err != nil {
+		r.replica.state.Fail(err)
+		return
+	}
+	metrics.ReplicaLag.Set(status.LagSeconds)
+	r.replica.state.Update(replayed, time.Duration(status.LagSeconds*float64(time.Second)))
+}
+
+// CheckReplica - проверка готовности: без реплики всегда успешна
+func (r *Repository) CheckReplica(ctx context.Context) error {
+	if r.replica == nil {
+		return nil
+	}
+	return r.replica.state.Check()
+}
+
+func newReplica(ctx context.Context, dsn string, opts Options) (*replica, error) {
+	pool, err := newPool(ctx, dsn, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &replica{q: db.New(pool), p: pool, state: NewReplicaState(opts.ReplicaMaxLag)}, nil
+}
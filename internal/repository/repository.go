@@ -3,47 +3,214 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
 
+	"github.com/glekoz/test_itk/internal/audit"
+	"github.com/glekoz/test_itk/internal/events"
+	"github.com/glekoz/test_itk/internal/logging"
 	"github.com/glekoz/test_itk/internal/repository/db"
+	"github.com/glekoz/test_itk/internal/shared/models"
 	"github.com/glekoz/test_itk/internal/shared/myerrors"
 	"github.com/glekoz/test_itk/internal/shared/myvars"
+	"github.com/glekoz/test_itk/internal/shared/requestid"
+	"github.com/glekoz/test_itk/internal/tenant"
+	"github.com/glekoz/test_itk/internal/tracing"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Repository struct {
-	q *db.Queries
-	p *pgxpool.Pool
+	q         *db.Queries
+	p         *pgxpool.Pool
+	txOptions pgx.TxOptions
+	retry     RetryPolicy
+	timeouts  *db.SetLocalTimeoutsParams // nil - таймауты операций не задаются
+	replica   *replica                   // nil - реплики нет, все читается с основного сервера
+	logger    *slog.Logger
 }
 
-func New(ctx context.Context, dsn string) (*Repository, error) {
-	pool, err := pgxpool.New(ctx, dsn)
+// Options - настройки пула и транзакций с кошельками; нулевые значения оставляют то,
+// что задано в DSN или по умолчанию в pgx
+type Options struct {
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+
+	// ConnectAttempts - сколько раз проверить подключение при запуске; пауза между попытками
+	// начинается с ConnectBackoff и удваивается. 0 - не проверять
+	ConnectAttempts int
+	ConnectBackoff  time.Duration
+
+	// таймауты транзакций с кошельками: запрос, который ждет блокировку горячего кошелька,
+	// прерывается через LockTimeout и возвращает myerrors.ErrTimeout. 0 - без таймаута
+	StatementTimeout time.Duration
+	LockTimeout      time.Duration
+
+	// Isolation - уровень изоляции транзакций с кошельками; пустой - read committed.
+	// Транзакции, отклоненные из-за конфликта сериализации или взаимной блокировки, повторяются по Retry
+	Isolation pgx.TxIsoLevel
+	Retry     RetryPolicy
+
+	// ReplicaURL - реплика для чтения балансов и истории операций; пустой - все читается с основного сервера.
+	// Пул реплики настраивается так же, как основной. Реплика, отстающая больше ReplicaMaxLag, не используется
+	ReplicaURL    string
+	ReplicaMaxLag time.Duration
+}
+
+func New(ctx context.Context, dsn string, opts Options, logger *slog.Logger) (*Repository, error) {
+	pool, err := newPool(ctx, dsn, opts)
 	if err != nil {
 		return nil, err
 	}
-	return &Repository{
-		q: db.New(pool),
-		p: pool,
-	}, nil
+	if err := ping(ctx, pool, opts.ConnectAttempts, opts.ConnectBackoff, logger); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	r := &Repository{
+		q:         db.New(pool),
+		p:         pool,
+		txOptions: pgx.TxOptions{IsoLevel: opts.Isolation},
+		retry:     opts.Retry,
+		logger:    logger,
+	}
+	if opts.StatementTimeout > 0 || opts.LockTimeout > 0 {
+		r.timeouts = &db.SetLocalTimeoutsParams{
+			LockTimeout:      strconv.FormatInt(opts.LockTimeout.Milliseconds(), 10),
+			StatementTimeout: strconv.FormatInt(opts.StatementTimeout.Milliseconds(), 10),
+		}
+	}
+	// недоступная реплика не мешает запуску: до первой успешной проверки все читается с основного сервера
+	if opts.ReplicaURL != "" {
+		r.replica, err = newReplica(ctx, opts.ReplicaURL, opts)
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("replica: %w", err)
+		}
+		r.checkReplica(ctx)
+		if err := r.replica.state.Check(); err != nil {
+			logger.Warn("replica is unavailable, reading from primary", logging.Error(err))
+		}
+	}
+	return r, nil
 }
 
-func (r *Repository) CreateWallet(ctx context.Context, id string) error {
-	err := r.q.CreateWallet(ctx, id)
+func newPool(ctx context.Context, dsn string, opts Options) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
-		var errp *pgconn.PgError
-		if errors.As(err, &errp) {
-			if errp.Code == UniqueViolationCode {
-				return myerrors.ErrAlreadyExists
-			}
+		return nil, err
+	}
+	config.ConnConfig.Tracer = tracing.QueryTracer{}
+	if opts.MaxConns > 0 {
+		config.MaxConns = opts.MaxConns
+	}
+	if opts.MinConns > 0 {
+		config.MinConns = opts.MinConns
+	}
+	if opts.MaxConnLifetime > 0 {
+		config.MaxConnLifetime = opts.MaxConnLifetime
+	}
+	if opts.MaxConnIdleTime > 0 {
+		config.MaxConnIdleTime = opts.MaxConnIdleTime
+	}
+	if opts.HealthCheckPeriod > 0 {
+		config.HealthCheckPeriod = opts.HealthCheckPeriod
+	}
+	return pgxpool.NewWithConfig(ctx, config)
+}
+
+// ping ждет базу при запуске: в docker-compose приложение может подняться раньше, чем Postgres начнет принимать подключения
+func ping(ctx context.Context, pool *pgxpool.Pool, attempts int, backoff time.Duration, logger *slog.Logger) error {
+	if attempts < 1 {
+		return nil
+	}
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err = pool.Ping(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
 		}
+		logger.WarnContext(ctx, "database is not ready, retrying",
+			slog.Int("attempt", attempt), slog.Duration("backoff", backoff), logging.Error(err))
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+	return fmt.Errorf("database is not ready after %d attempts: %w", attempts, err)
+}
+
+// timeoutError превращает сработавший lock_timeout или statement_timeout в myerrors.ErrTimeout.
+// Отмена запроса клиентом дает тот же код, что и statement_timeout, поэтому при отмененном ctx ошибка не меняется
+func timeoutError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() != nil {
 		return err
 	}
-	return nil
+	var errp *pgconn.PgError
+	if errors.As(err, &errp) && (errp.Code == LockNotAvailableCode || errp.Code == QueryCanceledCode) {
+		return fmt.Errorf("%w: %s", myerrors.ErrTimeout, errp.Message)
+	}
+	return err
+}
+
+func (r *Repository) CreateWallet(ctx context.Context, id, ownerID string) error {
+	return r.inTx(ctx, func(qtx *db.Queries) error {
+		err := qtx.CreateWallet(ctx, db.CreateWalletParams{
+			ID:       id,
+			OwnerID:  pgtype.Text{String: ownerID, Valid: ownerID != ""},
+			TenantID: tenant.ID(ctx),
+		})
+		if err != nil {
+			var errp *pgconn.PgError
+			if errors.As(err, &errp) {
+				if errp.Code == UniqueViolationCode {
+					return myerrors.ErrAlreadyExists
+				}
+			}
+			return err
+		}
+
+		err = appendEvent(ctx, qtx, id, 1, events.WalletCreated{OwnerID: ownerID})
+		if err != nil {
+			if errors.Is(err, myerrors.ErrConflict) {
+				return myerrors.ErrAlreadyExists
+			}
+			return err
+		}
+
+		balance := 0
+		return writeAudit(ctx, qtx, audit.ActionCreateWallet, id, nil, &audit.State{
+			Balance: &balance,
+			Status:  string(myvars.WalletStatusActive),
+			OwnerID: ownerID,
+		}, "")
+	})
 }
 
 func (r *Repository) GetBalance(ctx context.Context, id string) (int, error) {
-	amount, err := r.q.GetBalance(ctx, id)
+	var amount int32
+	err := r.read(ctx, func(q *db.Queries) error {
+		var err error
+		amount, err = q.GetBalance(ctx, db.GetBalanceParams{
+			ID:       id,
+			TenantID: tenant.ID(ctx),
+		})
+		return err
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return 0, myerrors.ErrNotFound
@@ -53,102 +220,189 @@ func (r *Repository) GetBalance(ctx context.Context, id string) (int, error) {
 	return int(amount), nil
 }
 
-func (r *Repository) Deposit(ctx context.Context, walletID, transactionID string, amount int, operationType myvars.OperationType) (int, error) {
-	tx, err := r.p.Begin(ctx)
-	if err != nil {
-		return 0, err
-	}
-	defer tx.Rollback(ctx)
-	qtx := r.q.WithTx(tx)
-
-	balance, err := qtx.Deposit(ctx, db.DepositParams{
-		ID:     walletID,
-		Amount: int32(amount),
+// GetWalletOwner возвращает пустую строку для кошельков без владельца
+func (r *Repository) GetWalletOwner(ctx context.Context, id string) (string, error) {
+	owner, err := r.q.GetWalletOwner(ctx, db.GetWalletOwnerParams{
+		ID:       id,
+		TenantID: tenant.ID(ctx),
 	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return 0, myerrors.ErrNotFound
+			return "", myerrors.ErrNotFound
 		}
-		return 0, err
+		return "", err
 	}
+	return owner.String, nil
+}
 
-	err = qtx.CreateTransaction(ctx, db.CreateTransactionParams{
-		ID:            transactionID,
-		WalletID:      walletID,
-		Amount:        int32(amount),
-		OperationType: string(operationType),
-	})
-	if err != nil {
-		var errp *pgconn.PgError
-		if errors.As(err, &errp) {
-			if errp.Code == UniqueViolationCode {
-				return 0, myerrors.ErrAlreadyExists
+func (r *Repository) Deposit(ctx context.Context, walletID, transactionID string, amount int, operationType myvars.OperationType) (int, error) {
+	var balance int
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		wallet, err := qtx.Deposit(ctx, db.DepositParams{
+			ID:       walletID,
+			Amount:   int32(amount),
+			TenantID: tenant.ID(ctx),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return missingWallet(ctx, qtx, walletID)
 			}
-			if errp.Code == ForeignKeyViolationCode {
-				return 0, myerrors.ErrNotFound
+			return err
+		}
+
+		err = qtx.CreateTransaction(ctx, db.CreateTransactionParams{
+			ID:            transactionID,
+			WalletID:      walletID,
+			Amount:        int32(amount),
+			OperationType: string(operationType),
+			RequestID:     requestIDParam(ctx),
+			TenantID:      tenant.ID(ctx),
+		})
+		if err != nil {
+			var errp *pgconn.PgError
+			if errors.As(err, &errp) {
+				if errp.Code == UniqueViolationCode {
+					return myerrors.ErrAlreadyExists
+				}
+				if errp.Code == ForeignKeyViolationCode {
+					return myerrors.ErrNotFound
+				}
 			}
+			return err
 		}
-		return 0, err
-	}
-	err = tx.Commit(ctx)
-	if err != nil {
-		return 0, err
-	}
-	return int(balance), nil
-}
 
-func (r *Repository) Withdraw(ctx context.Context, walletID, transactionID string, amount int, operationType myvars.OperationType) (int, error) {
-	tx, err := r.p.Begin(ctx)
-	if err != nil {
-		return 0, err
-	}
-	defer tx.Rollback(ctx)
-	qtx := r.q.WithTx(tx)
+		err = appendEvent(ctx, qtx, walletID, wallet.Version, events.Deposited{
+			TransactionID: transactionID,
+			Amount:        amount,
+		})
+		if err != nil {
+			return err
+		}
 
-	balance, err := qtx.Withdraw(ctx, db.WithdrawParams{
-		ID:     walletID,
-		Amount: int32(amount),
-	})
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return 0, myerrors.ErrNotFound
+		err = writeOutboxEvent(ctx, qtx, models.WalletEvent{
+			Type:          myvars.EventTypeDeposited,
+			WalletID:      walletID,
+			TransactionID: transactionID,
+			Amount:        amount,
+			Balance:       int(wallet.Amount),
+			OccurredAt:    time.Now().UTC(),
+		})
+		if err != nil {
+			return err
 		}
-		var errp *pgconn.PgError
-		if errors.As(err, &errp) {
-			if errp.Code == CheckViolationCode {
-				return 0, myerrors.ErrNegativeAmount
-			}
+
+		err = writeAudit(ctx, qtx, audit.ActionDeposit, walletID, balanceState(int(wallet.Amount)-amount), balanceState(int(wallet.Amount)), "")
+		if err != nil {
+			return err
 		}
-		return 0, err
-	}
 
-	err = qtx.CreateTransaction(ctx, db.CreateTransactionParams{
-		ID:            transactionID,
-		WalletID:      walletID,
-		Amount:        int32(amount),
-		OperationType: string(operationType),
+		balance = int(wallet.Amount)
+		return nil
 	})
-	if err != nil {
-		var errp *pgconn.PgError
-		if errors.As(err, &errp) {
-			if errp.Code == UniqueViolationCode {
-				return 0, myerrors.ErrAlreadyExists
+	return balance, err
+}
+
+func (r *Repository) Withdraw(ctx context.Context, walletID, transactionID string, amount int, operationType myvars.OperationType) (int, error) {
+	var balance int
+	err := r.inTx(ctx, func(qtx *db.Queries) error {
+		wallet, err := qtx.Withdraw(ctx, db.WithdrawParams{
+			ID:       walletID,
+			Amount:   int32(amount),
+			TenantID: tenant.ID(ctx),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return missingWallet(ctx, qtx, walletID)
 			}
-			if errp.Code == ForeignKeyViolationCode {
-				return 0, myerrors.ErrNotFound
+			var errp *pgconn.PgError
+			if errors.As(err, &errp) {
+				if errp.Code == CheckViolationCode {
+					return myerrors.ErrNegativeAmount
+				}
 			}
+			return err
 		}
-		return 0, err
-	}
 
-	err = tx.Commit(ctx)
+		err = qtx.CreateTransaction(ctx, db.CreateTransactionParams{
+			ID:            transactionID,
+			WalletID:      walletID,
+			Amount:        int32(amount),
+			OperationType: string(operationType),
+			RequestID:     requestIDParam(ctx),
+			TenantID:      tenant.ID(ctx),
+		})
+		if err != nil {
+			var errp *pgconn.PgError
+			if errors.As(err, &errp) {
+				if errp.Code == UniqueViolationCode {
+					return myerrors.ErrAlreadyExists
+				}
+				if errp.Code == ForeignKeyViolationCode {
+					return myerrors.ErrNotFound
+				}
+			}
+			return err
+		}
+
+		err = appendEvent(ctx, qtx, walletID, wallet.Version, events.Withdrawn{
+			TransactionID: transactionID,
+			Amount:        amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = writeOutboxEvent(ctx, qtx, models.WalletEvent{
+			Type:          myvars.EventTypeWithdrawn,
+			WalletID:      walletID,
+			TransactionID: transactionID,
+			Amount:        amount,
+			Balance:       int(wallet.Amount),
+			OccurredAt:    time.Now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+
+		err = writeAudit(ctx, qtx, audit.ActionWithdraw, walletID, balanceState(int(wallet.Amount)+amount), balanceState(int(wallet.Amount)), "")
+		if err != nil {
+			return err
+		}
+
+		balance = int(wallet.Amount)
+		return nil
+	})
+	return balance, err
+}
+
+// missingWallet объясняет, почему перевод не нашел кошелек: его нет у арендатора или он заморожен
+func missingWallet(ctx context.Context, qtx *db.Queries, walletID string) error {
+	_, err := qtx.GetWalletStatus(ctx, db.GetWalletStatusParams{
+		ID:       walletID,
+		TenantID: tenant.ID(ctx),
+	})
 	if err != nil {
-		return 0, err
+		if errors.Is(err, pgx.ErrNoRows) {
+			return myerrors.ErrNotFound
+		}
+		return err
 	}
+	return myerrors.ErrWalletFrozen
+}
 
-	return int(balance), nil
+// requestIDParam связывает операцию с HTTP-запросом, из которого она пришла
+func requestIDParam(ctx context.Context) pgtype.Text {
+	id := requestid.FromContext(ctx)
+	return pgtype.Text{String: id, Valid: id != ""}
+}
+
+func (r *Repository) Stat() *pgxpool.Stat {
+	return r.p.Stat()
 }
 
 func (r *Repository) Close() {
+	if r.replica != nil {
+		r.replica.p.Close()
+	}
 	r.p.Close()
 }
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/metrics"
+	"github.com/glekoz/test_itk/internal/repository/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Причины повтора транзакции, они же значения метки reason в metrics.TxRetries
+const (
+	RetrySerializationFailure = "serialization_failure"
+	RetryDeadlock             = "deadlock"
+)
+
+// TxBeginner - пул соединений; в тестах подменяется заглушкой
+type TxBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// RetryPolicy: MaxAttempts - сколько раз всего выполнить транзакцию, 1 - без повторов.
+// Пауза перед n-м повтором выбирается случайно из [0, Backoff * 2^(n-1)], чтобы столкнувшиеся
+// транзакции не повторялись одновременно и не сталкивались снова
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+// InTx выполняет fn в транзакции и повторяет ее целиком, если Postgres отклонил ее из-за
+// конфликта сериализации (40001) или взаимной блокировки (40P01). Остальные ошибки возвращаются сразу.
+// fn может выполниться несколько раз, поэтому результаты она должна записывать, а не накапливать
+func InTx(ctx context.Context, b TxBeginner, opts pgx.TxOptions, policy RetryPolicy, fn func(tx pgx.Tx) error) error {
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, b, opts, fn)
+		reason := retryReason(err)
+		if reason == "" || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+		metrics.TxRetries.WithLabelValues(reason).Inc()
+
+		var delay time.Duration
+		if ceiling := policy.Backoff << (attempt - 1); ceiling > 0 {
+			delay = rand.N(ceiling + 1)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+func runTx(ctx context.Context, b TxBeginner, opts pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	tx, err := b.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func retryReason(err error) string {
+	var errp *pgconn.PgError
+	if !errors.As(err, &errp) {
+		return ""
+	}
+	switch errp.Code {
+	case SerializationFailureCode:
+		return RetrySerializationFailure
+	case DeadlockDetectedCode:
+		return RetryDeadlock
+	}
+	return ""
+}
+
+// inTx - InTx для операций с кошельками: уровень изоляции, повторы и таймауты берутся из Options,
+// сработавший таймаут превращается в myerrors.ErrTimeout. После коммита позиция WAL попадает в токен согласованности
+func (r *Repository) inTx(ctx context.Context, fn func(qtx *db.Queries) error) error {
+	err := InTx(ctx, r.p, r.txOptions, r.retry, func(tx pgx.Tx) error {
+		qtx := r.q.WithTx(tx)
+		if r.timeouts != nil {
+			if err := qtx.SetLocalTimeouts(ctx, *r.timeouts); err != nil {
+				return err
+			}
+		}
+		return fn(qtx)
+	})
+	if err != nil {
+		return timeoutError(ctx, err)
+	}
+	r.rememberWrite(ctx)
+	return nil
+}
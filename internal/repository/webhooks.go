@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/repository/db"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+	"github.com/glekoz/test_itk/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (r *Repository) CreateWebhookSubscription(ctx context.Context, sub models.WebhookSubscription) error {
+	err := r.q.CreateWebhookSubscription(ctx, db.CreateWebhookSubscriptionParams{
+		ID:       sub.ID,
+		WalletID: pgtype.Text{String: sub.WalletID, Valid: sub.WalletID != ""},
+		Url:      sub.URL,
+		Secret:   sub.Secret,
+		TenantID: tenant.ID(ctx),
+	})
+	if err != nil {
+		var errp *pgconn.PgError
+		if errors.As(err, &errp) {
+			if errp.Code == UniqueViolationCode {
+				return myerrors.ErrAlreadyExists
+			}
+			if errp.Code == ForeignKeyViolationCode {
+				return myerrors.ErrNotFound
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]models.WebhookDelivery, error) {
+	_, err := r.q.GetWebhookSubscription(ctx, db.GetWebhookSubscriptionParams{
+		ID:       subscriptionID,
+		TenantID: tenant.ID(ctx),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, myerrors.ErrNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := r.q.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{
+		SubscriptionID: subscriptionID,
+		Limit:          int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			ID:             row.ID,
+			SubscriptionID: row.SubscriptionID,
+			EventID:        row.EventID,
+			EventType:      row.EventType,
+			Payload:        row.Payload,
+			Status:         myvars.DeliveryStatus(row.Status),
+			Attempts:       int(row.Attempts),
+			NextAttemptAt:  row.NextAttemptAt,
+			LastError:      row.LastError,
+			CreatedAt:      row.CreatedAt,
+			UpdatedAt:      row.UpdatedAt,
+		})
+	}
+	return deliveries, nil
+}
+
+// ReplayWebhookDelivery возвращает ErrDeliveryInProgress, если доставку сейчас отправляет воркер
+func (r *Repository) ReplayWebhookDelivery(ctx context.Context, deliveryID string) error {
+	n, err := r.q.ReplayWebhookDelivery(ctx, db.ReplayWebhookDeliveryParams{
+		ID:       deliveryID,
+		TenantID: tenant.ID(ctx),
+	})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	exists, err := r.q.WebhookDeliveryExists(ctx, db.WebhookDeliveryExistsParams{
+		ID:       deliveryID,
+		TenantID: tenant.ID(ctx),
+	})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return myerrors.ErrDeliveryInProgress
+	}
+	return myerrors.ErrNotFound
+}
+
+// CreateWebhookDeliveries создает по одной доставке на каждую подписку, подходящую под событие.
+// Повторный вызов для того же события ничего не меняет, поэтому его безопасно использовать при at-least-once доставке
+func (r *Repository) CreateWebhookDeliveries(ctx context.Context, event models.OutboxEvent) error {
+	subscriptions, err := r.q.GetWebhookSubscriptionsForWallet(ctx, event.WalletID)
+	if err != nil {
+		return err
+	}
+	for _, subscriptionID := range subscriptions {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return err
+		}
+		err = r.q.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+			ID:             id.String(),
+			SubscriptionID: subscriptionID,
+			EventID:        event.ID,
+			EventType:      event.EventType,
+			Payload:        event.Payload,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClaimWebhookDeliveries захватывает готовые к отправке доставки на время lease,
+// чтобы другие экземпляры приложения не отправили их повторно
+func (r *Repository) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookAttempt, error) {
+	rows, err := r.q.ClaimDueWebhookDeliveries(ctx, db.ClaimDueWebhookDeliveriesParams{
+		LeaseSeconds: int32(lease / time.Second),
+		MaxCount:     int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := make([]models.WebhookAttempt, 0, len(rows))
+	for _, row := range rows {
+		attempts = append(attempts, models.WebhookAttempt{
+			DeliveryID: row.ID,
+			EventID:    row.EventID,
+			EventType:  row.EventType,
+			Payload:    row.Payload,
+			Attempts:   int(row.Attempts),
+			URL:        row.Url,
+			Secret:     row.Secret,
+		})
+	}
+	return attempts, nil
+}
+
+func (r *Repository) MarkWebhookDeliveryDelivered(ctx context.Context, deliveryID string) error {
+	return r.q.MarkWebhookDeliveryDelivered(ctx, deliveryID)
+}
+
+func (r *Repository) MarkWebhookDeliveryFailed(ctx context.Context, deliveryID string, status myvars.DeliveryStatus, lastErr string, nextAttemptAt time.Time) error {
+	return r.q.MarkWebhookDeliveryFailed(ctx, db.MarkWebhookDeliveryFailedParams{
+		ID:            deliveryID,
+		Status:        string(status),
+		LastError:     lastErr,
+		NextAttemptAt: nextAttemptAt,
+	})
+}
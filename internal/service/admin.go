@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strings"
+
+	"github.com/glekoz/test_itk/internal/audit"
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+	"github.com/glekoz/test_itk/internal/tenant"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// столько последних операций показывается в карточке кошелька
+	walletDetailTransactions = 20
+	// страница, которой выгрузка читает кошельки из БД
+	exportPageSize = 500
+)
+
+// Действия операторов в логе. Изменения дополнительно пишутся в audit_log тем же действием
+const (
+	AuditListWallets     = "wallet.list"
+	AuditGetWallet       = "wallet.get"
+	AuditExportWallets   = "wallet.export"
+	AuditAdjustBalance   = audit.ActionAdjustBalance
+	AuditSetWalletStatus = audit.ActionSetWalletStatus
+)
+
+func (a *Service) ListWallets(ctx context.Context, filter models.WalletFilter) (_ []models.Wallet, err error) {
+	ctx, span := startSpan(ctx, "service.ListWallets", "")
+	defer func() { endSpan(span, err) }()
+	defer func() { a.audit(ctx, AuditListWallets, "", err, filterAttrs(filter)...) }()
+
+	return a.repo.ListWallets(ctx, filter)
+}
+
+func (a *Service) GetWallet(ctx context.Context, walletID string) (_ models.WalletDetail, err error) {
+	ctx, span := startSpan(ctx, "service.GetWallet", walletID)
+	defer func() { endSpan(span, err) }()
+	defer func() { a.audit(ctx, AuditGetWallet, walletID, err) }()
+
+	wallet, err := a.repo.GetWallet(ctx, walletID)
+	if err != nil {
+		return models.WalletDetail{}, err
+	}
+	transactions, err := a.repo.ListWalletTransactions(ctx, walletID, walletDetailTransactions)
+	if err != nil {
+		return models.WalletDetail{}, err
+	}
+	return models.WalletDetail{Wallet: wallet, Transactions: transactions}, nil
+}
+
+// ExportWallets передает в fn все кошельки, подходящие под фильтр; Limit и Offset фильтра не учитываются.
+// Выгрузка читается страницами по курсору, поэтому не держит весь список в памяти и не дублирует
+// и не теряет строки, когда во время выгрузки создаются новые кошельки
+func (a *Service) ExportWallets(ctx context.Context, filter models.WalletFilter, fn func(models.Wallet) error) (err error) {
+	ctx, span := startSpan(ctx, "service.ExportWallets", "")
+	defer func() { endSpan(span, err) }()
+	var exported int
+	defer func() {
+		a.audit(ctx, AuditExportWallets, "", err, append(filterAttrs(filter), slog.Int("exported", exported))...)
+	}()
+
+	filter.Limit, filter.Offset, filter.After = exportPageSize, 0, nil
+	for {
+		wallets, err := a.repo.ListWallets(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, w := range wallets {
+			if err := fn(w); err != nil {
+				return err
+			}
+			exported++
+		}
+		if len(wallets) < filter.Limit {
+			return nil
+		}
+		last := wallets[len(wallets)-1]
+		filter.After = &models.WalletCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+// AdjustBalance меняет баланс на amount со знаком в обход статуса кошелька и лимитов арендатора
+func (a *Service) AdjustBalance(ctx context.Context, walletID string, amount int, reason string) (_ int, err error) {
+	ctx, span := startSpan(ctx, "service.AdjustBalance", walletID, attribute.Int("wallet.amount", amount))
+	defer func() { endSpan(span, err) }()
+	defer func() {
+		a.audit(ctx, AuditAdjustBalance, walletID, err, logging.Amount(amount), slog.String("reason", reason))
+	}()
+
+	if amount == 0 {
+		return 0, myerrors.ErrInvalidInput
+	}
+	// сумма передается в БД как int32; без проверки -3000000000 превратилось бы в зачисление
+	if amount < math.MinInt32 || amount > math.MaxInt32 {
+		return 0, myerrors.ErrAmountRange
+	}
+	if strings.TrimSpace(reason) == "" {
+		return 0, myerrors.ErrReasonRequired
+	}
+	transactionID, err := uuid.NewV7()
+	if err != nil {
+		a.log(ctx).ErrorContext(ctx, "new uuid creating failed", logging.Error(err))
+		return 0, err
+	}
+	balance, err := a.repo.AdjustBalance(ctx, walletID, transactionID.String(), amount, reason)
+	// счетчик суммы не может уменьшаться, поэтому списание учитывается по модулю
+	observeOperation(myvars.OperationTypeAdjustment, max(amount, -amount), err)
+	if err != nil {
+		return 0, err
+	}
+	if err := a.cache.Add(tenant.ID(ctx), walletID, balance); err != nil {
+		a.cache.Delete(tenant.ID(ctx), walletID)
+	}
+	return balance, nil
+}
+
+func (a *Service) SetWalletStatus(ctx context.Context, walletID string, status myvars.WalletStatus, reason string) (err error) {
+	ctx, span := startSpan(ctx, "service.SetWalletStatus", walletID)
+	defer func() { endSpan(span, err) }()
+	defer func() {
+		a.audit(ctx, AuditSetWalletStatus, walletID, err, slog.String("wallet_status", string(status)), slog.String("reason", reason))
+	}()
+
+	if status != myvars.WalletStatusActive && status != myvars.WalletStatusFrozen {
+		return myerrors.ErrInvalidInput
+	}
+	if strings.TrimSpace(reason) == "" {
+		return myerrors.ErrReasonRequired
+	}
+	return a.repo.SetWalletStatus(ctx, walletID, status, reason)
+}
+
+// audit пишет действие оператора в лог, в том числе неудачное и чтение, которых нет в audit_log.
+// Request ID и арендатор попадают в запись из логгера запроса
+func (a *Service) audit(ctx context.Context, action, walletID string, err error, attrs ...slog.Attr) {
+	var actor string
+	if p, ok := auth.FromContext(ctx); ok {
+		actor = p.ID
+	}
+	attrs = append([]slog.Attr{
+		slog.Bool("audit", true),
+		slog.String("actor", actor),
+		slog.String("action", action),
+		slog.String(logging.KeyTenantID, tenant.ID(ctx)),
+	}, attrs...)
+	if walletID != "" {
+		attrs = append(attrs, logging.WalletID(walletID))
+	}
+	if err != nil {
+		attrs = append(attrs, logging.Error(err))
+	}
+	a.log(ctx).LogAttrs(ctx, slog.LevelInfo, "admin action", attrs...)
+}
+
+func filterAttrs(f models.WalletFilter) []slog.Attr {
+	var attrs []slog.Attr
+	if f.MinBalance != nil {
+		attrs = append(attrs, slog.Int("min_balance", *f.MinBalance))
+	}
+	if f.MaxBalance != nil {
+		attrs = append(attrs, slog.Int("max_balance", *f.MaxBalance))
+	}
+	if f.CreatedFrom != nil {
+		attrs = append(attrs, slog.Time("created_from", *f.CreatedFrom))
+	}
+	if f.CreatedTo != nil {
+		attrs = append(attrs, slog.Time("created_to", *f.CreatedTo))
+	}
+	if f.Status != "" {
+		attrs = append(attrs, slog.String("wallet_status", string(f.Status)))
+	}
+	return attrs
+}
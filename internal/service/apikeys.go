@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/tenant"
+	"github.com/google/uuid"
+)
+
+// IssueAPIKey возвращает ключ вместе с его открытым значением - больше оно нигде не отдается
+func (a *Service) IssueAPIKey(ctx context.Context, name string, scopes []string, ownerID string) (_ models.APIKey, _ string, err error) {
+	ctx, span := startSpan(ctx, "service.IssueAPIKey", "")
+	defer func() { endSpan(span, err) }()
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		a.log(ctx).ErrorContext(ctx, "new uuid creating failed", logging.Error(err))
+		return models.APIKey{}, "", err
+	}
+	secret, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		a.log(ctx).ErrorContext(ctx, "api key generation failed", logging.Error(err))
+		return models.APIKey{}, "", err
+	}
+
+	key := models.APIKey{
+		ID:        id.String(),
+		Name:      name,
+		Scopes:    scopes,
+		OwnerID:   ownerID,
+		CreatedAt: time.Now().UTC(),
+	}
+	// ключ, выпущенный в запросе, принадлежит арендатору этого запроса; из CLI выпускаются ключи платформы
+	if t, ok := tenant.FromContext(ctx); ok {
+		key.TenantID = t.ID
+	}
+	if err := a.repo.CreateAPIKey(ctx, key, hash); err != nil {
+		return models.APIKey{}, "", err
+	}
+	return key, secret, nil
+}
+
+// RevokeAPIKey отзывает ключ арендатора запроса. Администратор платформы (admin без арендатора)
+// отзывает любой ключ, в том числе ключи платформы, которые иначе не отозвать
+func (a *Service) RevokeAPIKey(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "service.RevokeAPIKey", "")
+	defer func() { endSpan(span, err) }()
+
+	if p, ok := auth.FromContext(ctx); ok && p.TenantID == "" && p.Has(auth.ScopeAdmin) {
+		return a.repo.RevokeAnyAPIKey(ctx, id)
+	}
+	return a.repo.RevokeAPIKey(ctx, id)
+}
+
+// CheckWalletAccess проверяет владение кошельком до того, как обработчик потратит его корзину
+// лимита: иначе чужой клиент мог бы исчерпать лимит кошелька, не имея к нему доступа
+func (a *Service) CheckWalletAccess(ctx context.Context, walletID string) error {
+	return a.checkAccess(ctx, walletID)
+}
+
+// checkAccess пускает к кошельку только его владельца. Без аутентификации и для ключей
+// без владельца проверка не делается, чтобы не тратить запрос к БД
+func (a *Service) checkAccess(ctx context.Context, walletID string) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok || p.OwnerID == "" || p.Has(auth.ScopeAdmin) {
+		return nil
+	}
+	owner, err := a.repo.GetWalletOwner(ctx, walletID)
+	if err != nil {
+		return err
+	}
+	if !p.CanAccess(owner) {
+		return myerrors.ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/metrics"
+	"github.com/glekoz/test_itk/internal/shared/consistency"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/tenant"
+)
+
+// loadTimeout ограничивает общее чтение баланса: оно не зависит от отмены запросов, которые его ждут
+const loadTimeout = 5 * time.Second
+
+// loadBalance читает баланс из базы один раз на кошелек, сколько бы запросов ни промахнулось мимо кэша одновременно
+func (a *Service) loadBalance(ctx context.Context, walletID string) (int, error) {
+	leader := false
+	ch := a.loads.DoChan(loadKey(ctx, walletID), func() (any, error) {
+		leader = true
+		return a.fetchBalance(ctx, walletID)
+	})
+	select {
+	case res := <-ch:
+		// leader записывается до отправки результата в канал, поэтому чтение после получения безопасно
+		if !leader {
+			metrics.CacheCoalescedLoads.Inc()
+		}
+		if res.Err != nil {
+			return 0, res.Err
+		}
+		return res.Val.(int), nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// refreshBalance перечитывает устаревший баланс в фоне; запрос, который его заметил, получает устаревшее значение сразу
+func (a *Service) refreshBalance(ctx context.Context, walletID string) {
+	ch := a.loads.DoChan(loadKey(ctx, walletID), func() (any, error) {
+		return a.fetchBalance(ctx, walletID)
+	})
+	a.refreshes.Add(1)
+	go func() {
+		defer a.refreshes.Done()
+		res := <-ch
+		if res.Err != nil && !errors.Is(res.Err, myerrors.ErrNotFound) {
+			metrics.CacheRefreshes.WithLabelValues(metrics.OutcomeError).Inc()
+			a.log(ctx).ErrorContext(ctx, "refreshing stale balance failed", logging.WalletID(walletID), logging.Error(res.Err))
+			return
+		}
+		metrics.CacheRefreshes.WithLabelValues(metrics.OutcomeSuccess).Inc()
+	}()
+}
+
+// WaitRefreshes дожидается фоновых обновлений балансов, чтобы при остановке они не читали из уже закрытого пула.
+// Новые обновления запускают только запросы, поэтому вызывается после остановки HTTP
+func (a *Service) WaitRefreshes(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		a.refreshes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fetchBalance читает баланс и кладет результат в кэш, включая отсутствие кошелька.
+// Результат нужен всем, кто его ждет, поэтому отмена запроса, начавшего чтение, его не прерывает.
+// Поколение берется до чтения: если за время чтения кошелек изменился, кэш не получит старое значение.
+// Если кошелек вытеснен по уведомлению, чтение идет только с реплики, дошедшей до изменения, иначе с основного сервера:
+// старый баланс с отстающей реплики пролежал бы в кэше весь ttl
+func (a *Service) fetchBalance(ctx context.Context, walletID string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+	defer cancel()
+
+	gen := a.cache.Generation(tenant.ID(ctx), walletID)
+	if lsn := a.cache.FillLSN(tenant.ID(ctx), walletID); lsn > consistency.FromContext(ctx).Required() {
+		ctx = consistency.WithToken(ctx, consistency.NewToken(lsn))
+	}
+	balance, err := a.repo.GetBalance(ctx, walletID)
+	if errors.Is(err, myerrors.ErrNotFound) {
+		if err := a.cache.AddNotFound(tenant.ID(ctx), walletID, gen); err != nil {
+			a.log(ctx).ErrorContext(ctx, "adding to cache failed", logging.WalletID(walletID), logging.Error(err))
+		}
+		return 0, err
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := a.cache.Fill(tenant.ID(ctx), walletID, balance, gen); err != nil {
+		a.log(ctx).ErrorContext(ctx, "adding to cache failed", logging.WalletID(walletID), logging.Error(err))
+	}
+	return balance, nil
+}
+
+// loadKey: чтения разных арендаторов не объединяются, а чтение с токеном согласованности объединяется
+// только с чтениями, требующими той же позиции WAL, иначе оно могло бы получить результат с отстающей реплики
+func loadKey(ctx context.Context, walletID string) string {
+	key := tenant.ID(ctx) + "/" + walletID
+	if lsn := consistency.FromContext(ctx).Required(); lsn != 0 {
+		key += "@" + lsn.String()
+	}
+	return key
+}
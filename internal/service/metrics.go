@@ -0,0 +1,28 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/glekoz/test_itk/internal/metrics"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+)
+
+func observeOperation(operation myvars.OperationType, amount int, err error) {
+	outcome := metrics.OutcomeSuccess
+	switch {
+	case err == nil:
+	case errors.Is(err, myerrors.ErrNotFound):
+		outcome = metrics.OutcomeNotFound
+	case errors.Is(err, myerrors.ErrNegativeAmount):
+		outcome = metrics.OutcomeInsufficientFunds
+	case errors.Is(err, myerrors.ErrConflict):
+		outcome = metrics.OutcomeConflict
+	case errors.Is(err, myerrors.ErrTimeout):
+		outcome = metrics.OutcomeTimeout
+	default:
+		outcome = metrics.OutcomeError
+	}
+	metrics.WalletOperations.WithLabelValues(string(operation), outcome).Inc()
+	metrics.WalletOperationAmount.WithLabelValues(string(operation), outcome).Add(float64(amount))
+}
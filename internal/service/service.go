@@ -2,103 +2,186 @@ package service
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"math"
+	"sync"
 
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/cache"
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/shared/consistency"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
 	"github.com/glekoz/test_itk/internal/shared/myvars"
+	"github.com/glekoz/test_itk/internal/tenant"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/singleflight"
 )
 
 type RepoAPI interface {
-	CreateWallet(ctx context.Context, id string) error
+	CreateWallet(ctx context.Context, id, ownerID string) error
 	GetBalance(ctx context.Context, id string) (int, error)
+	GetWalletOwner(ctx context.Context, id string) (string, error)
 	Deposit(ctx context.Context, walletID, transactionID string, amount int, operationType myvars.OperationType) (int, error)
 	Withdraw(ctx context.Context, walletID, transactionID string, amount int, operationType myvars.OperationType) (int, error)
+	CreateWebhookSubscription(ctx context.Context, sub models.WebhookSubscription) error
+	ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]models.WebhookDelivery, error)
+	ReplayWebhookDelivery(ctx context.Context, deliveryID string) error
+	CreateAPIKey(ctx context.Context, key models.APIKey, hash string) error
+	RevokeAPIKey(ctx context.Context, id string) error
+	RevokeAnyAPIKey(ctx context.Context, id string) error
+	ListWallets(ctx context.Context, filter models.WalletFilter) ([]models.Wallet, error)
+	GetWallet(ctx context.Context, id string) (models.Wallet, error)
+	ListWalletTransactions(ctx context.Context, walletID string, limit int) ([]models.WalletTransaction, error)
+	AdjustBalance(ctx context.Context, walletID, transactionID string, amount int, reason string) (int, error)
+	SetWalletStatus(ctx context.Context, walletID string, status myvars.WalletStatus, reason string) error
 }
 
+// CacheAPI: ключи кэша включают арендатора, чтобы записи разных брендов не пересекались
 type CacheAPI interface {
-	Add(walletID string, balance int) error
-	Get(walletID string) (int, bool)
-	Delete(walletID string)
+	Add(tenantID, walletID string, balance int) error
+	Generation(tenantID, walletID string) uint64
+	FillLSN(tenantID, walletID string) consistency.LSN
+	Fill(tenantID, walletID string, balance int, gen uint64) error
+	AddNotFound(tenantID, walletID string, gen uint64) error
+	Lookup(tenantID, walletID string) (cache.Entry, bool)
+	Delete(tenantID, walletID string)
 }
 
 type Service struct {
-	repo     RepoAPI
-	cache    CacheAPI
-	infoLog  *log.Logger
-	errorLog *log.Logger
+	repo      RepoAPI
+	cache     CacheAPI
+	loads     singleflight.Group // чтения балансов при промахе кэша, по одному на кошелек
+	refreshes sync.WaitGroup     // фоновые обновления устаревших балансов, их дожидается остановка
+	logger    *slog.Logger
 }
 
-func New(repo RepoAPI, cache CacheAPI, infoLog, errorLog *log.Logger) *Service {
+func New(repo RepoAPI, cache CacheAPI, logger *slog.Logger) *Service {
 	return &Service{
-		repo:     repo,
-		cache:    cache,
-		infoLog:  infoLog,
-		errorLog: errorLog,
+		repo:   repo,
+		cache:  cache,
+		logger: logger,
 	}
 }
 
-func (a *Service) CreateWallet(ctx context.Context) (string, error) {
+// log возвращает логгер запроса, если он есть в контексте
+func (a *Service) log(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, a.logger)
+}
+
+func (a *Service) CreateWallet(ctx context.Context) (_ string, err error) {
+	ctx, span := startSpan(ctx, "service.CreateWallet", "")
+	defer func() { endSpan(span, err) }()
+
 	id, err := uuid.NewV7()
 	if err != nil {
-		a.errorLog.Printf("new uuid creating failed")
+		a.log(ctx).ErrorContext(ctx, "new uuid creating failed", logging.Error(err))
 		return "", err
 	}
 	idstr := id.String()
-	err = a.repo.CreateWallet(ctx, idstr)
+	// кошелек, созданный ключом с владельцем, сразу принадлежит этому владельцу
+	var ownerID string
+	if p, ok := auth.FromContext(ctx); ok {
+		ownerID = p.OwnerID
+	}
+	err = a.repo.CreateWallet(ctx, idstr, ownerID)
 	if err != nil {
 		return "", err
 	}
 	return idstr, nil
 }
 
-func (a *Service) GetBalance(ctx context.Context, walletID string) (int, error) {
-	balance, ok := a.cache.Get(walletID)
-	if ok {
-		return balance, nil
-	}
-	balance, err := a.repo.GetBalance(ctx, walletID)
-	if err != nil {
+func (a *Service) GetBalance(ctx context.Context, walletID string) (_ int, err error) {
+	ctx, span := startSpan(ctx, "service.GetBalance", walletID)
+	defer func() { endSpan(span, err) }()
+
+	if err = a.checkAccess(ctx, walletID); err != nil {
 		return 0, err
 	}
-	if err := a.cache.Add(walletID, balance); err != nil {
-		a.errorLog.Printf("adding to cache failed")
+	// кэш не знает, с какой позиции WAL прочитан баланс, поэтому чтение с токеном согласованности идет в базу
+	if consistency.FromContext(ctx).Required() != 0 {
+		return a.loadBalance(ctx, walletID)
 	}
-	return balance, nil
+	entry, ok := a.cacheGet(ctx, walletID)
+	if !ok {
+		return a.loadBalance(ctx, walletID)
+	}
+	if entry.NotFound {
+		return 0, myerrors.ErrNotFound
+	}
+	if entry.Stale {
+		a.refreshBalance(ctx, walletID)
+	}
+	return entry.Balance, nil
 }
 
-func (a *Service) Deposit(ctx context.Context, walletID string, amount int) error {
+func (a *Service) Deposit(ctx context.Context, walletID string, amount int) (err error) {
+	ctx, span := startSpan(ctx, "service.Deposit", walletID, attribute.Int("wallet.amount", amount))
+	defer func() { endSpan(span, err) }()
+
+	if err = checkTransferLimit(ctx, amount); err != nil {
+		return err
+	}
+	if err = a.checkAccess(ctx, walletID); err != nil {
+		return err
+	}
 	transactionID, err := uuid.NewV7()
 	if err != nil {
-		a.errorLog.Printf("new uuid creating failed")
+		a.log(ctx).ErrorContext(ctx, "new uuid creating failed", logging.Error(err))
 		return err
 	}
 	balance, err := a.repo.Deposit(ctx, walletID, transactionID.String(), amount, myvars.OperationTypeDeposit)
+	observeOperation(myvars.OperationTypeDeposit, amount, err)
 	if err != nil {
 		return err
 	}
-	if err := a.cache.Add(walletID, balance); err != nil {
-		a.errorLog.Printf("adding to cache failed")
-		a.cache.Delete(walletID)
+	if err := a.cache.Add(tenant.ID(ctx), walletID, balance); err != nil {
+		a.log(ctx).ErrorContext(ctx, "adding to cache failed", logging.WalletID(walletID), logging.Error(err))
+		a.cache.Delete(tenant.ID(ctx), walletID)
 	}
 
 	return nil
 }
 
-func (a *Service) Withdraw(ctx context.Context, walletID string, amount int) error {
+func (a *Service) Withdraw(ctx context.Context, walletID string, amount int) (err error) {
+	ctx, span := startSpan(ctx, "service.Withdraw", walletID, attribute.Int("wallet.amount", amount))
+	defer func() { endSpan(span, err) }()
+
+	if err = checkTransferLimit(ctx, amount); err != nil {
+		return err
+	}
+	if err = a.checkAccess(ctx, walletID); err != nil {
+		return err
+	}
 	transactionID, err := uuid.NewV7()
 	if err != nil {
-		a.errorLog.Printf("new uuid creating failed")
+		a.log(ctx).ErrorContext(ctx, "new uuid creating failed", logging.Error(err))
 		return err
 	}
 
 	balance, err := a.repo.Withdraw(ctx, walletID, transactionID.String(), amount, myvars.OperationTypeWithdraw)
+	observeOperation(myvars.OperationTypeWithdraw, amount, err)
 	if err != nil {
 		return err
 	}
 
-	if err := a.cache.Add(walletID, balance); err != nil {
-		a.cache.Delete(walletID)
+	if err := a.cache.Add(tenant.ID(ctx), walletID, balance); err != nil {
+		a.cache.Delete(tenant.ID(ctx), walletID)
 	}
 
 	return nil
 }
+
+// checkTransferLimit применяет ограничение суммы одной операции из настроек арендатора.
+// Сумма передается в БД как int32, поэтому большие суммы отклоняются и без настроек
+func checkTransferLimit(ctx context.Context, amount int) error {
+	if amount < math.MinInt32 || amount > math.MaxInt32 {
+		return myerrors.ErrAmountRange
+	}
+	t, ok := tenant.FromContext(ctx)
+	if ok && t.MaxTransferAmount > 0 && amount > t.MaxTransferAmount {
+		return myerrors.ErrLimitExceeded
+	}
+	return nil
+}
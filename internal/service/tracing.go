@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+
+	"github.com/glekoz/test_itk/internal/cache"
+	"github.com/glekoz/test_itk/internal/tenant"
+	"github.com/glekoz/test_itk/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func startSpan(ctx context.Context, name, walletID string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if walletID != "" {
+		attrs = append(attrs, attribute.String("wallet.id", walletID))
+	}
+	return tracing.Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		tracing.RecordError(span, err)
+	}
+	span.End()
+}
+
+// cacheGet оборачивает обращение к кэшу в спан: у кэша нет контекста, поэтому спан создается здесь
+func (a *Service) cacheGet(ctx context.Context, walletID string) (cache.Entry, bool) {
+	_, span := tracing.Tracer().Start(ctx, "cache.Get", trace.WithAttributes(attribute.String("wallet.id", walletID)))
+	defer span.End()
+
+	entry, ok := a.cache.Lookup(tenant.ID(ctx), walletID)
+	span.SetAttributes(attribute.Bool("cache.hit", ok))
+	if ok {
+		span.SetAttributes(attribute.Bool("cache.stale", entry.Stale), attribute.Bool("cache.not_found", entry.NotFound))
+	}
+	return entry, ok
+}
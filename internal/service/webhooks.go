@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const webhookSecretSize = 32
+
+// CreateWebhookSubscription возвращает подписку вместе с секретом - больше секрет нигде не отдается
+func (a *Service) CreateWebhookSubscription(ctx context.Context, walletID, url string) (_ models.WebhookSubscription, err error) {
+	ctx, span := startSpan(ctx, "service.CreateWebhookSubscription", walletID)
+	defer func() { endSpan(span, err) }()
+
+	if walletID != "" {
+		if err = a.checkAccess(ctx, walletID); err != nil {
+			return models.WebhookSubscription{}, err
+		}
+	}
+	id, err := uuid.NewV7()
+	if err != nil {
+		a.log(ctx).ErrorContext(ctx, "new uuid creating failed", logging.Error(err))
+		return models.WebhookSubscription{}, err
+	}
+	secret := make([]byte, webhookSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		a.log(ctx).ErrorContext(ctx, "webhook secret generation failed", logging.Error(err))
+		return models.WebhookSubscription{}, err
+	}
+
+	sub := models.WebhookSubscription{
+		ID:        id.String(),
+		WalletID:  walletID,
+		URL:       url,
+		Secret:    hex.EncodeToString(secret),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := a.repo.CreateWebhookSubscription(ctx, sub); err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	return sub, nil
+}
+
+func (a *Service) ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) (_ []models.WebhookDelivery, err error) {
+	ctx, span := startSpan(ctx, "service.ListWebhookDeliveries", "", attribute.String("webhook.subscription_id", subscriptionID))
+	defer func() { endSpan(span, err) }()
+
+	return a.repo.ListWebhookDeliveries(ctx, subscriptionID, limit)
+}
+
+// ReplayWebhookDelivery возвращает доставку (в том числе из dead letter) в очередь с обнуленным счетчиком попыток
+func (a *Service) ReplayWebhookDelivery(ctx context.Context, deliveryID string) (err error) {
+	ctx, span := startSpan(ctx, "service.ReplayWebhookDelivery", "", attribute.String("webhook.delivery_id", deliveryID))
+	defer func() { endSpan(span, err) }()
+
+	return a.repo.ReplayWebhookDelivery(ctx, deliveryID)
+}
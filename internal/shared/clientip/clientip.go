@@ -0,0 +1,27 @@
+package clientip
+
+import (
+	"context"
+	"net"
+)
+
+type key struct{}
+
+func WithIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, key{}, ip)
+}
+
+// FromContext возвращает пустую строку для операций, запущенных не из HTTP-запроса
+func FromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(key{}).(string)
+	return ip
+}
+
+// FromRemoteAddr отбрасывает порт из RemoteAddr; заголовкам прокси не доверяем
+func FromRemoteAddr(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
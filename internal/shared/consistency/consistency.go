@@ -0,0 +1,88 @@
+package consistency
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Header - токен согласованности: ответ на запись возвращает в нем позицию WAL после коммита,
+// а чтение с этим заголовком не получит данные старше нее, даже если идет с реплики
+const Header = "X-Consistency-Token"
+
+// LSN - позиция в журнале WAL Postgres, в тексте записывается как "16/B374D848"
+type LSN uint64
+
+func ParseLSN(s string) (LSN, error) {
+	hi, lo, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid lsn %q", s)
+	}
+	h, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid lsn %q", s)
+	}
+	l, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid lsn %q", s)
+	}
+	return LSN(h<<32 | l), nil
+}
+
+func (l LSN) String() string {
+	return fmt.Sprintf("%X/%X", uint64(l)>>32, uint32(l))
+}
+
+// Token - требования запроса к свежести чтения: позиция из заголовка клиента и позиция
+// последней записи самого запроса. Методы можно вызывать у nil - у операций не из HTTP-запроса токена нет
+type Token struct {
+	min     LSN
+	written atomic.Uint64
+}
+
+func NewToken(min LSN) *Token {
+	return &Token{min: min}
+}
+
+// Wrote запоминает позицию WAL после коммита записи
+func (t *Token) Wrote(lsn LSN) {
+	if t == nil {
+		return
+	}
+	for {
+		old := t.written.Load()
+		if uint64(lsn) <= old || t.written.CompareAndSwap(old, uint64(lsn)) {
+			return
+		}
+	}
+}
+
+// Written - позиция последней записи запроса, 0 - запрос ничего не записал
+func (t *Token) Written() LSN {
+	if t == nil {
+		return 0
+	}
+	return LSN(t.written.Load())
+}
+
+// Required - позиция, которую должна воспроизвести реплика, чтобы чтение увидело записи клиента
+func (t *Token) Required() LSN {
+	if t == nil {
+		return 0
+	}
+	return max(t.min, t.Written())
+}
+
+type key struct{}
+
+func WithToken(ctx context.Context, t *Token) context.Context {
+	return context.WithValue(ctx, key{}, t)
+}
+
+// FromContext возвращает nil для операций, запущенных не из HTTP-запроса
+func FromContext(ctx context.Context) *Token {
+	t, _ := ctx.Value(key{}).(*Token)
+	return t
+}
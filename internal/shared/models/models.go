@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+)
+
+// WalletEvent - тело события, которое записывается в outbox и отправляется подписчикам
+type WalletEvent struct {
+	Type          myvars.EventType `json:"type"`
+	WalletID      string           `json:"wallet_id"`
+	TransactionID string           `json:"transaction_id"`
+	Amount        int              `json:"amount"`
+	Balance       int              `json:"balance"`
+	OccurredAt    time.Time        `json:"occurred_at"`
+}
+
+// Wallet - кошелек со служебными полями, как его видят операторы
+type Wallet struct {
+	ID        string
+	Balance   int
+	Status    myvars.WalletStatus
+	OwnerID   string
+	Version   int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// WalletDetail - кошелек вместе с последними операциями
+type WalletDetail struct {
+	Wallet
+	Transactions []WalletTransaction
+}
+
+type WalletTransaction struct {
+	ID            string
+	Amount        int
+	OperationType myvars.OperationType
+	RequestID     string
+	CreatedAt     time.Time
+}
+
+// WalletFilter - условия поиска кошельков; nil и пустой статус означают, что условие не применяется
+type WalletFilter struct {
+	MinBalance  *int
+	MaxBalance  *int
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time // не включительно
+	Status      myvars.WalletStatus
+	Limit       int
+	Offset      int
+
+	// After - курсор: только кошельки, идущие в выдаче после указанного. Выгрузка листает по нему,
+	// а не по Offset, чтобы кошельки, созданные во время выгрузки, не сдвигали страницы
+	After *WalletCursor
+}
+
+// WalletCursor - положение кошелька в выдаче, отсортированной по created_at DESC, id
+type WalletCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// OutboxEvent - запись outbox в том виде, в котором ее получают публикаторы
+type OutboxEvent struct {
+	ID        int64
+	WalletID  string
+	EventType string
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+// WebhookSubscription - подписка на события кошелька; пустой WalletID означает подписку на все кошельки
+type WebhookSubscription struct {
+	ID        string
+	WalletID  string
+	URL       string
+	Secret    string
+	CreatedAt time.Time
+}
+
+type WebhookDelivery struct {
+	ID             string
+	SubscriptionID string
+	EventID        int64
+	EventType      string
+	Payload        []byte
+	Status         myvars.DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastError      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// WebhookAttempt - доставка, захваченная воркером, вместе с адресом и секретом подписки
+type WebhookAttempt struct {
+	DeliveryID string
+	EventID    int64
+	EventType  string
+	Payload    []byte
+	Attempts   int
+	URL        string
+	Secret     string
+}
+
+// APIKey - выпущенный ключ без самого секрета; пустой OwnerID означает доступ ко всем кошелькам
+type APIKey struct {
+	ID        string
+	Name      string
+	Scopes    []string
+	OwnerID   string
+	TenantID  string // пустой - ключ платформы, арендатор выбирается заголовком
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
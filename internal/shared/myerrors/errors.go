@@ -3,9 +3,17 @@ package myerrors
 import "errors"
 
 var (
-	ErrNotFound       = errors.New("no result found")
-	ErrInternal       = errors.New("something goes wrong")
-	ErrAlreadyExists  = errors.New("already exists")
-	ErrNegativeAmount = errors.New("amount can't be negative")
-	ErrInvalidInput   = errors.New("only positive amount allowed")
+	ErrNotFound           = errors.New("no result found")
+	ErrInternal           = errors.New("something goes wrong")
+	ErrAlreadyExists      = errors.New("already exists")
+	ErrNegativeAmount     = errors.New("amount can't be negative")
+	ErrInvalidInput       = errors.New("only positive amount allowed")
+	ErrConflict           = errors.New("wallet was modified concurrently")
+	ErrForbidden          = errors.New("access to the wallet is forbidden")
+	ErrLimitExceeded      = errors.New("amount exceeds the tenant transfer limit")
+	ErrWalletFrozen       = errors.New("wallet is frozen")
+	ErrReasonRequired     = errors.New("reason is required")
+	ErrAmountRange        = errors.New("amount is out of range") // баланс хранится в INTEGER
+	ErrDeliveryInProgress = errors.New("delivery is being sent, try again later")
+	ErrTimeout            = errors.New("wallet is busy, try again later") // истек lock_timeout или statement_timeout
 )
@@ -5,4 +5,29 @@ type OperationType string
 const (
 	OperationTypeDeposit  OperationType = "deposit"
 	OperationTypeWithdraw OperationType = "withdraw"
+	// корректировка оператора; сумма в транзакции хранится со знаком
+	OperationTypeAdjustment OperationType = "adjustment"
+)
+
+type EventType string
+
+const (
+	EventTypeDeposited EventType = "wallet.deposited"
+	EventTypeWithdrawn EventType = "wallet.withdrawn"
+	EventTypeAdjusted  EventType = "wallet.adjusted"
+)
+
+type WalletStatus string
+
+const (
+	WalletStatusActive WalletStatus = "active"
+	WalletStatusFrozen WalletStatus = "frozen" // переводы клиентов отклоняются
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "pending"
+	DeliveryStatusDelivered DeliveryStatus = "delivered"
+	DeliveryStatusDead      DeliveryStatus = "dead"
 )
@@ -0,0 +1,17 @@
+package requestid
+
+import "context"
+
+const Header = "X-Request-ID"
+
+type key struct{}
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+// FromContext возвращает пустую строку для операций, запущенных не из HTTP-запроса
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(key{}).(string)
+	return id
+}
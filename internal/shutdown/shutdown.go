@@ -0,0 +1,105 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/logging"
+)
+
+type Readiness interface {
+	SetReady(ready bool)
+}
+
+// Step - этап остановки; этапы выполняются в порядке регистрации после остановки HTTP-сервера
+type Step struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+type Manager struct {
+	srv            *http.Server
+	readiness      Readiness
+	readinessDelay time.Duration
+	drainTimeout   time.Duration
+	steps          []Step
+	logger         *slog.Logger
+}
+
+// New: readinessDelay - пауза между снятием готовности и остановкой HTTP, чтобы балансировщик
+// успел убрать экземпляр из ротации; drainTimeout - лимит на дожидание запросов и отдельно общий лимит
+// на все этапы остановки. Вся остановка укладывается в readinessDelay + 2 * drainTimeout
+func New(srv *http.Server, readiness Readiness, readinessDelay, drainTimeout time.Duration, logger *slog.Logger) *Manager {
+	return &Manager{
+		srv:            srv,
+		readiness:      readiness,
+		readinessDelay: readinessDelay,
+		drainTimeout:   drainTimeout,
+		logger:         logger,
+	}
+}
+
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.steps = append(m.steps, Step{Name: name, Fn: fn})
+}
+
+// Serve принимает запросы на ln (или на srv.Addr, если ln == nil) до отмены ctx,
+// после чего останавливает сервер, дождавшись уже принятых запросов, и выполняет этапы остановки
+func (m *Manager) Serve(ctx context.Context, ln net.Listener) error {
+	if ln == nil {
+		var err error
+		ln, err = net.Listen("tcp", m.srv.Addr)
+		if err != nil {
+			return err
+		}
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- m.srv.Serve(ln)
+	}()
+	m.readiness.SetReady(true)
+
+	select {
+	case err := <-serveErr:
+		m.readiness.SetReady(false)
+		return err
+	case <-ctx.Done():
+	}
+
+	m.logger.Info("shutting down")
+	m.readiness.SetReady(false)
+	if m.readinessDelay > 0 {
+		time.Sleep(m.readinessDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), m.drainTimeout)
+	defer cancel()
+
+	var errs []error
+	if err := m.srv.Shutdown(shutdownCtx); err != nil {
+		m.logger.Error("http server shutdown failed", logging.Error(err))
+		errs = append(errs, err)
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		errs = append(errs, err)
+	}
+
+	// этапы делят свой срок, отдельный от срока дожидания запросов: если запросы не успели завершиться,
+	// этапы не получат уже истекший контекст, а общее время остановки остается ограниченным
+	stepsCtx, cancelSteps := context.WithTimeout(context.Background(), m.drainTimeout)
+	defer cancelSteps()
+	for _, step := range m.steps {
+		if err := step.Fn(stepsCtx); err != nil {
+			m.logger.Error("shutdown step failed", slog.String("step", step.Name), logging.Error(err))
+			errs = append(errs, err)
+			continue
+		}
+		m.logger.Info("shutdown step finished", slog.String("step", step.Name))
+	}
+	return errors.Join(errs...)
+}
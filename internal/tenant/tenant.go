@@ -0,0 +1,94 @@
+// Package tenant описывает арендаторов (бренды) одной инсталляции и их настройки
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/glekoz/test_itk/internal/ratelimit"
+)
+
+const (
+	Header = "X-Tenant-ID"
+
+	// DefaultID получают запросы без арендатора и все данные, созданные до разделения
+	DefaultID = "default"
+)
+
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// Config - настройки арендатора; пустые лимиты означают общие значения из конфигурации
+type Config struct {
+	ID                string           `json:"id"`
+	Currency          string           `json:"currency"`
+	MaxTransferAmount int              `json:"max_transfer_amount"` // 0 - без ограничения
+	ClientRateLimit   *ratelimit.Limit `json:"client_rate_limit"`
+	WalletRateLimit   *ratelimit.Limit `json:"wallet_rate_limit"`
+}
+
+type Registry struct {
+	tenants map[string]Config
+}
+
+// NewRegistry всегда содержит арендатора по умолчанию, даже если он не передан явно
+func NewRegistry(defaultCurrency string, tenants ...Config) (*Registry, error) {
+	r := &Registry{tenants: map[string]Config{
+		DefaultID: {ID: DefaultID, Currency: defaultCurrency},
+	}}
+	for i, t := range tenants {
+		if !validID.MatchString(t.ID) {
+			return nil, fmt.Errorf("tenant %d: invalid id %q", i, t.ID)
+		}
+		if t.Currency == "" {
+			t.Currency = defaultCurrency
+		}
+		if t.MaxTransferAmount < 0 {
+			return nil, fmt.Errorf("tenant %s: max transfer amount can't be negative", t.ID)
+		}
+		r.tenants[t.ID] = t
+	}
+	return r, nil
+}
+
+// Load читает арендаторов из JSON-файла вида {"tenants": [...]}
+func Load(path, defaultCurrency string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Tenants []Config `json:"tenants"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decoding tenants: %w", err)
+	}
+	return NewRegistry(defaultCurrency, file.Tenants...)
+}
+
+func (r *Registry) Get(id string) (Config, bool) {
+	t, ok := r.tenants[id]
+	return t, ok
+}
+
+type configKey struct{}
+
+func WithConfig(ctx context.Context, t Config) context.Context {
+	return context.WithValue(ctx, configKey{}, t)
+}
+
+// FromContext возвращает false вне HTTP-запроса (фоновые задачи, команды CLI)
+func FromContext(ctx context.Context) (Config, bool) {
+	t, ok := ctx.Value(configKey{}).(Config)
+	return t, ok
+}
+
+// ID возвращает арендатора запроса или DefaultID
+func ID(ctx context.Context) string {
+	if t, ok := FromContext(ctx); ok {
+		return t.ID
+	}
+	return DefaultID
+}
@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryTracer создает спан на каждый запрос pgx и на ожидание соединения из пула:
+// так в трейсе видно, где тратится время - в очереди за соединением или на блокировке строки
+type QueryTracer struct{}
+
+var (
+	_ pgx.QueryTracer       = QueryTracer{}
+	_ pgxpool.AcquireTracer = QueryTracer{}
+)
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = Tracer().Start(ctx, QueryName(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.query.text", data.SQL),
+		),
+	)
+	return ctx
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		RecordError(span, data.Err)
+	} else {
+		span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	span.End()
+}
+
+func (QueryTracer) TraceAcquireStart(ctx context.Context, _ *pgxpool.Pool, _ pgxpool.TraceAcquireStartData) context.Context {
+	ctx, _ = Tracer().Start(ctx, "db.acquire", trace.WithSpanKind(trace.SpanKindInternal))
+	return ctx
+}
+
+func (QueryTracer) TraceAcquireEnd(ctx context.Context, _ *pgxpool.Pool, data pgxpool.TraceAcquireEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		RecordError(span, data.Err)
+	}
+	span.End()
+}
+
+// QueryName берет имя из заголовка sqlc ("-- name: GetBalance :one"),
+// для остальных запросов - первое слово, например db.begin
+func QueryName(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if rest, ok := strings.CutPrefix(sql, "-- name: "); ok {
+		if name, _, ok := strings.Cut(rest, " "); ok {
+			return "db." + name
+		}
+	}
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "db.query"
+	}
+	return "db." + strings.ToLower(fields[0])
+}
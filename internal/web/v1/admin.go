@@ -0,0 +1,244 @@
+package web
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/glekoz/test_itk/api/v1"
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+)
+
+const (
+	defaultWalletsLimit = 50
+	maxWalletsLimit     = 500
+)
+
+func (s *Server) AdminListWallets(w http.ResponseWriter, r *http.Request) {
+	filter, errs := parseWalletFilter(r)
+	filter.Limit = defaultWalletsLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxWalletsLimit {
+			errs += "limit must be between 1 and 500; "
+		}
+		filter.Limit = n
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			errs += "offset can't be negative; "
+		}
+		filter.Offset = n
+	}
+	if len(errs) > 0 {
+		s.sendError(w, r, http.StatusBadRequest, errs)
+		return
+	}
+
+	wallets, err := s.service.ListWallets(r.Context(), filter)
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	res := api.AdminWalletList{Wallets: make([]api.AdminWallet, 0, len(wallets))}
+	for _, wallet := range wallets {
+		res.Wallets = append(res.Wallets, adminWallet(wallet))
+	}
+	WriteJSON(w, http.StatusOK, res)
+}
+
+// AdminExportWallets отдает CSV потоком; ошибка посреди выгрузки уже не может сменить статус ответа,
+// поэтому только попадает в лог, а файл получается обрезанным
+func (s *Server) AdminExportWallets(w http.ResponseWriter, r *http.Request) {
+	filter, errs := parseWalletFilter(r)
+	if len(errs) > 0 {
+		s.sendError(w, r, http.StatusBadRequest, errs)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="wallets.csv"`)
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"id", "balance", "status", "owner_id", "version", "created_at", "updated_at"})
+	err := s.service.ExportWallets(r.Context(), filter, func(wallet models.Wallet) error {
+		return out.Write([]string{
+			wallet.ID,
+			strconv.Itoa(wallet.Balance),
+			string(wallet.Status),
+			wallet.OwnerID,
+			strconv.FormatInt(wallet.Version, 10),
+			wallet.CreatedAt.UTC().Format(time.RFC3339),
+			wallet.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	})
+	out.Flush()
+	if err == nil {
+		err = out.Error()
+	}
+	if err != nil {
+		logging.AddAttrs(r.Context(), slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+func (s *Server) AdminGetWallet(w http.ResponseWriter, r *http.Request) {
+	walletID := r.PathValue("wallet_id")
+	logging.AddAttrs(r.Context(), logging.WalletID(walletID))
+
+	detail, err := s.service.GetWallet(r.Context(), walletID)
+	if err != nil {
+		if errors.Is(err, myerrors.ErrNotFound) {
+			s.sendError(w, r, http.StatusNotFound, err.Error())
+			return
+		}
+		s.sendError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	res := api.AdminWalletDetail{
+		Wallet:       adminWallet(detail.Wallet),
+		Transactions: make([]api.WalletTransaction, 0, len(detail.Transactions)),
+	}
+	for _, t := range detail.Transactions {
+		tr := api.WalletTransaction{
+			Id:            t.ID,
+			Amount:        t.Amount,
+			OperationType: string(t.OperationType),
+			CreatedAt:     t.CreatedAt,
+		}
+		if t.RequestID != "" {
+			tr.RequestId = &t.RequestID
+		}
+		res.Transactions = append(res.Transactions, tr)
+	}
+	WriteJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) AdminAdjustBalance(w http.ResponseWriter, r *http.Request) {
+	walletID := r.PathValue("wallet_id")
+	var req api.BalanceAdjustment
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, r, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	logging.AddAttrs(r.Context(), logging.WalletID(walletID), logging.Operation(string(myvars.OperationTypeAdjustment)), logging.Amount(req.Amount))
+
+	balance, err := s.service.AdjustBalance(r.Context(), walletID, req.Amount, req.Reason)
+	if err != nil {
+		if errors.Is(err, myerrors.ErrInvalidInput) {
+			s.sendError(w, r, http.StatusBadRequest, "amount can't be zero")
+			return
+		} else if errors.Is(err, myerrors.ErrReasonRequired) || errors.Is(err, myerrors.ErrNegativeAmount) || errors.Is(err, myerrors.ErrAmountRange) {
+			s.sendError(w, r, http.StatusBadRequest, err.Error())
+			return
+		} else if errors.Is(err, myerrors.ErrNotFound) {
+			s.sendError(w, r, http.StatusNotFound, err.Error())
+			return
+		} else if errors.Is(err, myerrors.ErrConflict) {
+			s.sendError(w, r, http.StatusConflict, err.Error())
+			return
+		} else if errors.Is(err, myerrors.ErrTimeout) {
+			s.sendBusy(w, r, err)
+			return
+		}
+		s.sendError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	WriteJSON(w, http.StatusOK, api.Balance{
+		WalletId: walletID,
+		Balance:  balance,
+	})
+}
+
+func (s *Server) AdminSetWalletStatus(w http.ResponseWriter, r *http.Request) {
+	walletID := r.PathValue("wallet_id")
+	var req api.WalletStatusUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, r, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	logging.AddAttrs(r.Context(), logging.WalletID(walletID))
+
+	err := s.service.SetWalletStatus(r.Context(), walletID, myvars.WalletStatus(req.Status), req.Reason)
+	if err != nil {
+		if errors.Is(err, myerrors.ErrInvalidInput) {
+			s.sendError(w, r, http.StatusBadRequest, "status must be either 'active' or 'frozen'")
+			return
+		} else if errors.Is(err, myerrors.ErrReasonRequired) {
+			s.sendError(w, r, http.StatusBadRequest, err.Error())
+			return
+		} else if errors.Is(err, myerrors.ErrNotFound) {
+			s.sendError(w, r, http.StatusNotFound, err.Error())
+			return
+		} else if errors.Is(err, myerrors.ErrTimeout) {
+			s.sendBusy(w, r, err)
+			return
+		}
+		s.sendError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseWalletFilter собирает все ошибки параметров в одну строку, как это делает Transfer
+func parseWalletFilter(r *http.Request) (models.WalletFilter, string) {
+	var (
+		filter models.WalletFilter
+		errs   string
+	)
+	q := r.URL.Query()
+	for _, p := range []struct {
+		name string
+		dst  **int
+	}{{"min_balance", &filter.MinBalance}, {"max_balance", &filter.MaxBalance}} {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs += p.name + " must be an integer; "
+				continue
+			}
+			*p.dst = &n
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"created_from", &filter.CreatedFrom}, {"created_to", &filter.CreatedTo}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				errs += p.name + " must be an RFC 3339 timestamp; "
+				continue
+			}
+			*p.dst = &t
+		}
+	}
+	switch status := myvars.WalletStatus(q.Get("status")); status {
+	case "", myvars.WalletStatusActive, myvars.WalletStatusFrozen:
+		filter.Status = status
+	default:
+		errs += "status must be either 'active' or 'frozen'; "
+	}
+	return filter, errs
+}
+
+func adminWallet(w models.Wallet) api.AdminWallet {
+	res := api.AdminWallet{
+		Id:        w.ID,
+		Balance:   w.Balance,
+		Status:    api.WalletStatus(w.Status),
+		Version:   w.Version,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+	if w.OwnerID != "" {
+		res.OwnerId = &w.OwnerID
+	}
+	return res
+}
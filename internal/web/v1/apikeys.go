@@ -0,0 +1,80 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/glekoz/test_itk/api/v1"
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+)
+
+func (s *Server) IssueAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req api.APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, r, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	var errs string
+	if req.Name == "" {
+		errs += "name is required; "
+	}
+	if len(req.Scopes) == 0 {
+		errs += "at least one scope is required; "
+	}
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, sc := range req.Scopes {
+		if _, err := auth.ParseScope(sc); err != nil {
+			errs += err.Error() + "; "
+			continue
+		}
+		scopes = append(scopes, sc)
+	}
+	if len(errs) > 0 {
+		s.sendError(w, r, http.StatusBadRequest, errs)
+		return
+	}
+	var ownerID string
+	if req.OwnerId != nil {
+		ownerID = *req.OwnerId
+	}
+
+	key, secret, err := s.service.IssueAPIKey(r.Context(), req.Name, scopes, ownerID)
+	if err != nil {
+		s.sendError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	res := api.APIKey{
+		Id:        key.ID,
+		Name:      key.Name,
+		Key:       secret,
+		Scopes:    key.Scopes,
+		OwnerId:   req.OwnerId,
+		CreatedAt: key.CreatedAt,
+	}
+	if key.TenantID != "" {
+		res.TenantId = &key.TenantID
+	}
+	WriteJSON(w, http.StatusCreated, res)
+}
+
+func (s *Server) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyID := r.PathValue("key_id")
+	if keyID == "" {
+		s.sendError(w, r, http.StatusBadRequest, "key id can not be empty")
+		return
+	}
+
+	err := s.service.RevokeAPIKey(r.Context(), keyID)
+	if err != nil {
+		if errors.Is(err, myerrors.ErrNotFound) {
+			s.sendError(w, r, http.StatusNotFound, err.Error())
+			return
+		}
+		s.sendError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
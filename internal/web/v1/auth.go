@@ -0,0 +1,72 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/justinas/alice"
+)
+
+// authenticate определяет клиента запроса; при выключенной аутентификации пропускает всех
+func (a *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.auth == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		p, err := a.auth.Authenticate(r)
+		if err != nil {
+			if errors.Is(err, auth.ErrUnauthenticated) || errors.Is(err, auth.ErrInvalidCredentials) {
+				a.sendErrorCode(w, r, http.StatusUnauthorized, authErrorCode(err), err.Error())
+				return
+			}
+			a.sendError(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		logging.AddAttrs(r.Context(), slog.String("principal", p.ID))
+
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+	})
+}
+
+// authErrorCode различает причины отказа подписанного запроса: при расхождении часов клиенту
+// нужно синхронизировать время, при повторе - сгенерировать новый nonce
+func authErrorCode(err error) string {
+	switch {
+	case errors.Is(err, auth.ErrClockSkew):
+		return "clock_skew"
+	case errors.Is(err, auth.ErrReplay):
+		return "replayed_request"
+	case errors.Is(err, auth.ErrInvalidSignature):
+		return "invalid_signature"
+	}
+	return ""
+}
+
+// requireScope пропускает только клиентов с правом scope
+func (a *Server) requireScope(scope auth.Scope) alice.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !a.allowed(w, r, scope) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allowed отвечает 403 и возвращает false, если у клиента нет права scope.
+// Без клиента в контексте аутентификация выключена, и проверка не делается
+func (a *Server) allowed(w http.ResponseWriter, r *http.Request, scope auth.Scope) bool {
+	p, ok := auth.FromContext(r.Context())
+	if !ok || p.Has(scope) {
+		return true
+	}
+	a.sendError(w, r, http.StatusForbidden, fmt.Sprintf("%s scope is required", scope))
+	return false
+}
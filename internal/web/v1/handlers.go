@@ -5,11 +5,20 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/glekoz/test_itk/api/v1"
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/health"
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/ratelimit"
+	"github.com/glekoz/test_itk/internal/shared/models"
 	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+	"github.com/glekoz/test_itk/internal/tenant"
 )
 
 type ServiceAPI interface {
@@ -17,29 +26,80 @@ type ServiceAPI interface {
 	GetBalance(ctx context.Context, walletID string) (int, error)
 	Deposit(ctx context.Context, walletID string, amount int) error
 	Withdraw(ctx context.Context, walletID string, amount int) error
+	CheckWalletAccess(ctx context.Context, walletID string) error
+	CreateWebhookSubscription(ctx context.Context, walletID, url string) (models.WebhookSubscription, error)
+	ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]models.WebhookDelivery, error)
+	ReplayWebhookDelivery(ctx context.Context, deliveryID string) error
+	IssueAPIKey(ctx context.Context, name string, scopes []string, ownerID string) (models.APIKey, string, error)
+	RevokeAPIKey(ctx context.Context, id string) error
+	ListWallets(ctx context.Context, filter models.WalletFilter) ([]models.Wallet, error)
+	ExportWallets(ctx context.Context, filter models.WalletFilter, fn func(models.Wallet) error) error
+	GetWallet(ctx context.Context, walletID string) (models.WalletDetail, error)
+	AdjustBalance(ctx context.Context, walletID string, amount int, reason string) (int, error)
+	SetWalletStatus(ctx context.Context, walletID string, status myvars.WalletStatus, reason string) error
 }
 
+const healthCheckTimeout = 2 * time.Second
+
 type Server struct {
-	service  ServiceAPI
-	host     string
-	infoLog  *log.Logger
-	errorLog *log.Logger
+	service ServiceAPI
+	host    string
+	ready   atomic.Bool
+	health  *health.Registry
+	auth    auth.Authenticator // nil - аутентификация выключена
+	limiter ratelimit.Store    // nil - частота запросов не ограничивается
+	limits  atomic.Pointer[RateLimits]
+	tenants atomic.Pointer[tenant.Registry] // nil - единственный арендатор по умолчанию
+	logger  *slog.Logger
 }
 
-func New(service ServiceAPI, h string, infoLog, errorLog *log.Logger) *Server {
+func New(service ServiceAPI, h string, logger *slog.Logger) *Server {
 	return &Server{
-		service:  service,
-		host:     h,
-		infoLog:  infoLog,
-		errorLog: errorLog,
+		service: service,
+		host:    h,
+		health:  health.NewRegistry(healthCheckTimeout),
+		logger:  logger,
 	}
 }
 
+// SetReady переключает ответ /readyz; при остановке готовность снимается до закрытия соединений
+func (s *Server) SetReady(ready bool) {
+	s.ready.Store(ready)
+}
+
+// SetAuthenticator включает аутентификацию для API; health-эндпоинты и /metrics остаются открытыми
+func (s *Server) SetAuthenticator(a auth.Authenticator) {
+	s.auth = a
+}
+
+// RegisterCheck добавляет проверку зависимости в /readyz
+func (s *Server) RegisterCheck(name string, critical bool, fn health.CheckFunc) {
+	s.health.Register(name, critical, fn)
+}
+
+// Healthz отвечает, пока процесс жив и обрабатывает запросы; зависимости не проверяются
+func (s *Server) Healthz(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, health.Report{Status: health.StatusOK})
+}
+
+func (s *Server) Ready(w http.ResponseWriter, r *http.Request) {
+	if !s.ready.Load() {
+		WriteJSON(w, http.StatusServiceUnavailable, health.Report{Status: health.StatusShuttingDown})
+		return
+	}
+	report := s.health.Run(r.Context())
+	if report.Status == health.StatusFailed {
+		logging.AddAttrs(r.Context(), slog.String(logging.KeyError, "readiness check failed"))
+		WriteJSON(w, http.StatusServiceUnavailable, report)
+		return
+	}
+	WriteJSON(w, http.StatusOK, report)
+}
+
 func (s *Server) Transfer(w http.ResponseWriter, r *http.Request) {
 	var req api.Transfer
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.infoLog.Printf("%s - %s %s %s - ended with error (400, %s)", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI(), "invalid JSON body")
-		SendError(w, http.StatusBadRequest, "invalid JSON body")
+		s.sendError(w, r, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
 
@@ -54,8 +114,33 @@ func (s *Server) Transfer(w http.ResponseWriter, r *http.Request) {
 		errs += "operation must be either 'deposit' or 'withdraw'."
 	}
 	if len(errs) > 0 {
-		s.infoLog.Printf("%s - %s %s %s - ended with error (400, %s)", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI(), errs)
-		SendError(w, http.StatusBadRequest, errs)
+		s.sendError(w, r, http.StatusBadRequest, errs)
+		return
+	}
+	logging.AddAttrs(r.Context(), logging.WalletID(req.WalletId), logging.Operation(string(req.Operation)), logging.Amount(req.Amount))
+
+	// нужное право зависит от операции, поэтому проверяется здесь, а не в маршруте
+	scope := auth.ScopeDeposit
+	if req.Operation == api.Withdraw {
+		scope = auth.ScopeWithdraw
+	}
+	if !s.allowed(w, r, scope) {
+		return
+	}
+	// корзину кошелька тратят только клиенты с доступом к нему
+	if err := s.service.CheckWalletAccess(r.Context(), req.WalletId); err != nil {
+		if errors.Is(err, myerrors.ErrNotFound) {
+			s.sendError(w, r, http.StatusNotFound, err.Error())
+			return
+		} else if errors.Is(err, myerrors.ErrForbidden) {
+			s.sendError(w, r, http.StatusForbidden, err.Error())
+			return
+		}
+		s.sendError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// переводы по одному кошельку конкурируют за блокировку его строки
+	if !s.limit(w, r, limitByWallet, tenant.ID(r.Context())+"/"+req.WalletId, s.walletLimit(r)) {
 		return
 	}
 
@@ -63,44 +148,67 @@ func (s *Server) Transfer(w http.ResponseWriter, r *http.Request) {
 	case api.Deposit:
 		err := s.service.Deposit(r.Context(), req.WalletId, req.Amount)
 		if err != nil {
-			if errors.Is(err, myerrors.ErrNotFound) {
-				s.infoLog.Printf("%s - %s %s %s - ended with error (404, %s)", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI(), err.Error())
-				SendError(w, http.StatusNotFound, err.Error())
+			if errors.Is(err, myerrors.ErrLimitExceeded) || errors.Is(err, myerrors.ErrAmountRange) {
+				s.sendError(w, r, http.StatusBadRequest, err.Error())
+				return
+			} else if errors.Is(err, myerrors.ErrNotFound) {
+				s.sendError(w, r, http.StatusNotFound, err.Error())
+				return
+			} else if errors.Is(err, myerrors.ErrForbidden) {
+				s.sendError(w, r, http.StatusForbidden, err.Error())
+				return
+			} else if errors.Is(err, myerrors.ErrConflict) {
+				s.sendError(w, r, http.StatusConflict, err.Error())
+				return
+			} else if errors.Is(err, myerrors.ErrWalletFrozen) {
+				s.sendErrorCode(w, r, http.StatusConflict, "wallet_frozen", err.Error())
+				return
+			} else if errors.Is(err, myerrors.ErrTimeout) {
+				s.sendBusy(w, r, err)
 				return
 			}
-			s.infoLog.Printf("%s - %s %s %s - ended with error (500, %s)", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI(), err.Error())
-			SendError(w, http.StatusInternalServerError, err.Error())
+			s.sendError(w, r, http.StatusInternalServerError, err.Error())
 			return
 		}
 	case api.Withdraw:
 		err := s.service.Withdraw(r.Context(), req.WalletId, req.Amount)
 		if err != nil {
-			if errors.Is(err, myerrors.ErrNegativeAmount) {
-				s.infoLog.Printf("%s - %s %s %s - ended with error (400, %s)", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI(), err.Error())
-				SendError(w, http.StatusBadRequest, err.Error())
+			if errors.Is(err, myerrors.ErrNegativeAmount) || errors.Is(err, myerrors.ErrLimitExceeded) || errors.Is(err, myerrors.ErrAmountRange) {
+				s.sendError(w, r, http.StatusBadRequest, err.Error())
 				return
 			} else if errors.Is(err, myerrors.ErrNotFound) {
-				s.infoLog.Printf("%s - %s %s %s - ended with error (404, %s)", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI(), err.Error())
-				SendError(w, http.StatusNotFound, err.Error())
+				s.sendError(w, r, http.StatusNotFound, err.Error())
+				return
+			} else if errors.Is(err, myerrors.ErrForbidden) {
+				s.sendError(w, r, http.StatusForbidden, err.Error())
+				return
+			} else if errors.Is(err, myerrors.ErrConflict) {
+				s.sendError(w, r, http.StatusConflict, err.Error())
+				return
+			} else if errors.Is(err, myerrors.ErrWalletFrozen) {
+				s.sendErrorCode(w, r, http.StatusConflict, "wallet_frozen", err.Error())
+				return
+			} else if errors.Is(err, myerrors.ErrTimeout) {
+				s.sendBusy(w, r, err)
 				return
 			}
-			s.infoLog.Printf("%s - %s %s %s - ended with error (500, %s)", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI(), err.Error())
-			SendError(w, http.StatusInternalServerError, err.Error())
+			s.sendError(w, r, http.StatusInternalServerError, err.Error())
 			return
 		}
 	}
-	s.infoLog.Printf("%s - %s %s %s - completed successful", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI())
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (s *Server) CreateWallet(w http.ResponseWriter, r *http.Request) {
 	res, err := s.service.CreateWallet(r.Context())
 	if err != nil {
-		s.infoLog.Printf("%s - %s %s %s - ended with error (500, %s)", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI(), err.Error())
-		SendError(w, http.StatusInternalServerError, err.Error()) // репозиторий может вернуть AlreadyExists, но в данном случае это считаем ошибкой сервера
+		if errors.Is(err, myerrors.ErrTimeout) {
+			s.sendBusy(w, r, err)
+			return
+		}
+		s.sendError(w, r, http.StatusInternalServerError, err.Error()) // репозиторий может вернуть AlreadyExists, но в данном случае это считаем ошибкой сервера
 		return
 	}
-	s.infoLog.Printf("%s - %s %s %s - completed successful", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI())
 	w.Header().Set("Location", fmt.Sprintf("http://%s/api/v1/wallets/%s", s.host, res))
 	w.WriteHeader(http.StatusCreated)
 }
@@ -108,25 +216,29 @@ func (s *Server) CreateWallet(w http.ResponseWriter, r *http.Request) {
 func (s *Server) GetBalance(w http.ResponseWriter, r *http.Request) {
 	walletID := r.PathValue("wallet_uuid")
 	if walletID == "" {
-		s.infoLog.Printf("%s - %s %s %s - ended with error (400, %s)", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI(), "wallet uuid can not be empty")
-		SendError(w, http.StatusBadRequest, "wallet uuid can not be empty")
+		s.sendError(w, r, http.StatusBadRequest, "wallet uuid can not be empty")
 		return
 	}
+	logging.AddAttrs(r.Context(), logging.WalletID(walletID))
 
 	res, err := s.service.GetBalance(r.Context(), walletID)
 	if err != nil {
 		if errors.Is(err, myerrors.ErrNotFound) {
-			s.infoLog.Printf("%s - %s %s %s - ended with error (404, %s)", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI(), err.Error())
-			SendError(w, http.StatusNotFound, err.Error())
+			s.sendError(w, r, http.StatusNotFound, err.Error())
+			return
+		} else if errors.Is(err, myerrors.ErrForbidden) {
+			s.sendError(w, r, http.StatusForbidden, err.Error())
 			return
 		}
-		s.infoLog.Printf("%s - %s %s %s - ended with error (500, %s)", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI(), err.Error())
-		SendError(w, http.StatusInternalServerError, err.Error())
+		s.sendError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
-	s.infoLog.Printf("%s - %s %s %s - completed successful", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI())
-	WriteJSON(w, http.StatusOK, api.Balance{
+	balance := api.Balance{
 		Balance:  res,
 		WalletId: walletID,
-	})
+	}
+	if t, ok := tenant.FromContext(r.Context()); ok && t.Currency != "" {
+		balance.Currency = &t.Currency
+	}
+	WriteJSON(w, http.StatusOK, balance)
 }
@@ -2,19 +2,54 @@ package web
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
 
 	"github.com/glekoz/test_itk/api/v1"
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/shared/requestid"
 )
 
-func SendError(w http.ResponseWriter, status int, err string) {
+// sendError отправляет ошибку и добавляет ее причину в строку лога запроса
+func (s *Server) sendError(w http.ResponseWriter, r *http.Request, status int, err string) {
+	logging.AddAttrs(r.Context(), slog.String(logging.KeyError, err))
+	SendError(w, r, status, err)
+}
+
+// sendErrorCode - sendError с машиночитаемым кодом причины
+func (s *Server) sendErrorCode(w http.ResponseWriter, r *http.Request, status int, code, err string) {
+	logging.AddAttrs(r.Context(), slog.String(logging.KeyError, err))
+	writeError(w, r, status, code, err)
+}
+
+// sendBusy отвечает 503, когда кошелек дольше lock_timeout заблокирован другой операцией: запрос можно повторить
+func (s *Server) sendBusy(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("Retry-After", "1")
+	s.sendErrorCode(w, r, http.StatusServiceUnavailable, "wallet_busy", err.Error())
+}
+
+func SendError(w http.ResponseWriter, r *http.Request, status int, err string) {
+	writeError(w, r, status, "", err)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, err string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
+	var requestID *string
+	if id := requestid.FromContext(r.Context()); id != "" {
+		requestID = &id
+	}
+	var errorCode *string
+	if code != "" {
+		errorCode = &code
+	}
 	erro := json.NewEncoder(w).Encode(api.Error{
-		Status: status,
-		Title:  http.StatusText(status),
-		Detail: err,
+		Status:    status,
+		Title:     http.StatusText(status),
+		Detail:    err,
+		RequestId: requestID,
+		Code:      errorCode,
 	})
 	if erro != nil {
 		http.Error(w, "Failed to send error response", http.StatusInternalServerError)
@@ -1,15 +1,166 @@
 package web
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/metrics"
+	"github.com/glekoz/test_itk/internal/shared/clientip"
+	"github.com/glekoz/test_itk/internal/shared/consistency"
+	"github.com/glekoz/test_itk/internal/shared/requestid"
+	"github.com/glekoz/test_itk/internal/tracing"
+	"github.com/google/uuid"
+	"github.com/justinas/alice"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+type routeKey struct{}
+
+const maxRequestIDLength = 128
+
+// statusRecorder запоминает код ответа для метрик
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// requestID берет X-Request-ID клиента или генерирует новый и возвращает его в ответе
+func (a *Server) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(requestid.Header, id)
+
+		next.ServeHTTP(w, r.WithContext(requestid.WithID(r.Context(), id)))
+	})
+}
+
+// clientIP запоминает адрес клиента для лимитов и журнала аудита
+func (a *Server) clientIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := clientip.FromRemoteAddr(r.RemoteAddr)
+		next.ServeHTTP(w, r.WithContext(clientip.WithIP(r.Context(), ip)))
+	})
+}
+
+// consistencyToken принимает токен согласованности клиента и возвращает в ответе на запись новую позицию WAL.
+// Токен появляется, только если настроена реплика: без нее репозиторий позицию не запрашивает
+func (a *Server) consistencyToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var min consistency.LSN
+		if header := r.Header.Get(consistency.Header); header != "" {
+			lsn, err := consistency.ParseLSN(header)
+			if err != nil {
+				a.sendErrorCode(w, r, http.StatusBadRequest, "invalid_consistency_token", err.Error())
+				return
+			}
+			min = lsn
+		}
+		token := consistency.NewToken(min)
+
+		tw := &tokenWriter{ResponseWriter: w, token: token}
+		next.ServeHTTP(tw, r.WithContext(consistency.WithToken(r.Context(), token)))
+	})
+}
+
+// tokenWriter добавляет токен в заголовки перед началом ответа: обработчик к этому моменту уже закоммитил запись
+type tokenWriter struct {
+	http.ResponseWriter
+	token       *consistency.Token
+	wroteHeader bool
+}
+
+func (w *tokenWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if lsn := w.token.Written(); lsn != 0 {
+			w.Header().Set(consistency.Header, lsn.String())
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *tokenWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *tokenWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// validRequestID не пускает в логи и БД произвольные строки от клиента
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// requestLogger кладет в контекст логгер с идентификатором запроса и накопитель полей для logRequest
+func (a *Server) requestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := a.logger.With(slog.String(logging.KeyRequestID, requestid.FromContext(r.Context())))
+		ctx := logging.WithAttrs(logging.WithLogger(r.Context(), logger))
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// logRequest пишет одну строку на запрос: обработчики только добавляют в нее поля
 func (a *Server) logRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		a.infoLog.Printf("%s - %s %s %s", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI())
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(rec, r)
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.RequestURI()),
+			slog.String("route", routePattern(r)),
+			slog.String("remote_addr", r.RemoteAddr),
+			logging.Status(rec.status),
+			logging.Duration(time.Since(start)),
+		}
+		attrs = append(attrs, logging.Attrs(r.Context())...)
+
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logging.FromContext(r.Context(), a.logger).LogAttrs(r.Context(), level, "request", attrs...)
 	})
 }
 
@@ -17,11 +168,79 @@ func (a *Server) recoverPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
+				logging.FromContext(r.Context(), a.logger).LogAttrs(r.Context(), slog.LevelError, "panic recovered",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.RequestURI()),
+					logging.Status(http.StatusInternalServerError),
+					slog.String(logging.KeyError, fmt.Sprintf("%s", err)),
+					slog.String("stack", string(debug.Stack())),
+				)
 				w.Header().Set("Connection", "close")
-				SendError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+				// по request_id в ответе клиент может найти запись о панике в логах
+				SendError(w, r, http.StatusInternalServerError, fmt.Sprintf("%s", err))
 			}
 		}()
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+func (a *Server) recordMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		// шаблон маршрута вместо пути не раздувает число серий
+		route := routePattern(r)
+		status := strconv.Itoa(rec.status)
+		metrics.HTTPRequests.WithLabelValues(route, r.Method, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+// matchRoute заранее определяет шаблон маршрута. ServeMux заполняет r.Pattern только
+// у своей копии запроса, а middleware, меняющие контекст, работают с другими копиями
+func matchRoute(mux *http.ServeMux) alice.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, pattern := mux.Handler(r)
+			ctx := context.WithValue(r.Context(), routeKey{}, pattern)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func routePattern(r *http.Request) string {
+	pattern, _ := r.Context().Value(routeKey{}).(string)
+	if pattern == "" {
+		return "unmatched"
+	}
+	return pattern
+}
+
+// traceRequest продолжает трейс из заголовка traceparent или начинает новый
+func (a *Server) traceRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		route := routePattern(r)
+		ctx, span := tracing.Tracer().Start(ctx, route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
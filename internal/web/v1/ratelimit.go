@@ -0,0 +1,118 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/metrics"
+	"github.com/glekoz/test_itk/internal/ratelimit"
+	"github.com/glekoz/test_itk/internal/shared/clientip"
+	"github.com/glekoz/test_itk/internal/tenant"
+)
+
+const (
+	limitByClient = "client"
+	limitByIP     = "ip"
+	limitByWallet = "wallet"
+)
+
+// RateLimits задает корзины по измерениям; нулевой Limit отключает измерение
+type RateLimits struct {
+	Client ratelimit.Limit
+	IP     ratelimit.Limit
+	Wallet ratelimit.Limit
+}
+
+// SetRateLimiter включает ограничение частоты запросов к API
+func (s *Server) SetRateLimiter(store ratelimit.Store, limits RateLimits) {
+	s.limiter = store
+	s.SetRateLimits(limits)
+}
+
+// SetRateLimits меняет лимиты на лету; корзины в хранилище сохраняются
+func (s *Server) SetRateLimits(limits RateLimits) {
+	s.limits.Store(&limits)
+}
+
+func (s *Server) rateLimits() RateLimits {
+	if limits := s.limits.Load(); limits != nil {
+		return *limits
+	}
+	return RateLimits{}
+}
+
+// limitIP стоит до аутентификации, чтобы перебор ключей тоже упирался в лимит
+func (a *Server) limitIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.limit(w, r, limitByIP, clientip.FromContext(r.Context()), a.rateLimits().IP) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitClient стоит после аутентификации; без аутентификации клиентов не различить
+func (a *Server) limitClient(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := auth.FromContext(r.Context()); ok {
+			limit := a.rateLimits().Client
+			if t, ok := tenant.FromContext(r.Context()); ok && t.ClientRateLimit != nil {
+				limit = *t.ClientRateLimit
+			}
+			// ID уникальны только внутри арендатора и способа аутентификации
+			if !a.limit(w, r, limitByClient, tenant.ID(r.Context())+"/"+string(p.Method)+"/"+p.ID, limit) {
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// walletLimit учитывает настройку арендатора, если она задана
+func (s *Server) walletLimit(r *http.Request) ratelimit.Limit {
+	if t, ok := tenant.FromContext(r.Context()); ok && t.WalletRateLimit != nil {
+		return *t.WalletRateLimit
+	}
+	return s.rateLimits().Wallet
+}
+
+// limit берет токен из корзины измерения и отвечает 429, если токенов нет.
+// При сбое хранилища запрос пропускается: лимит защищает базу, но не должен останавливать переводы
+func (s *Server) limit(w http.ResponseWriter, r *http.Request, dimension, key string, limit ratelimit.Limit) bool {
+	if s.limiter == nil || !limit.Enabled() {
+		return true
+	}
+	res, err := s.limiter.Take(r.Context(), dimension+":"+key, limit)
+	if err != nil {
+		logging.FromContext(r.Context(), s.logger).WarnContext(r.Context(), "rate limit check failed, request allowed", logging.Error(err))
+		return true
+	}
+	setRateLimitHeaders(w, res)
+	if res.Allowed {
+		return true
+	}
+
+	metrics.RateLimitedRequests.WithLabelValues(dimension).Inc()
+	w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+	s.sendErrorCode(w, r, http.StatusTooManyRequests, "rate_limited", fmt.Sprintf("%s rate limit exceeded", dimension))
+	return false
+}
+
+// setRateLimitHeaders показывает самое строгое из проверенных измерений - с наименьшим остатком
+func setRateLimitHeaders(w http.ResponseWriter, res ratelimit.Result) {
+	if current, err := strconv.Atoi(w.Header().Get("RateLimit-Remaining")); err == nil && current <= res.Remaining {
+		return
+	}
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -3,16 +3,41 @@ package web
 import (
 	"net/http"
 
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/metrics"
 	"github.com/justinas/alice"
 )
 
 func (a *Server) Routes() http.Handler {
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("POST /api/v1/wallet", a.Transfer)
-	mux.HandleFunc("POST /api/v1/wallet/create", a.CreateWallet)
-	mux.HandleFunc("GET /api/v1/wallets/{wallet_uuid}", a.GetBalance)
+	mux.HandleFunc("GET /healthz", a.Healthz)
+	mux.HandleFunc("GET /readyz", a.Ready)
+	mux.Handle("GET /metrics", metrics.Handler())
 
-	standard := alice.New(a.recoverPanic, a.logRequest)
+	protected := alice.New(a.limitIP, a.authenticate, a.resolveTenant, a.limitClient)
+
+	// право для перевода зависит от операции и проверяется в обработчике
+	mux.Handle("POST /api/v1/wallet", protected.ThenFunc(a.Transfer))
+	mux.Handle("POST /api/v1/wallet/create", protected.Append(a.requireScope(auth.ScopeDeposit)).ThenFunc(a.CreateWallet))
+	mux.Handle("GET /api/v1/wallets/{wallet_uuid}", protected.Append(a.requireScope(auth.ScopeRead)).ThenFunc(a.GetBalance))
+
+	mux.Handle("POST /api/v1/webhooks", protected.Append(a.requireScope(auth.ScopeRead)).ThenFunc(a.CreateWebhookSubscription))
+	mux.Handle("GET /api/v1/webhooks/{subscription_id}/deliveries", protected.Append(a.requireScope(auth.ScopeAdmin)).ThenFunc(a.ListWebhookDeliveries))
+	mux.Handle("POST /api/v1/webhooks/deliveries/{delivery_id}/replay", protected.Append(a.requireScope(auth.ScopeAdmin)).ThenFunc(a.ReplayWebhookDelivery))
+
+	mux.Handle("POST /api/v1/admin/keys", protected.Append(a.requireScope(auth.ScopeAdmin)).ThenFunc(a.IssueAPIKey))
+	mux.Handle("DELETE /api/v1/admin/keys/{key_id}", protected.Append(a.requireScope(auth.ScopeAdmin)).ThenFunc(a.RevokeAPIKey))
+
+	// служебный API поддержки; арендатор выбирается так же, как в клиентском API
+	operator := protected.Append(a.requireScope(auth.ScopeOperator))
+	mux.Handle("GET /admin/v1/wallets", operator.ThenFunc(a.AdminListWallets))
+	mux.Handle("GET /admin/v1/wallets/export", operator.ThenFunc(a.AdminExportWallets))
+	mux.Handle("GET /admin/v1/wallets/{wallet_id}", operator.ThenFunc(a.AdminGetWallet))
+	mux.Handle("POST /admin/v1/wallets/{wallet_id}/adjustments", operator.ThenFunc(a.AdminAdjustBalance))
+	mux.Handle("PUT /admin/v1/wallets/{wallet_id}/status", operator.ThenFunc(a.AdminSetWalletStatus))
+
+	// recoverPanic - внутри метрик, трассировки и лога запроса, чтобы запрос с паникой попал в них как 500
+	standard := alice.New(a.requestID, a.clientIP, a.requestLogger, matchRoute(mux), a.traceRequest, a.recordMetrics, a.logRequest, a.consistencyToken, a.recoverPanic)
 	return standard.Then(mux)
 }
@@ -0,0 +1,59 @@
+package web
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/tenant"
+)
+
+// SetTenants включает разделение по арендаторам с настройками из registry;
+// при перезагрузке конфигурации реестр подменяется целиком для следующих запросов
+func (s *Server) SetTenants(registry *tenant.Registry) {
+	s.tenants.Store(registry)
+}
+
+// resolveTenant определяет арендатора запроса. Арендатор из учетных данных главнее заголовка:
+// клиент бренда не может переключиться на чужой бренд, указав X-Tenant-ID. Выбирать арендатора
+// заголовком может только администратор платформы (admin без арендатора), остальные клиенты
+// без арендатора работают в арендаторе по умолчанию
+func (a *Server) resolveTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get(tenant.Header)
+		id := header
+		// без аутентификации (AUTH_MODE=none) разделять нечего, заголовок принимается как есть
+		if p, ok := auth.FromContext(r.Context()); ok {
+			own := p.TenantID
+			if own == "" && !p.Has(auth.ScopeAdmin) {
+				own = tenant.DefaultID
+			}
+			if own != "" {
+				if header != "" && header != own {
+					a.sendError(w, r, http.StatusForbidden, "credentials belong to another tenant")
+					return
+				}
+				id = own
+			}
+		}
+		if id == "" {
+			id = tenant.DefaultID
+		}
+
+		t := tenant.Config{ID: tenant.DefaultID}
+		if tenants := a.tenants.Load(); tenants != nil {
+			var ok bool
+			if t, ok = tenants.Get(id); !ok {
+				a.sendError(w, r, http.StatusBadRequest, "unknown tenant")
+				return
+			}
+		} else if id != tenant.DefaultID {
+			a.sendError(w, r, http.StatusBadRequest, "unknown tenant")
+			return
+		}
+		logging.AddAttrs(r.Context(), slog.String(logging.KeyTenantID, t.ID))
+
+		next.ServeHTTP(w, r.WithContext(tenant.WithConfig(r.Context(), t)))
+	})
+}
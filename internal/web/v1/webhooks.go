@@ -0,0 +1,149 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/glekoz/test_itk/api/v1"
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/webhook"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+func (s *Server) CreateWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	var req api.WebhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, r, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	u, err := url.Parse(req.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		s.sendError(w, r, http.StatusBadRequest, "url must be an absolute http(s) URL")
+		return
+	}
+	// DNS-имена проверяет клиент воркера при соединении, здесь отсекаются очевидные случаи
+	if !publicHost(u.Hostname()) {
+		s.sendError(w, r, http.StatusBadRequest, "url must point to a public host")
+		return
+	}
+	var walletID string
+	if req.WalletId != nil {
+		walletID = *req.WalletId
+		logging.AddAttrs(r.Context(), logging.WalletID(walletID))
+	}
+
+	// подписка на все кошельки видит чужие операции, поэтому доступна только администратору
+	if walletID == "" && !s.allowed(w, r, auth.ScopeAdmin) {
+		return
+	}
+
+	sub, err := s.service.CreateWebhookSubscription(r.Context(), walletID, req.Url)
+	if err != nil {
+		if errors.Is(err, myerrors.ErrNotFound) {
+			s.sendError(w, r, http.StatusNotFound, err.Error())
+			return
+		} else if errors.Is(err, myerrors.ErrForbidden) {
+			s.sendError(w, r, http.StatusForbidden, err.Error())
+			return
+		}
+		s.sendError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	WriteJSON(w, http.StatusCreated, api.WebhookSubscription{
+		Id:        sub.ID,
+		Url:       sub.URL,
+		WalletId:  req.WalletId,
+		Secret:    sub.Secret,
+		CreatedAt: sub.CreatedAt,
+	})
+}
+
+func publicHost(host string) bool {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return false
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return webhook.IsPublicAddr(addr)
+	}
+	return true
+}
+
+func (s *Server) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	subscriptionID := r.PathValue("subscription_id")
+	if subscriptionID == "" {
+		s.sendError(w, r, http.StatusBadRequest, "subscription id can not be empty")
+		return
+	}
+
+	limit := defaultDeliveriesLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxDeliveriesLimit {
+			s.sendError(w, r, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := s.service.ListWebhookDeliveries(r.Context(), subscriptionID, limit)
+	if err != nil {
+		if errors.Is(err, myerrors.ErrNotFound) {
+			s.sendError(w, r, http.StatusNotFound, err.Error())
+			return
+		}
+		s.sendError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	res := api.WebhookDeliveries{Deliveries: make([]api.WebhookDelivery, 0, len(deliveries))}
+	for _, d := range deliveries {
+		res.Deliveries = append(res.Deliveries, api.WebhookDelivery{
+			Id:             d.ID,
+			SubscriptionId: d.SubscriptionID,
+			EventId:        d.EventID,
+			EventType:      d.EventType,
+			Status:         api.WebhookDeliveryStatus(d.Status),
+			Attempts:       d.Attempts,
+			NextAttemptAt:  d.NextAttemptAt,
+			LastError:      d.LastError,
+			CreatedAt:      d.CreatedAt,
+			UpdatedAt:      d.UpdatedAt,
+		})
+	}
+	WriteJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) ReplayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	deliveryID := r.PathValue("delivery_id")
+	if deliveryID == "" {
+		s.sendError(w, r, http.StatusBadRequest, "delivery id can not be empty")
+		return
+	}
+
+	err := s.service.ReplayWebhookDelivery(r.Context(), deliveryID)
+	if err != nil {
+		if errors.Is(err, myerrors.ErrNotFound) {
+			s.sendError(w, r, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, myerrors.ErrDeliveryInProgress) {
+			s.sendError(w, r, http.StatusConflict, err.Error())
+			return
+		}
+		s.sendError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
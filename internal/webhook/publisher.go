@@ -0,0 +1,25 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/glekoz/test_itk/internal/shared/models"
+)
+
+type DeliveryCreator interface {
+	CreateWebhookDeliveries(ctx context.Context, event models.OutboxEvent) error
+}
+
+// Publisher подключается к релею outbox и превращает события в доставки по подпискам,
+// которые затем отправляет Worker
+type Publisher struct {
+	repo DeliveryCreator
+}
+
+func NewPublisher(repo DeliveryCreator) *Publisher {
+	return &Publisher{repo: repo}
+}
+
+func (p *Publisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	return p.repo.CreateWebhookDeliveries(ctx, event)
+}
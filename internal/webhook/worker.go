@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+)
+
+const (
+	batchSize       = 100
+	deliveryTimeout = 10 * time.Second
+	// доставки пачки отправляются по очереди, поэтому аренда должна пережить отправку всей пачки,
+	// иначе другой экземпляр захватит еще не отправленные доставки и отправит их второй раз.
+	// Запас покрывает запись результатов в базу
+	claimLease  = batchSize*deliveryTimeout + time.Minute
+	baseBackoff = 5 * time.Second
+	maxBackoff  = time.Hour
+)
+
+type RepoAPI interface {
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookAttempt, error)
+	MarkWebhookDeliveryDelivered(ctx context.Context, deliveryID string) error
+	MarkWebhookDeliveryFailed(ctx context.Context, deliveryID string, status myvars.DeliveryStatus, lastErr string, nextAttemptAt time.Time) error
+}
+
+type Worker struct {
+	repo         RepoAPI
+	client       *http.Client
+	pollInterval time.Duration
+	maxAttempts  int
+	logger       *slog.Logger
+}
+
+func New(repo RepoAPI, pollInterval time.Duration, maxAttempts int, logger *slog.Logger) *Worker {
+	return &Worker{
+		repo:         repo,
+		client:       NewClient(),
+		pollInterval: pollInterval,
+		maxAttempts:  maxAttempts,
+		logger:       logger,
+	}
+}
+
+// SetClient заменяет HTTP-клиент доставок. Нужен тестам, которые поднимают получателя на loopback
+func (w *Worker) SetClient(client *http.Client) {
+	w.client = client
+}
+
+// Run отправляет доставки из очереди, пока не будет отменен ctx
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.Tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick выполняет одну итерацию: захватывает и отправляет готовые доставки
+func (w *Worker) Tick(ctx context.Context) {
+	attempts, err := w.repo.ClaimWebhookDeliveries(ctx, batchSize, claimLease)
+	if err != nil {
+		w.logger.ErrorContext(ctx, "claiming webhook deliveries failed", logging.Error(err))
+		return
+	}
+	for _, a := range attempts {
+		w.deliver(ctx, a)
+	}
+}
+
+func (w *Worker) deliver(ctx context.Context, a models.WebhookAttempt) {
+	err := w.send(ctx, a)
+	if err == nil {
+		if err := w.repo.MarkWebhookDeliveryDelivered(ctx, a.DeliveryID); err != nil {
+			w.logger.ErrorContext(ctx, "marking webhook delivery as delivered failed", slog.String("delivery_id", a.DeliveryID), logging.Error(err))
+		}
+		return
+	}
+
+	attempt := a.Attempts + 1
+	status := myvars.DeliveryStatusPending
+	if attempt >= w.maxAttempts {
+		status = myvars.DeliveryStatusDead
+		w.logger.WarnContext(ctx, "webhook delivery moved to dead letter", slog.String("delivery_id", a.DeliveryID), slog.Int("attempts", attempt), logging.Error(err))
+	}
+	nextAttemptAt := time.Now().UTC().Add(Backoff(attempt))
+	if err := w.repo.MarkWebhookDeliveryFailed(ctx, a.DeliveryID, status, err.Error(), nextAttemptAt); err != nil {
+		w.logger.ErrorContext(ctx, "marking webhook delivery as failed", slog.String("delivery_id", a.DeliveryID), logging.Error(err))
+	}
+}
+
+func (w *Worker) send(ctx context.Context, a models.WebhookAttempt) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(a.Payload))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDeliveryID, a.DeliveryID)
+	req.Header.Set(HeaderEvent, a.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(a.Secret, timestamp, a.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Backoff возвращает задержку перед следующей попыткой: 5s, 10s, 20s, ... но не больше часа
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := baseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
@@ -0,0 +1,144 @@
+package audit_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chain собирает цепочки так же, как репозиторий: у каждого кошелька своя
+func chain(t *testing.T, entries []audit.Entry) []audit.Entry {
+	t.Helper()
+	last := map[string]string{}
+	created := time.Date(2025, 11, 9, 12, 0, 0, 0, time.UTC)
+	for i := range entries {
+		entries[i].ID = int64(i + 1)
+		entries[i].CreatedAt = created.Add(time.Duration(i) * time.Microsecond)
+		require.NoError(t, audit.Seal(&entries[i], last[entries[i].WalletID]))
+		last[entries[i].WalletID] = entries[i].Hash
+	}
+	return entries
+}
+
+func state(t *testing.T, s audit.State) json.RawMessage {
+	t.Helper()
+	b, err := audit.MarshalState(&s)
+	require.NoError(t, err)
+	return b
+}
+
+func balance(n int) *int {
+	return &n
+}
+
+func testEntries(t *testing.T) []audit.Entry {
+	return chain(t, []audit.Entry{
+		{TenantID: "default", Actor: "k1", Action: audit.ActionCreateWallet, WalletID: "w1", After: state(t, audit.State{Balance: balance(0), Status: "active"})},
+		{TenantID: "brand-a", Actor: "k2", Action: audit.ActionCreateWallet, WalletID: "w2", After: state(t, audit.State{Balance: balance(0), Status: "active"})},
+		{TenantID: "default", Actor: "k1", Action: audit.ActionDeposit, WalletID: "w1", RequestID: "req-1", SourceIP: "10.0.0.1",
+			Before: state(t, audit.State{Balance: balance(0)}), After: state(t, audit.State{Balance: balance(100)})},
+		{TenantID: "default", Actor: "ops", Action: audit.ActionSetWalletStatus, WalletID: "w1", Reason: "fraud investigation",
+			Before: state(t, audit.State{Status: "active"}), After: state(t, audit.State{Status: "frozen"})},
+	})
+}
+
+func verify(entries []audit.Entry) error {
+	v := audit.NewVerifier()
+	for _, e := range entries {
+		if err := v.Check(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestVerifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   func(entries []audit.Entry) []audit.Entry
+		brokenAt int64
+	}{
+		{
+			name:   "intact",
+			tamper: func(entries []audit.Entry) []audit.Entry { return entries },
+		},
+		{
+			name: "changed value",
+			tamper: func(entries []audit.Entry) []audit.Entry {
+				entries[2].After = json.RawMessage(`{"balance":1000000}`)
+				return entries
+			},
+			brokenAt: 3,
+		},
+		{
+			name: "changed actor",
+			tamper: func(entries []audit.Entry) []audit.Entry {
+				entries[3].Actor = "someone-else"
+				return entries
+			},
+			brokenAt: 4,
+		},
+		{
+			name: "deleted entry",
+			tamper: func(entries []audit.Entry) []audit.Entry {
+				return append(entries[:2], entries[3:]...)
+			},
+			brokenAt: 4,
+		},
+		{
+			name: "rehashed entry",
+			// пересчитанный хеш не спасает: следующая запись ссылается на старый
+			tamper: func(entries []audit.Entry) []audit.Entry {
+				entries[0].Actor = "someone-else"
+				entries[0].Hash, _ = entries[0].ComputeHash()
+				return entries
+			},
+			brokenAt: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verify(tt.tamper(testEntries(t)))
+			if tt.brokenAt == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, audit.ErrChainBroken)
+			var broken *audit.BrokenChainError
+			require.ErrorAs(t, err, &broken)
+			assert.Equal(t, tt.brokenAt, broken.ID)
+		})
+	}
+}
+
+func TestVerifier_WalletChainsAreIndependent(t *testing.T) {
+	entries := testEntries(t)
+	assert.Empty(t, entries[0].PrevHash)
+	assert.Empty(t, entries[1].PrevHash)
+	assert.Equal(t, entries[0].Hash, entries[2].PrevHash)
+
+	v := audit.NewVerifier()
+	for _, e := range entries {
+		require.NoError(t, v.Check(e))
+	}
+	assert.Equal(t, 4, v.Checked())
+}
+
+func TestComputeHash_JSONBFormatting(t *testing.T) {
+	e := testEntries(t)[2]
+	// JSONB возвращает ключи в своем порядке и с пробелами
+	e.Before = json.RawMessage(`{"balance": 0}`)
+	e.After = json.RawMessage(`{ "balance": 100 }`)
+	hash, err := e.ComputeHash()
+	require.NoError(t, err)
+	assert.Equal(t, e.Hash, hash)
+
+	got, err := audit.Canonical([]byte(`{"status": "frozen", "balance": 5}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"balance":5,"status":"frozen"}`, string(got))
+}
@@ -0,0 +1,87 @@
+package auth_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type keyStore map[string]models.APIKey
+
+func (s keyStore) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	k, ok := s[hash]
+	if !ok {
+		return models.APIKey{}, myerrors.ErrNotFound
+	}
+	return k, nil
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	active, activeHash, err := auth.GenerateAPIKey()
+	require.NoError(t, err)
+	revoked, revokedHash, err := auth.GenerateAPIKey()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(active, "itk_"))
+	assert.Equal(t, activeHash, auth.HashAPIKey(active))
+
+	revokedAt := time.Now()
+	store := keyStore{
+		activeHash:  {ID: "k1", OwnerID: "merchant-1", Scopes: []string{"read", "withdraw", "legacy"}},
+		revokedHash: {ID: "k2", Scopes: []string{"admin"}, RevokedAt: &revokedAt},
+	}
+	authenticator := auth.NewAPIKeyAuthenticator(store)
+
+	tests := []struct {
+		name        string
+		key         string
+		expectedErr error
+		expectedID  string
+	}{
+		{name: "missing key", key: "", expectedErr: auth.ErrUnauthenticated},
+		{name: "unknown key", key: "itk_unknown", expectedErr: auth.ErrInvalidCredentials},
+		{name: "revoked key", key: revoked, expectedErr: auth.ErrInvalidCredentials},
+		{name: "active key", key: active, expectedID: "k1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/wallets/w-1", nil)
+			if tt.key != "" {
+				req.Header.Set(auth.APIKeyHeader, tt.key)
+			}
+
+			p, err := authenticator.Authenticate(req)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedID, p.ID)
+			assert.Equal(t, []auth.Scope{auth.ScopeRead, auth.ScopeWithdraw}, p.Scopes)
+		})
+	}
+}
+
+func TestPrincipal(t *testing.T) {
+	merchant := auth.Principal{ID: "k1", OwnerID: "merchant-1", Scopes: []auth.Scope{auth.ScopeRead}}
+	assert.True(t, merchant.Has(auth.ScopeRead))
+	assert.False(t, merchant.Has(auth.ScopeWithdraw))
+	assert.True(t, merchant.CanAccess("merchant-1"))
+	assert.False(t, merchant.CanAccess("merchant-2"))
+	assert.False(t, merchant.CanAccess(""))
+
+	admin := auth.Principal{ID: "k2", OwnerID: "merchant-1", Scopes: []auth.Scope{auth.ScopeAdmin}}
+	assert.True(t, admin.Has(auth.ScopeWithdraw))
+	assert.True(t, admin.CanAccess("merchant-2"))
+
+	unrestricted := auth.Principal{ID: "k3", Scopes: []auth.Scope{auth.ScopeDeposit}}
+	assert.True(t, unrestricted.CanAccess("merchant-2"))
+}
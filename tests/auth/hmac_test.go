@@ -0,0 +1,163 @@
+package auth_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/cache"
+	"github.com/glekoz/test_itk/pkg/signing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const partnerSecret = "0123456789abcdef0123456789abcdef"
+
+func newHMACAuthenticator(t *testing.T) *auth.HMACAuthenticator {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "clients.json")
+	data, err := json.Marshal(map[string]any{"clients": []map[string]any{
+		{"id": "partner-1", "secret": partnerSecret, "owner_id": "merchant-1", "scopes": []string{"read", "withdraw"}},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	nonces, err := cache.NewNonceCache(10 * time.Minute)
+	require.NoError(t, err)
+	a, err := auth.NewHMACAuthenticator(path, 5*time.Minute, nonces)
+	require.NoError(t, err)
+	return a
+}
+
+// signedRequest подписывает клиентским помощником и превращает запрос в серверный
+func signedRequest(t *testing.T, body string) *http.Request {
+	t.Helper()
+	client, err := http.NewRequest("POST", "http://wallet.local/api/v1/wallet?source=partner", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	require.NoError(t, signing.SignRequest(client, "partner-1", []byte(partnerSecret)))
+
+	sent, err := io.ReadAll(client.Body)
+	require.NoError(t, err)
+	req := httptest.NewRequest(client.Method, client.URL.RequestURI(), bytes.NewReader(sent))
+	req.Header = client.Header.Clone()
+	return req
+}
+
+func TestHMACAuthenticator(t *testing.T) {
+	a := newHMACAuthenticator(t)
+	body := `{"wallet_id": "w-1", "operation": "withdraw", "amount": 100000}`
+
+	req := signedRequest(t, body)
+	p, err := a.Authenticate(req)
+	require.NoError(t, err)
+	assert.Equal(t, "partner-1", p.ID)
+	assert.Equal(t, "merchant-1", p.OwnerID)
+	assert.Equal(t, []auth.Scope{auth.ScopeRead, auth.ScopeWithdraw}, p.Scopes)
+
+	// тело остается доступным обработчику
+	rest, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(rest))
+
+	t.Run("replay", func(t *testing.T) {
+		again := httptest.NewRequest(req.Method, req.URL.RequestURI(), bytes.NewBufferString(body))
+		again.Header = req.Header.Clone()
+		_, err := a.Authenticate(again)
+		assert.ErrorIs(t, err, auth.ErrReplay)
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		tampered := signedRequest(t, body)
+		tampered.Body = io.NopCloser(bytes.NewBufferString(`{"wallet_id": "w-1", "operation": "withdraw", "amount": 999999}`))
+		_, err := a.Authenticate(tampered)
+		assert.ErrorIs(t, err, auth.ErrInvalidSignature)
+	})
+
+	t.Run("tampered path", func(t *testing.T) {
+		tampered := signedRequest(t, body)
+		tampered.URL.RawQuery = "source=other"
+		_, err := a.Authenticate(tampered)
+		assert.ErrorIs(t, err, auth.ErrInvalidSignature)
+	})
+
+	t.Run("clock skew", func(t *testing.T) {
+		old := time.Now().Add(-10 * time.Minute).Unix()
+		skewed := httptest.NewRequest("POST", "/api/v1/wallet", bytes.NewBufferString(body))
+		skewed.Header.Set(signing.HeaderClientID, "partner-1")
+		skewed.Header.Set(signing.HeaderTimestamp, strconv.FormatInt(old, 10))
+		skewed.Header.Set(signing.HeaderNonce, "n-1")
+		skewed.Header.Set(signing.HeaderSignature, signing.Compute([]byte(partnerSecret),
+			signing.StringToSign("POST", "/api/v1/wallet", old, "n-1", []byte(body))))
+		_, err := a.Authenticate(skewed)
+		assert.ErrorIs(t, err, auth.ErrClockSkew)
+	})
+
+	t.Run("unknown client", func(t *testing.T) {
+		unknown := signedRequest(t, body)
+		unknown.Header.Set(signing.HeaderClientID, "partner-2")
+		_, err := a.Authenticate(unknown)
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	})
+
+	t.Run("incomplete headers", func(t *testing.T) {
+		incomplete := signedRequest(t, body)
+		incomplete.Header.Del(signing.HeaderNonce)
+		_, err := a.Authenticate(incomplete)
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		_, err := a.Authenticate(httptest.NewRequest("POST", "/api/v1/wallet", bytes.NewBufferString(body)))
+		assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+	})
+}
+
+func TestChain(t *testing.T) {
+	active, activeHash, err := auth.GenerateAPIKey()
+	require.NoError(t, err)
+	a := auth.Chain(newHMACAuthenticator(t), auth.NewAPIKeyAuthenticator(keyStore{activeHash: {ID: "k1"}}))
+
+	p, err := a.Authenticate(signedRequest(t, `{}`))
+	require.NoError(t, err)
+	assert.Equal(t, "partner-1", p.ID)
+
+	req := httptest.NewRequest("GET", "/api/v1/wallets/w-1", nil)
+	req.Header.Set(auth.APIKeyHeader, active)
+	p, err = a.Authenticate(req)
+	require.NoError(t, err)
+	assert.Equal(t, "k1", p.ID)
+
+	// неверная подпись не передается следующему способу, даже если ключ верный
+	bad := signedRequest(t, `{}`)
+	bad.Header.Set(signing.HeaderSignature, "00")
+	bad.Header.Set(auth.APIKeyHeader, active)
+	_, err = a.Authenticate(bad)
+	assert.ErrorIs(t, err, auth.ErrInvalidSignature)
+
+	_, err = a.Authenticate(httptest.NewRequest("GET", "/api/v1/wallets/w-1", nil))
+	assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+}
+
+func TestNonceCache(t *testing.T) {
+	nonces, err := cache.NewNonceCache(time.Minute)
+	require.NoError(t, err)
+
+	fresh, err := nonces.Use("partner-1:n-1")
+	require.NoError(t, err)
+	assert.True(t, fresh)
+	fresh, err = nonces.Use("partner-1:n-1")
+	require.NoError(t, err)
+	assert.False(t, fresh)
+	fresh, err = nonces.Use("partner-2:n-1")
+	require.NoError(t, err)
+	assert.True(t, fresh)
+}
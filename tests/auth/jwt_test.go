@@ -0,0 +1,307 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAudience = "wallet-api"
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+		"n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "EC", "kid": kid, "crv": "P-256",
+		"x": b64(key.X.FillBytes(make([]byte, 32))), "y": b64(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func writeJWKS(t *testing.T, path string, keys ...map[string]string) {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	require.NoError(t, err)
+	// запись через временный файл и rename, как это делают секреты в Kubernetes
+	tmp := path + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, data, 0o600))
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+func signJWT(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		require.NoError(t, err)
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + b64(sig)
+}
+
+func validClaims() map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"sub":   "client-1",
+		"aud":   testAudience,
+		"exp":   now.Add(time.Hour).Unix(),
+		"nbf":   now.Add(-time.Minute).Unix(),
+		"scope": "read deposit",
+	}
+}
+
+func with(claims map[string]any, key string, value any) map[string]any {
+	claims[key] = value
+	return claims
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	foreignKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, rsaJWK("rsa-1", &rsaKey.PublicKey), ecJWK("ec-1", &ecKey.PublicKey))
+	authenticator, err := auth.NewJWTAuthenticator(path, testAudience, "owner_id", slog.Default())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		header         string
+		expectedErr    error
+		expectedOwner  string
+		expectedScopes []auth.Scope
+	}{
+		{name: "missing header", header: "", expectedErr: auth.ErrUnauthenticated},
+		{name: "not bearer", header: "Basic dXNlcjpwYXNz", expectedErr: auth.ErrUnauthenticated},
+		{name: "malformed", header: "Bearer abc.def", expectedErr: auth.ErrInvalidCredentials},
+		{
+			name:           "valid RS256",
+			header:         "Bearer " + signJWT(t, "RS256", "rsa-1", rsaKey, with(validClaims(), "owner_id", "merchant-1")),
+			expectedOwner:  "merchant-1",
+			expectedScopes: []auth.Scope{auth.ScopeRead, auth.ScopeDeposit},
+		},
+		{
+			// без владельца токен получил бы доступ ко всем кошелькам арендатора
+			name:        "without owner claim",
+			header:      "Bearer " + signJWT(t, "RS256", "rsa-1", rsaKey, validClaims()),
+			expectedErr: auth.ErrInvalidCredentials,
+		},
+		{
+			name:        "empty owner claim",
+			header:      "Bearer " + signJWT(t, "RS256", "rsa-1", rsaKey, with(validClaims(), "owner_id", "")),
+			expectedErr: auth.ErrInvalidCredentials,
+		},
+		{
+			name:           "admin without owner claim",
+			header:         "Bearer " + signJWT(t, "RS256", "rsa-1", rsaKey, with(validClaims(), "scope", "admin")),
+			expectedScopes: []auth.Scope{auth.ScopeAdmin},
+		},
+		{
+			name:           "valid ES256 with owner and scopes array",
+			header:         "Bearer " + signJWT(t, "ES256", "ec-1", ecKey, with(with(validClaims(), "owner_id", "merchant-1"), "scopes", []string{"withdraw", "unknown"})),
+			expectedOwner:  "merchant-1",
+			expectedScopes: []auth.Scope{auth.ScopeRead, auth.ScopeDeposit, auth.ScopeWithdraw},
+		},
+		{
+			name:           "audience array",
+			header:         "Bearer " + signJWT(t, "RS256", "rsa-1", rsaKey, with(with(validClaims(), "owner_id", "merchant-1"), "aud", []string{"other", testAudience})),
+			expectedOwner:  "merchant-1",
+			expectedScopes: []auth.Scope{auth.ScopeRead, auth.ScopeDeposit},
+		},
+		{
+			name:        "expired",
+			header:      "Bearer " + signJWT(t, "RS256", "rsa-1", rsaKey, with(validClaims(), "exp", time.Now().Add(-time.Hour).Unix())),
+			expectedErr: auth.ErrInvalidCredentials,
+		},
+		{
+			name:        "without exp",
+			header:      "Bearer " + signJWT(t, "RS256", "rsa-1", rsaKey, with(validClaims(), "exp", nil)),
+			expectedErr: auth.ErrInvalidCredentials,
+		},
+		{
+			name:        "not valid yet",
+			header:      "Bearer " + signJWT(t, "RS256", "rsa-1", rsaKey, with(validClaims(), "nbf", time.Now().Add(time.Hour).Unix())),
+			expectedErr: auth.ErrInvalidCredentials,
+		},
+		{
+			name:        "wrong audience",
+			header:      "Bearer " + signJWT(t, "RS256", "rsa-1", rsaKey, with(validClaims(), "aud", "other")),
+			expectedErr: auth.ErrInvalidCredentials,
+		},
+		{
+			name:        "unknown kid",
+			header:      "Bearer " + signJWT(t, "ES256", "ec-2", ecKey, validClaims()),
+			expectedErr: auth.ErrInvalidCredentials,
+		},
+		{
+			name:        "foreign key",
+			header:      "Bearer " + signJWT(t, "ES256", "ec-1", foreignKey, validClaims()),
+			expectedErr: auth.ErrInvalidCredentials,
+		},
+		{
+			name:        "alg mismatch",
+			header:      "Bearer " + signJWT(t, "ES256", "rsa-1", rsaKey, validClaims()),
+			expectedErr: auth.ErrInvalidCredentials,
+		},
+		{
+			name:        "alg none",
+			header:      "Bearer " + unsignedJWT(t, validClaims()),
+			expectedErr: auth.ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/wallets/w-1", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			p, err := authenticator.Authenticate(req)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "client-1", p.ID)
+			assert.Equal(t, tt.expectedOwner, p.OwnerID)
+			assert.Equal(t, tt.expectedScopes, p.Scopes)
+		})
+	}
+}
+
+func unsignedJWT(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "none"})
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	return b64(header) + "." + b64(payload) + "."
+}
+
+func TestJWTAuthenticatorRejectsInvalidJWKS(t *testing.T) {
+	dir := t.TempDir()
+
+	missing := filepath.Join(dir, "missing.json")
+	_, err := auth.NewJWTAuthenticator(missing, testAudience, "", slog.Default())
+	assert.Error(t, err)
+
+	empty := filepath.Join(dir, "empty.json")
+	writeJWKS(t, empty)
+	_, err = auth.NewJWTAuthenticator(empty, testAudience, "", slog.Default())
+	assert.Error(t, err)
+
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	short := filepath.Join(dir, "short.json")
+	writeJWKS(t, short, rsaJWK("weak", &weak.PublicKey))
+	_, err = auth.NewJWTAuthenticator(short, testAudience, "", slog.Default())
+	assert.Error(t, err)
+}
+
+func TestJWTAuthenticatorReloadsJWKS(t *testing.T) {
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, ecJWK("old", &oldKey.PublicKey))
+	authenticator, err := auth.NewJWTAuthenticator(path, testAudience, "", slog.Default())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- authenticator.Watch(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	authenticate := func(kid string, key *ecdsa.PrivateKey) error {
+		req := httptest.NewRequest("GET", "/api/v1/wallets/w-1", nil)
+		req.Header.Set("Authorization", "Bearer "+signJWT(t, "ES256", kid, key, validClaims()))
+		_, err := authenticator.Authenticate(req)
+		return err
+	}
+	require.NoError(t, authenticate("old", oldKey))
+
+	// ротация ключа: старый удален из набора
+	writeJWKS(t, path, ecJWK("new", &newKey.PublicKey))
+	require.Eventually(t, func() bool {
+		return authenticate("new", newKey) == nil
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.ErrorIs(t, authenticate("old", oldKey), auth.ErrInvalidCredentials)
+
+	// испорченный файл не сбрасывает действующие ключи
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o600))
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, authenticate("new", newKey))
+}
+
+func TestJWTAuthenticatorRequiresTenant(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, ecJWK("ec-1", &key.PublicKey))
+	authenticator, err := auth.NewJWTAuthenticator(path, testAudience, "", slog.Default())
+	require.NoError(t, err)
+
+	authenticate := func(claims map[string]any) (auth.Principal, error) {
+		req := httptest.NewRequest("GET", "/api/v1/wallets/w-1", nil)
+		req.Header.Set("Authorization", "Bearer "+signJWT(t, "ES256", "ec-1", key, claims))
+		return authenticator.Authenticate(req)
+	}
+
+	_, err = authenticate(validClaims())
+	require.NoError(t, err)
+
+	// при заданных арендаторах токен без tenant_id стал бы клиентом платформы
+	authenticator.SetRequireTenant(true)
+	_, err = authenticate(validClaims())
+	assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	_, err = authenticate(with(validClaims(), "scope", "admin"))
+	assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+
+	p, err := authenticate(with(validClaims(), "tenant_id", "brand-a"))
+	require.NoError(t, err)
+	assert.Equal(t, "brand-a", p.TenantID)
+}
@@ -0,0 +1,115 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/glekoz/test_itk/internal/cache"
+	"github.com/glekoz/test_itk/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Lookup(t *testing.T) {
+	t.Run("fresh balance", func(t *testing.T) {
+		c, err := cache.New(30, 0)
+		require.NoError(t, err)
+		require.NoError(t, c.Add("default", "w-1", 10))
+
+		entry, ok := c.Lookup("default", "w-1")
+		require.True(t, ok)
+		assert.Equal(t, cache.Entry{Balance: 10}, entry)
+	})
+
+	t.Run("missing wallet is remembered", func(t *testing.T) {
+		negative := metrics.CacheRequests.WithLabelValues(metrics.CacheNegativeHit)
+		before := testutil.ToFloat64(negative)
+		c, err := cache.New(30, 0)
+		require.NoError(t, err)
+		c.SetNegativeTTL(5)
+		require.NoError(t, c.AddNotFound("default", "missing", c.Generation("default", "missing")))
+
+		entry, ok := c.Lookup("default", "missing")
+		require.True(t, ok)
+		assert.True(t, entry.NotFound)
+		_, ok = c.Get("default", "missing")
+		assert.False(t, ok, "Get returns only balances")
+		assert.Equal(t, before+2, testutil.ToFloat64(negative))
+	})
+
+	t.Run("negative caching is off by default", func(t *testing.T) {
+		c, err := cache.New(30, 0)
+		require.NoError(t, err)
+		require.NoError(t, c.AddNotFound("default", "missing", c.Generation("default", "missing")))
+
+		_, ok := c.Lookup("default", "missing")
+		assert.False(t, ok)
+	})
+
+	t.Run("flush drops all entries", func(t *testing.T) {
+		c, err := cache.New(30, 0)
+		require.NoError(t, err)
+		c.SetNegativeTTL(5)
+		require.NoError(t, c.Add("default", "w-1", 10))
+		require.NoError(t, c.AddNotFound("default", "missing", c.Generation("default", "missing")))
+
+		require.NoError(t, c.Flush(0))
+		_, ok := c.Lookup("default", "w-1")
+		assert.False(t, ok)
+		_, ok = c.Lookup("default", "missing")
+		assert.False(t, ok)
+	})
+}
+
+func TestCache_Fill(t *testing.T) {
+	tests := []struct {
+		name    string
+		between func(c *cache.Cache)
+		cached  bool
+	}{
+		{name: "unchanged entry is filled", between: func(c *cache.Cache) {}, cached: true},
+		// уведомление об изменении пришло, пока баланс читался из базы
+		{name: "deleted during read", between: func(c *cache.Cache) { c.Delete("default", "w-1") }},
+		{name: "flushed during read", between: func(c *cache.Cache) { require.NoError(t, c.Flush(0)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := cache.New(30, 0)
+			require.NoError(t, err)
+
+			gen := c.Generation("default", "w-1")
+			tt.between(c)
+			require.NoError(t, c.Fill("default", "w-1", 10, gen))
+
+			_, ok := c.Lookup("default", "w-1")
+			assert.Equal(t, tt.cached, ok)
+		})
+	}
+
+	t.Run("write during read wins", func(t *testing.T) {
+		c, err := cache.New(30, 0)
+		require.NoError(t, err)
+
+		gen := c.Generation("default", "w-1")
+		require.NoError(t, c.Add("default", "w-1", 150))
+		require.NoError(t, c.Fill("default", "w-1", 100, gen))
+
+		balance, ok := c.Get("default", "w-1")
+		require.True(t, ok)
+		assert.Equal(t, 150, balance)
+	})
+
+	t.Run("wallet created during read is not remembered as missing", func(t *testing.T) {
+		c, err := cache.New(30, 0)
+		require.NoError(t, err)
+		c.SetNegativeTTL(5)
+
+		gen := c.Generation("default", "w-1")
+		c.Delete("default", "w-1")
+		require.NoError(t, c.AddNotFound("default", "w-1", gen))
+
+		_, ok := c.Lookup("default", "w-1")
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,127 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/cache"
+	"github.com/glekoz/test_itk/internal/metrics"
+	"github.com/glekoz/test_itk/internal/shared/consistency"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// notification - уведомление об изменении кошелька на позиции WAL lsn
+type notification struct {
+	tenantID, walletID string
+	lsn                consistency.LSN
+}
+
+// session - одно подключение заглушки: подписывается на позиции lsn, доставляет уведомления из notify
+// и обрывается, когда закрыт drop. Пока тест не передал следующую сессию, заглушка не подключена
+type session struct {
+	lsn    consistency.LSN
+	notify chan notification
+	drop   chan struct{}
+}
+
+type fakeListener struct {
+	sessions chan *session
+	calls    sync.WaitGroup
+}
+
+func (l *fakeListener) ListenWalletChanges(ctx context.Context, ready func(lsn consistency.LSN), handle func(tenantID, walletID string, lsn consistency.LSN)) error {
+	var s *session
+	select {
+	case s = <-l.sessions:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	ready(s.lsn)
+	for {
+		select {
+		case n := <-s.notify:
+			handle(n.tenantID, n.walletID, n.lsn)
+			l.calls.Done()
+		case <-s.drop:
+			return errors.New("connection reset")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func newSession(lsn consistency.LSN) *session {
+	return &session{lsn: lsn, notify: make(chan notification), drop: make(chan struct{})}
+}
+
+func TestInvalidator(t *testing.T) {
+	balances, err := cache.New(30, 0)
+	require.NoError(t, err)
+	listener := &fakeListener{sessions: make(chan *session)}
+	invalidator := cache.NewInvalidator(balances, listener, time.Millisecond, slog.Default())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		invalidator.Run(ctx)
+		close(done)
+	}()
+
+	require.Error(t, invalidator.Check(ctx), "not connected before the first subscription")
+
+	first := newSession(100)
+	listener.sessions <- first
+	require.Eventually(t, func() bool { return invalidator.Check(ctx) == nil }, time.Second, time.Millisecond)
+
+	t.Run("notification evicts only the changed wallet", func(t *testing.T) {
+		require.NoError(t, balances.Add("default", "w-1", 100))
+		require.NoError(t, balances.Add("default", "w-2", 200))
+		require.NoError(t, balances.Add("acme", "w-1", 300))
+
+		listener.calls.Add(1)
+		first.notify <- notification{"default", "w-1", 150}
+		listener.calls.Wait()
+
+		_, ok := balances.Get("default", "w-1")
+		assert.False(t, ok)
+		balance, ok := balances.Get("default", "w-2")
+		assert.True(t, ok)
+		assert.Equal(t, 200, balance)
+		_, ok = balances.Get("acme", "w-1")
+		assert.True(t, ok, "same wallet id of another tenant stays cached")
+
+		// перечитать вытесненный баланс можно только с реплики, дошедшей до изменения
+		assert.Equal(t, consistency.LSN(150), balances.FillLSN("default", "w-1"))
+	})
+
+	t.Run("reconnect flushes the whole cache", func(t *testing.T) {
+		flushes := testutil.ToFloat64(metrics.CacheFlushes)
+		require.NoError(t, balances.Add("default", "w-2", 250))
+
+		close(first.drop)
+		require.Eventually(t, func() bool { return invalidator.Check(ctx) != nil }, time.Second, time.Millisecond)
+
+		listener.sessions <- newSession(200)
+		require.Eventually(t, func() bool { return invalidator.Check(ctx) == nil }, time.Second, time.Millisecond)
+
+		_, ok := balances.Get("default", "w-2")
+		assert.False(t, ok, "changes missed while disconnected must not be served from cache")
+		assert.Equal(t, flushes+1, testutil.ToFloat64(metrics.CacheFlushes))
+		// пропущенные изменения могли коснуться любого кошелька
+		assert.Equal(t, consistency.LSN(200), balances.FillLSN("acme", "w-3"))
+	})
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("invalidator did not stop after cancel")
+	}
+	assert.Error(t, invalidator.Check(context.Background()))
+}
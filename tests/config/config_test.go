@@ -0,0 +1,112 @@
+package config_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/glekoz/test_itk/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.env")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Layers(t *testing.T) {
+	path := writeConfig(t, "CACHE_TTL=40\nLOG_LEVEL=warn\nHTTP_READ_TIMEOUT=7\nDB_MAX_CONNS=20\n")
+	t.Setenv("LOG_LEVEL", "error")
+	t.Setenv("HTTP_READ_TIMEOUT", "8")
+
+	fs := flag.NewFlagSet("itkapp", flag.ContinueOnError)
+	flags := config.RegisterFlags(fs)
+	require.NoError(t, fs.Parse([]string{"--config", path, "--http-read-timeout", "9", "serve"}))
+	assert.Equal(t, []string{"serve"}, fs.Args())
+
+	cfg, err := flags.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 10, cfg.HTTPWriteTimeout, "default")
+	assert.Equal(t, 40, cfg.CacheTTL, "file over default")
+	assert.Equal(t, 20, cfg.DBMaxConns, "file over default")
+	assert.Equal(t, "error", cfg.LogLevel, "env over file")
+	assert.Equal(t, 9, cfg.HTTPReadTimeout, "flag over env")
+}
+
+func TestLoad_PathFromEnv(t *testing.T) {
+	t.Setenv(config.PathEnv, writeConfig(t, "CACHE_SIZE=64\n"))
+
+	cfg, err := config.Load("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 64, cfg.CacheSize)
+}
+
+func TestLoad_MissingExplicitFile(t *testing.T) {
+	_, err := config.Load(filepath.Join(t.TempDir(), "missing.env"), nil)
+	assert.Error(t, err)
+}
+
+func TestLoad_ReportsAllErrors(t *testing.T) {
+	path := writeConfig(t, "CACHE_TTL=0\nAUTH_MODE=jwt\n")
+
+	_, err := config.Load(path, map[string]string{
+		"DB_MIN_CONNS":             "10",
+		"DB_MAX_CONNS":             "5",
+		"HTTP_IDLE_TIMEOUT":        "0",
+		"RATE_LIMIT_IP_RATE":       "5",
+		"RATE_LIMIT_IP_BURST":      "0",
+		"TRACING_EXPORTER":         "zipkin",
+		"OUTBOX_PUBLISHER":         "file",
+		"SHUTDOWN_READINESS_DELAY": "-1",
+		"DB_CONNECT_ATTEMPTS":      "0",
+		"DB_LOCK_TIMEOUT":          "-1",
+	})
+	require.Error(t, err)
+
+	var keys []string
+	for _, line := range strings.Split(err.Error(), "\n")[1:] {
+		key, _, _ := strings.Cut(line, ":")
+		keys = append(keys, key)
+	}
+	assert.ElementsMatch(t, []string{
+		"CACHE_TTL",
+		"JWT_JWKS_PATH",
+		"JWT_AUDIENCE",
+		"DB_MIN_CONNS",
+		"HTTP_IDLE_TIMEOUT",
+		"RATE_LIMIT_IP_BURST",
+		"TRACING_EXPORTER",
+		"OUTBOX_FILE_PATH",
+		"SHUTDOWN_READINESS_DELAY",
+		"DB_CONNECT_ATTEMPTS",
+		"DB_LOCK_TIMEOUT",
+	}, keys)
+}
+
+func TestSettings_RedactsSecrets(t *testing.T) {
+	for name, tc := range map[string]struct {
+		dsn  string
+		want string
+	}{
+		"url":      {"postgresql://app:s3cret@db:5432/itkapp?sslmode=disable", "postgresql://app:xxxxx@db:5432/itkapp?sslmode=disable"},
+		"keywords": {"host=db user=app password=s3cret", "[REDACTED]"},
+		"empty":    {"", ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := config.Load(writeConfig(t, ""), map[string]string{"DATABASE_URL": tc.dsn})
+			require.NoError(t, err)
+
+			settings := cfg.Settings()
+			require.NotEmpty(t, settings)
+			assert.Equal(t, config.Setting{Key: "DATABASE_URL", Value: tc.want}, settings[0])
+			for _, s := range settings {
+				assert.NotContains(t, s.Value, "s3cret")
+			}
+		})
+	}
+}
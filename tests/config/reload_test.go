@@ -0,0 +1,81 @@
+package config_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/glekoz/test_itk/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloader(t *testing.T) {
+	path := writeConfig(t, "CACHE_TTL=30\nRATE_LIMIT_IP_RATE=5\nRATE_LIMIT_IP_BURST=10\n")
+	initial, err := config.Load(path, nil)
+	require.NoError(t, err)
+
+	var applied []*config.Config
+	var applyErr error
+	reloader := config.NewReloader(initial, func() (*config.Config, error) {
+		return config.Load(path, nil)
+	}, func(c *config.Config) error {
+		if applyErr != nil {
+			return applyErr
+		}
+		applied = append(applied, c)
+		return nil
+	}, slog.Default())
+	rewrite := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	t.Run("safe keys are applied", func(t *testing.T) {
+		rewrite("CACHE_TTL=60\nLOG_LEVEL=debug\nRATE_LIMIT_IP_RATE=5\nRATE_LIMIT_IP_BURST=10\n")
+
+		keys, err := reloader.Reload(context.Background())
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"CACHE_TTL", "LOG_LEVEL"}, keys)
+		require.Len(t, applied, 1)
+		assert.Equal(t, 60, applied[0].CacheTTL)
+		assert.Same(t, applied[0], reloader.Current())
+	})
+
+	t.Run("keys requiring restart are ignored", func(t *testing.T) {
+		rewrite("CACHE_TTL=90\nLOG_LEVEL=debug\nRATE_LIMIT_IP_RATE=5\nRATE_LIMIT_IP_BURST=10\nITKAPP_PORT=9090\nDB_MAX_CONNS=50\n")
+
+		keys, err := reloader.Reload(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"CACHE_TTL"}, keys)
+		assert.Equal(t, 90, reloader.Current().CacheTTL)
+		assert.Equal(t, "8080", reloader.Current().Port)
+		assert.Equal(t, 0, reloader.Current().DBMaxConns)
+	})
+
+	t.Run("invalid config is rejected", func(t *testing.T) {
+		before := reloader.Current()
+		calls := len(applied)
+		rewrite("CACHE_TTL=0\nRATE_LIMIT_IP_RATE=5\nRATE_LIMIT_IP_BURST=0\n")
+
+		_, err := reloader.Reload(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CACHE_TTL")
+		assert.Contains(t, err.Error(), "RATE_LIMIT_IP_BURST")
+		assert.Len(t, applied, calls)
+		assert.Same(t, before, reloader.Current())
+	})
+
+	t.Run("failed apply keeps previous config", func(t *testing.T) {
+		before := reloader.Current()
+		rewrite("CACHE_TTL=120\nRATE_LIMIT_IP_RATE=5\nRATE_LIMIT_IP_BURST=10\n")
+		applyErr = errors.New("tenants file is broken")
+		defer func() { applyErr = nil }()
+
+		_, err := reloader.Reload(context.Background())
+		require.ErrorIs(t, err, applyErr)
+		assert.Same(t, before, reloader.Current())
+		assert.Equal(t, 90, reloader.Current().CacheTTL)
+	})
+}
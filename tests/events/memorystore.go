@@ -0,0 +1,160 @@
+package events_test
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/events"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+)
+
+type storedEvent struct {
+	sequence  int64
+	eventType events.Type
+	version   int
+	payload   []byte
+	createdAt time.Time
+}
+
+// MemoryStore ведет себя как repository: проекция обновляется инкрементально
+// в момент записи, а события хранятся в сериализованном виде
+type MemoryStore struct {
+	streams     map[string][]storedEvent
+	projections map[string]events.Wallet
+}
+
+func newMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		streams:     make(map[string][]storedEvent),
+		projections: make(map[string]events.Wallet),
+	}
+}
+
+// append - аналог первичного ключа (wallet_id, sequence) в wallet_events
+func (s *MemoryStore) append(walletID string, sequence int64, e events.Event) error {
+	if int64(len(s.streams[walletID])) != sequence-1 {
+		return myerrors.ErrConflict
+	}
+	payload, err := events.Encode(e)
+	if err != nil {
+		return err
+	}
+	s.streams[walletID] = append(s.streams[walletID], storedEvent{
+		sequence:  sequence,
+		eventType: e.Type(),
+		version:   e.Version(),
+		payload:   payload,
+		createdAt: time.Now().UTC(),
+	})
+	return nil
+}
+
+func (s *MemoryStore) CreateWallet(id, ownerID string) error {
+	if _, ok := s.projections[id]; ok {
+		return myerrors.ErrAlreadyExists
+	}
+	if err := s.append(id, 1, events.WalletCreated{OwnerID: ownerID}); err != nil {
+		return err
+	}
+	s.projections[id] = events.Wallet{ID: id, OwnerID: ownerID, Status: myvars.WalletStatusActive, Sequence: 1, CreatedAt: s.streams[id][0].createdAt}
+	return nil
+}
+
+func (s *MemoryStore) Deposit(id, transactionID string, amount int) error {
+	w, ok := s.projections[id]
+	if !ok {
+		return myerrors.ErrNotFound
+	}
+	w.Balance += amount
+	w.Sequence++
+	if err := s.append(id, w.Sequence, events.Deposited{TransactionID: transactionID, Amount: amount}); err != nil {
+		return err
+	}
+	s.projections[id] = w
+	return nil
+}
+
+func (s *MemoryStore) Withdraw(id, transactionID string, amount int) error {
+	w, ok := s.projections[id]
+	if !ok {
+		return myerrors.ErrNotFound
+	}
+	if w.Balance < amount {
+		return myerrors.ErrNegativeAmount
+	}
+	w.Balance -= amount
+	w.Sequence++
+	if err := s.append(id, w.Sequence, events.Withdrawn{TransactionID: transactionID, Amount: amount}); err != nil {
+		return err
+	}
+	s.projections[id] = w
+	return nil
+}
+
+func (s *MemoryStore) SetStatus(id string, status myvars.WalletStatus) error {
+	w, ok := s.projections[id]
+	if !ok {
+		return myerrors.ErrNotFound
+	}
+	w.Status = status
+	w.Sequence++
+	if err := s.append(id, w.Sequence, events.StatusChanged{Status: status}); err != nil {
+		return err
+	}
+	s.projections[id] = w
+	return nil
+}
+
+func (s *MemoryStore) ListEventStreamIDs(ctx context.Context) ([]string, error) {
+	ids := make([]string, 0, len(s.streams))
+	for id := range s.streams {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// records десериализует поток так же, как repository читает wallet_events
+func (s *MemoryStore) records(walletID string) ([]events.Record, error) {
+	records := make([]events.Record, 0, len(s.streams[walletID]))
+	for _, se := range s.streams[walletID] {
+		e, err := events.Decode(se.eventType, se.version, se.payload)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, events.Record{
+			WalletID:   walletID,
+			Sequence:   se.sequence,
+			Event:      e,
+			OccurredAt: se.createdAt,
+		})
+	}
+	return records, nil
+}
+
+func (s *MemoryStore) LoadWalletState(ctx context.Context, walletID string) ([]events.Record, events.Wallet, error) {
+	records, err := s.records(walletID)
+	if err != nil {
+		return nil, events.Wallet{}, err
+	}
+	w, ok := s.projections[walletID]
+	if !ok {
+		return records, events.Wallet{}, myerrors.ErrNotFound
+	}
+	return records, w, nil
+}
+
+func (s *MemoryStore) RebuildWalletProjection(ctx context.Context, walletID string, replay func(records []events.Record) (events.Wallet, error)) error {
+	records, err := s.records(walletID)
+	if err != nil {
+		return err
+	}
+	w, err := replay(records)
+	if err != nil {
+		return err
+	}
+	s.projections[walletID] = w
+	return nil
+}
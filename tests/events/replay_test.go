@@ -0,0 +1,227 @@
+package events_test
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/glekoz/test_itk/internal/events"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebuildProjections_ReplayEqualsLiveState(t *testing.T) {
+	store := newMemoryStore()
+	rnd := rand.New(rand.NewPCG(1, 2))
+
+	ids := []string{"w1", "w2", "w3", "w4"}
+	for i, id := range ids {
+		// часть кошельков без владельца, как созданные ключом платформы
+		owner := ""
+		if i%2 == 0 {
+			owner = "owner-" + id
+		}
+		require.NoError(t, store.CreateWallet(id, owner))
+	}
+	for i := range 500 {
+		id := ids[rnd.IntN(len(ids))]
+		amount := 1 + rnd.IntN(1000)
+		txID := fmt.Sprintf("tx-%d", i)
+		if rnd.IntN(3) == 0 {
+			err := store.Withdraw(id, txID, amount)
+			if err != nil {
+				require.ErrorIs(t, err, myerrors.ErrNegativeAmount)
+			}
+			continue
+		}
+		require.NoError(t, store.Deposit(id, txID, amount))
+	}
+	require.NoError(t, store.SetStatus("w2", myvars.WalletStatusFrozen))
+
+	live := maps.Clone(store.projections)
+
+	// проекцию стираем полностью, чтобы убедиться, что она восстанавливается только из событий
+	clear(store.projections)
+	n, err := events.RebuildProjections(context.Background(), store)
+	require.NoError(t, err)
+	assert.Equal(t, len(ids), n)
+	assert.Equal(t, live, store.projections)
+	assert.Equal(t, "owner-w1", store.projections["w1"].OwnerID)
+	assert.Equal(t, myvars.WalletStatusFrozen, store.projections["w2"].Status)
+}
+
+func TestReconcile(t *testing.T) {
+	store := newMemoryStore()
+	for _, id := range []string{"w1", "w2", "w3", "w4", "w5"} {
+		require.NoError(t, store.CreateWallet(id, ""))
+		require.NoError(t, store.Deposit(id, "tx-"+id, 100))
+	}
+
+	n, mismatches, err := events.Reconcile(context.Background(), store)
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Empty(t, mismatches)
+
+	// проекцию правят в обход событий
+	w := store.projections["w2"]
+	w.Balance = 1000
+	store.projections["w2"] = w
+	w = store.projections["w3"]
+	w.Sequence = 7
+	store.projections["w3"] = w
+	delete(store.projections, "w4")
+	store.streams["w5"] = store.streams["w5"][1:]
+
+	n, mismatches, err = events.Reconcile(context.Background(), store)
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	require.Len(t, mismatches, 4)
+	assert.Equal(t, "w2", mismatches[0].WalletID)
+	assert.Equal(t, events.MismatchBalance, mismatches[0].Reason)
+	assert.Equal(t, 1000, mismatches[0].Projection.Balance)
+	assert.Equal(t, 100, mismatches[0].Replayed.Balance)
+	assert.Equal(t, events.MismatchVersion, mismatches[1].Reason)
+	assert.Equal(t, events.MismatchMissingProjection, mismatches[2].Reason)
+	assert.Equal(t, events.MismatchReplayFailed, mismatches[3].Reason)
+	assert.NotEmpty(t, mismatches[3].Error)
+
+	// сверка ничего не исправляет
+	assert.Equal(t, 1000, store.projections["w2"].Balance)
+}
+
+func TestMemoryStore_OptimisticConcurrency(t *testing.T) {
+	store := newMemoryStore()
+	require.NoError(t, store.CreateWallet("w1", ""))
+
+	err := store.append("w1", 1, events.Deposited{TransactionID: "tx", Amount: 10})
+	assert.ErrorIs(t, err, myerrors.ErrConflict)
+
+	err = store.append("w1", 3, events.Deposited{TransactionID: "tx", Amount: 10})
+	assert.ErrorIs(t, err, myerrors.ErrConflict)
+}
+
+func TestWallet_Apply(t *testing.T) {
+	tests := []struct {
+		name          string
+		records       []events.Record
+		expected      events.Wallet
+		expectedError error
+	}{
+		{
+			name: "deposit and withdraw",
+			records: []events.Record{
+				{WalletID: "w1", Sequence: 1, Event: events.WalletCreated{}},
+				{WalletID: "w1", Sequence: 2, Event: events.Deposited{TransactionID: "t1", Amount: 100}},
+				{WalletID: "w1", Sequence: 3, Event: events.Withdrawn{TransactionID: "t2", Amount: 30}},
+			},
+			expected: events.Wallet{ID: "w1", Balance: 70, Status: myvars.WalletStatusActive, Sequence: 3},
+		},
+		{
+			name: "adjustments",
+			records: []events.Record{
+				{WalletID: "w1", Sequence: 1, Event: events.WalletCreated{}},
+				{WalletID: "w1", Sequence: 2, Event: events.Adjusted{TransactionID: "t1", Amount: 100, Reason: "refund"}},
+				{WalletID: "w1", Sequence: 3, Event: events.Adjusted{TransactionID: "t2", Amount: -40, Reason: "chargeback"}},
+			},
+			expected: events.Wallet{ID: "w1", Balance: 60, Status: myvars.WalletStatusActive, Sequence: 3},
+		},
+		{
+			name: "status changes",
+			records: []events.Record{
+				{WalletID: "w1", Sequence: 1, Event: events.WalletCreated{}},
+				{WalletID: "w1", Sequence: 2, Event: events.StatusChanged{Status: myvars.WalletStatusFrozen, Reason: "fraud check"}},
+				{WalletID: "w1", Sequence: 3, Event: events.Adjusted{TransactionID: "t1", Amount: 10, Reason: "refund"}},
+			},
+			expected: events.Wallet{ID: "w1", Balance: 10, Status: myvars.WalletStatusFrozen, Sequence: 3},
+		},
+		{
+			name: "negative adjustment below zero",
+			records: []events.Record{
+				{WalletID: "w1", Sequence: 1, Event: events.WalletCreated{}},
+				{WalletID: "w1", Sequence: 2, Event: events.Adjusted{TransactionID: "t1", Amount: -1, Reason: "fix"}},
+			},
+			expectedError: myerrors.ErrNegativeAmount,
+		},
+		{
+			name: "sequence gap",
+			records: []events.Record{
+				{WalletID: "w1", Sequence: 1, Event: events.WalletCreated{}},
+				{WalletID: "w1", Sequence: 3, Event: events.Deposited{TransactionID: "t1", Amount: 100}},
+			},
+			expectedError: events.ErrSequenceGap,
+		},
+		{
+			name: "overdraft",
+			records: []events.Record{
+				{WalletID: "w1", Sequence: 1, Event: events.WalletCreated{}},
+				{WalletID: "w1", Sequence: 2, Event: events.Withdrawn{TransactionID: "t1", Amount: 1}},
+			},
+			expectedError: myerrors.ErrNegativeAmount,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := events.Replay("w1", tt.records)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, w)
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	payload, err := events.Encode(events.Deposited{TransactionID: "t1", Amount: 42})
+	require.NoError(t, err)
+
+	e, err := events.Decode(events.TypeDeposited, 1, payload)
+	require.NoError(t, err)
+	assert.Equal(t, events.Deposited{TransactionID: "t1", Amount: 42}, e)
+
+	_, err = events.Decode(events.TypeDeposited, 2, payload)
+	assert.Error(t, err)
+
+	_, err = events.Decode("Unknown", 1, payload)
+	assert.Error(t, err)
+}
+
+func TestDecode_WalletCreated(t *testing.T) {
+	payload, err := events.Encode(events.WalletCreated{OwnerID: "u1"})
+	require.NoError(t, err)
+
+	e, err := events.Decode(events.TypeWalletCreated, 2, payload)
+	require.NoError(t, err)
+	assert.Equal(t, events.WalletCreated{OwnerID: "u1"}, e)
+
+	// события, записанные до появления владельца
+	e, err = events.Decode(events.TypeWalletCreated, 1, []byte(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, events.WalletCreated{}, e)
+}
+
+func TestDecode_Adjusted(t *testing.T) {
+	adjusted := events.Adjusted{TransactionID: "t1", Amount: -42, Reason: "duplicate deposit"}
+	payload, err := events.Encode(adjusted)
+	require.NoError(t, err)
+
+	e, err := events.Decode(events.TypeAdjusted, 1, payload)
+	require.NoError(t, err)
+	assert.Equal(t, adjusted, e)
+}
+
+func TestDecode_StatusChanged(t *testing.T) {
+	changed := events.StatusChanged{Status: myvars.WalletStatusFrozen, Reason: "fraud check"}
+	payload, err := events.Encode(changed)
+	require.NoError(t, err)
+
+	e, err := events.Decode(events.TypeStatusChanged, 1, payload)
+	require.NoError(t, err)
+	assert.Equal(t, changed, e)
+}
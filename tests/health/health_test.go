@@ -0,0 +1,85 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func ok(ctx context.Context) error { return nil }
+
+func failing(ctx context.Context) error { return errors.New("connection refused") }
+
+func TestRegistry_Run(t *testing.T) {
+	tests := []struct {
+		name           string
+		register       func(r *health.Registry)
+		expectedStatus string
+	}{
+		{
+			name:           "no checks",
+			register:       func(r *health.Registry) {},
+			expectedStatus: health.StatusOK,
+		},
+		{
+			name: "all checks pass",
+			register: func(r *health.Registry) {
+				r.Register("postgres", true, ok)
+				r.Register("cache", false, ok)
+			},
+			expectedStatus: health.StatusOK,
+		},
+		{
+			name: "non-critical check fails",
+			register: func(r *health.Registry) {
+				r.Register("postgres", true, ok)
+				r.Register("cache", false, failing)
+			},
+			expectedStatus: health.StatusDegraded,
+		},
+		{
+			name: "critical check fails",
+			register: func(r *health.Registry) {
+				r.Register("postgres", true, failing)
+				r.Register("cache", false, failing)
+			},
+			expectedStatus: health.StatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := health.NewRegistry(time.Second)
+			tt.register(r)
+
+			report := r.Run(context.Background())
+
+			assert.Equal(t, tt.expectedStatus, report.Status)
+			for name, res := range report.Checks {
+				if res.Status == health.StatusFailed {
+					assert.NotEmpty(t, res.Error, name)
+				}
+				assert.GreaterOrEqual(t, res.LatencyMs, 0.0, name)
+			}
+		})
+	}
+}
+
+func TestRegistry_CheckTimeout(t *testing.T) {
+	r := health.NewRegistry(50 * time.Millisecond)
+	r.Register("slow", true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	report := r.Run(context.Background())
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, health.StatusFailed, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+}
@@ -0,0 +1,144 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/glekoz/test_itk/internal/cache"
+	"github.com/glekoz/test_itk/internal/metrics"
+	"github.com/glekoz/test_itk/internal/service"
+	"github.com/glekoz/test_itk/internal/shared/consistency"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+	"github.com/glekoz/test_itk/internal/web/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubService отвечает на GetBalance, остальные методы в этих тестах не вызываются
+type stubService struct {
+	web.ServiceAPI
+}
+
+func (stubService) GetBalance(ctx context.Context, walletID string) (int, error) {
+	if walletID == "missing" {
+		return 0, myerrors.ErrNotFound
+	}
+	if walletID == "panic" {
+		panic("nil map write")
+	}
+	return 100, nil
+}
+
+// stubRepo возвращает ошибку withdrawErr из Withdraw, остальные методы не вызываются
+type stubRepo struct {
+	service.RepoAPI
+	withdrawErr error
+}
+
+func (r stubRepo) Withdraw(ctx context.Context, walletID, transactionID string, amount int, operationType myvars.OperationType) (int, error) {
+	return 0, r.withdrawErr
+}
+
+type noopCache struct{}
+
+func (noopCache) Add(tenantID, walletID string, balance int) error { return nil }
+func (noopCache) Generation(tenantID, walletID string) uint64      { return 0 }
+func (noopCache) FillLSN(tenantID, walletID string) consistency.LSN {
+	return 0
+}
+func (noopCache) Fill(tenantID, walletID string, balance int, gen uint64) error {
+	return nil
+}
+func (noopCache) AddNotFound(tenantID, walletID string, gen uint64) error { return nil }
+func (noopCache) Lookup(tenantID, walletID string) (cache.Entry, bool) {
+	return cache.Entry{}, false
+}
+func (noopCache) Delete(tenantID, walletID string) {}
+
+func TestHTTPMetrics(t *testing.T) {
+	server := web.New(stubService{}, "test-host", slog.Default())
+	handler := server.Routes()
+
+	ok := metrics.HTTPRequests.WithLabelValues("GET /api/v1/wallets/{wallet_uuid}", "GET", "200")
+	notFound := metrics.HTTPRequests.WithLabelValues("GET /api/v1/wallets/{wallet_uuid}", "GET", "404")
+	okBefore, notFoundBefore := testutil.ToFloat64(ok), testutil.ToFloat64(notFound)
+
+	for _, id := range []string{"w1", "w2", "missing"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/wallets/"+id, nil))
+	}
+
+	assert.Equal(t, okBefore+2, testutil.ToFloat64(ok))
+	assert.Equal(t, notFoundBefore+1, testutil.ToFloat64(notFound))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	body, _ := io.ReadAll(w.Body)
+	assert.True(t, strings.Contains(string(body), `itkapp_http_request_duration_seconds_bucket{method="GET",route="GET /api/v1/wallets/{wallet_uuid}",status="200"`))
+}
+
+func TestHTTPMetrics_Panic(t *testing.T) {
+	server := web.New(stubService{}, "test-host", slog.Default())
+
+	failed := metrics.HTTPRequests.WithLabelValues("GET /api/v1/wallets/{wallet_uuid}", "GET", "500")
+	before := testutil.ToFloat64(failed)
+
+	w := httptest.NewRecorder()
+	server.Routes().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/wallets/panic", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, before+1, testutil.ToFloat64(failed))
+}
+
+func TestWalletOperationMetrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		outcome string
+	}{
+		{name: "success", outcome: metrics.OutcomeSuccess},
+		{name: "insufficient funds", err: myerrors.ErrNegativeAmount, outcome: metrics.OutcomeInsufficientFunds},
+		{name: "not found", err: myerrors.ErrNotFound, outcome: metrics.OutcomeNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count := metrics.WalletOperations.WithLabelValues("withdraw", tt.outcome)
+			amount := metrics.WalletOperationAmount.WithLabelValues("withdraw", tt.outcome)
+			countBefore, amountBefore := testutil.ToFloat64(count), testutil.ToFloat64(amount)
+
+			s := service.New(stubRepo{withdrawErr: tt.err}, noopCache{}, slog.Default())
+			_ = s.Withdraw(context.Background(), "w1", 250)
+
+			assert.Equal(t, countBefore+1, testutil.ToFloat64(count))
+			assert.Equal(t, amountBefore+250, testutil.ToFloat64(amount))
+		})
+	}
+}
+
+func TestCacheMetrics(t *testing.T) {
+	c, err := cache.New(30, 0)
+	require.NoError(t, err)
+
+	hits := metrics.CacheRequests.WithLabelValues(metrics.CacheHit)
+	misses := metrics.CacheRequests.WithLabelValues(metrics.CacheMiss)
+	hitsBefore, missesBefore := testutil.ToFloat64(hits), testutil.ToFloat64(misses)
+	evictionsBefore := testutil.ToFloat64(metrics.CacheEvictions)
+
+	c.Get("default", "w1")
+	require.NoError(t, c.Add("default", "w1", 10))
+	c.Get("default", "w1")
+	c.Delete("default", "w1")
+	c.Get("default", "w1")
+
+	assert.Equal(t, hitsBefore+1, testutil.ToFloat64(hits))
+	assert.Equal(t, missesBefore+2, testutil.ToFloat64(misses))
+	assert.Equal(t, evictionsBefore+1, testutil.ToFloat64(metrics.CacheEvictions))
+}
@@ -0,0 +1,32 @@
+package migrations_test
+
+import (
+	"database/sql"
+	"io/fs"
+	"testing"
+
+	"github.com/glekoz/test_itk/internal/repository/migrations"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEmbeddedMigrations проверяет, что goose видит все встроенные миграции без подключения к базе
+func TestEmbeddedMigrations(t *testing.T) {
+	db, err := sql.Open("pgx", "postgres://localhost:1/unused")
+	require.NoError(t, err)
+	defer db.Close()
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, migrations.FS)
+	require.NoError(t, err)
+
+	files, err := fs.Glob(migrations.FS, "*.sql")
+	require.NoError(t, err)
+	sources := provider.ListSources()
+	require.Len(t, sources, len(files))
+
+	latest, err := migrations.LatestVersion()
+	require.NoError(t, err)
+	assert.Equal(t, latest, sources[len(sources)-1].Version)
+}
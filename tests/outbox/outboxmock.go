@@ -0,0 +1,52 @@
+package outbox_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/shared/models"
+)
+
+// MockRepo повторяет семантику repository.ProcessOutboxEvents на слайсе в памяти
+type MockRepo struct {
+	mu        sync.Mutex
+	events    []models.OutboxEvent
+	processed map[int64]bool
+	deleted   int
+}
+
+func newMockRepo(events ...models.OutboxEvent) *MockRepo {
+	return &MockRepo{
+		events:    events,
+		processed: make(map[int64]bool),
+	}
+}
+
+func (m *MockRepo) ProcessOutboxEvents(ctx context.Context, limit int, handle func(ctx context.Context, event models.OutboxEvent) error) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int
+	for _, e := range m.events {
+		if m.processed[e.ID] {
+			continue
+		}
+		if n == limit {
+			break
+		}
+		if err := handle(ctx, e); err != nil {
+			return n, err
+		}
+		m.processed[e.ID] = true
+		n++
+	}
+	return n, nil
+}
+
+func (m *MockRepo) DeleteProcessedOutboxEvents(ctx context.Context, processedBefore time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleted++
+	return 0, nil
+}
@@ -0,0 +1,104 @@
+package outbox_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/outbox"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyPublisher запоминает порядок публикаций и возвращает ошибку на событии failOn один раз
+type flakyPublisher struct {
+	published []int64
+	failOn    int64
+	failed    bool
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	if event.ID == p.failOn && !p.failed {
+		p.failed = true
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, event.ID)
+	return nil
+}
+
+func testEvents(n int) []models.OutboxEvent {
+	events := make([]models.OutboxEvent, 0, n)
+	for i := 1; i <= n; i++ {
+		events = append(events, models.OutboxEvent{
+			ID:        int64(i),
+			WalletID:  "w1",
+			EventType: "wallet.deposited",
+			Payload:   []byte(`{"amount":100}`),
+			CreatedAt: time.Now(),
+		})
+	}
+	return events
+}
+
+func TestRelay_PublishesInOrderAtLeastOnce(t *testing.T) {
+	repo := newMockRepo(testEvents(5)...)
+	publisher := &flakyPublisher{failOn: 3}
+	relay := outbox.NewRelay(repo, publisher, time.Second, time.Hour, slog.Default())
+
+	relay.Tick(context.Background())
+	assert.Equal(t, []int64{1, 2}, publisher.published, "relay must stop on the first failure")
+
+	relay.Tick(context.Background())
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, publisher.published, "failed event must be retried before later ones")
+	assert.Equal(t, 1, repo.deleted, "cleanup runs at most once per interval")
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	publisher, f, err := outbox.NewFilePublisher(path)
+	require.NoError(t, err)
+
+	for _, e := range testEvents(2) {
+		require.NoError(t, publisher.Publish(context.Background(), e))
+	}
+	require.NoError(t, f.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var ids []int64
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var line struct {
+			ID      int64           `json:"id"`
+			Type    string          `json:"type"`
+			Payload json.RawMessage `json:"payload"`
+		}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		assert.Equal(t, "wallet.deposited", line.Type)
+		assert.JSONEq(t, `{"amount":100}`, string(line.Payload))
+		ids = append(ids, line.ID)
+	}
+	assert.Equal(t, []int64{1, 2}, ids)
+}
+
+func TestMultiPublisher_StopsOnError(t *testing.T) {
+	var buf bytes.Buffer
+	failing := &flakyPublisher{failOn: 1}
+	multi := outbox.MultiPublisher{failing, outbox.NewWriterPublisher(&buf)}
+
+	err := multi.Publish(context.Background(), testEvents(1)[0])
+	require.Error(t, err)
+	assert.Zero(t, buf.Len())
+
+	require.NoError(t, multi.Publish(context.Background(), testEvents(1)[0]))
+	assert.NotZero(t, buf.Len())
+}
@@ -0,0 +1,108 @@
+package ratelimit_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	limit := ratelimit.Limit{Rate: 2, Burst: 4}
+
+	tests := []struct {
+		name           string
+		tokens         float64
+		elapsed        time.Duration
+		expectedTokens float64
+		expected       ratelimit.Result
+	}{
+		{
+			name:           "new bucket",
+			tokens:         4,
+			expectedTokens: 3,
+			expected:       ratelimit.Result{Allowed: true, Limit: 4, Remaining: 3, Reset: 500 * time.Millisecond},
+		},
+		{
+			name:           "refill is capped by burst",
+			tokens:         1,
+			elapsed:        time.Hour,
+			expectedTokens: 3,
+			expected:       ratelimit.Result{Allowed: true, Limit: 4, Remaining: 3, Reset: 500 * time.Millisecond},
+		},
+		{
+			name:           "partial refill",
+			tokens:         0,
+			elapsed:        750 * time.Millisecond,
+			expectedTokens: 0.5,
+			expected:       ratelimit.Result{Allowed: true, Limit: 4, Remaining: 0, Reset: 1750 * time.Millisecond},
+		},
+		{
+			name:           "empty bucket",
+			tokens:         0.5,
+			expectedTokens: 0.5,
+			expected:       ratelimit.Result{Limit: 4, Remaining: 0, Reset: 1750 * time.Millisecond, RetryAfter: 250 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, res := ratelimit.Apply(tt.tokens, tt.elapsed, limit)
+			assert.InDelta(t, tt.expectedTokens, tokens, 1e-9)
+			assert.Equal(t, tt.expected, res)
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	ctx := context.Background()
+	limit := ratelimit.Limit{Rate: 50, Burst: 2}
+
+	for i := range 2 {
+		res, err := store.Take(ctx, "ip:10.0.0.1", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed, "request %d", i)
+	}
+	res, err := store.Take(ctx, "ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Positive(t, res.RetryAfter)
+
+	// корзины разных ключей независимы
+	res, err = store.Take(ctx, "ip:10.0.0.2", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	time.Sleep(res.Reset + 10*time.Millisecond)
+	res, err = store.Take(ctx, "ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+}
+
+func TestMemoryStore_Concurrent(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	// пополнение за время теста пренебрежимо мало
+	limit := ratelimit.Limit{Rate: 0.001, Burst: 10}
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := store.Take(context.Background(), "wallet:w-1", limit)
+			assert.NoError(t, err)
+			if res.Allowed {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(10), allowed.Load())
+}
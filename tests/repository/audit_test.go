@@ -0,0 +1,48 @@
+package repository_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/glekoz/test_itk/internal/audit"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+	"github.com/glekoz/test_itk/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// параллельные операции с кошельками одного арендатора пишут журнал без общей блокировки,
+// а цепочки кошельков после этого сходятся
+func TestAuditWalletChains_Postgres(t *testing.T) {
+	repo, _ := openTestRepository(t)
+	ctx := tenant.WithConfig(context.Background(), tenant.Config{ID: "audit-test"})
+
+	ids := []string{uuid.NewString(), uuid.NewString(), uuid.NewString()}
+	for _, id := range ids {
+		require.NoError(t, repo.CreateWallet(ctx, id, ""))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(ids)*10)
+	for _, id := range ids {
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := repo.Deposit(ctx, id, uuid.NewString(), 10, myvars.OperationTypeDeposit)
+				errs <- err
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	v := audit.NewVerifier()
+	require.NoError(t, repo.ListAuditEntries(context.Background(), v.Check))
+	assert.GreaterOrEqual(t, v.Checked(), len(ids)*11)
+}
@@ -0,0 +1,39 @@
+package repository_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/glekoz/test_itk/internal/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+// openTestRepository подключается к базе из TEST_DATABASE_URL и применяет миграции.
+// Без переменной тест пропускается: в CI база поднимается через docker-compose
+func openTestRepository(t *testing.T) (*repository.Repository, *pgxpool.Pool) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	repo, err := repository.New(ctx, dsn, repository.Options{ConnectAttempts: 1}, slog.Default())
+	require.NoError(t, err)
+	t.Cleanup(repo.Close)
+
+	m, err := repo.Migrator()
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	m.Close()
+	require.NoError(t, err)
+
+	// отдельный пул для правок в обход репозитория
+	pool, err := pgxpool.New(ctx, dsn)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return repo, pool
+}
@@ -0,0 +1,54 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glekoz/test_itk/internal/events"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+	"github.com/glekoz/test_itk/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// проверяет настоящие запросы RebuildWalletProjection/UpsertWalletProjection: удаленная проекция
+// восстанавливается из wallet_events с тем же балансом, версией, статусом, владельцем и арендатором
+func TestRebuildProjections_Postgres(t *testing.T) {
+	repo, pool := openTestRepository(t)
+	ctx := tenant.WithConfig(context.Background(), tenant.Config{ID: "rebuild-test"})
+
+	owned, anonymous := uuid.NewString(), uuid.NewString()
+	require.NoError(t, repo.CreateWallet(ctx, owned, "owner-1"))
+	require.NoError(t, repo.CreateWallet(ctx, anonymous, ""))
+	for _, id := range []string{owned, anonymous} {
+		_, err := repo.Deposit(ctx, id, uuid.NewString(), 500, myvars.OperationTypeDeposit)
+		require.NoError(t, err)
+		_, err = repo.Withdraw(ctx, id, uuid.NewString(), 120, myvars.OperationTypeWithdraw)
+		require.NoError(t, err)
+	}
+	_, err := repo.AdjustBalance(ctx, owned, uuid.NewString(), -80, "chargeback")
+	require.NoError(t, err)
+	require.NoError(t, repo.SetWalletStatus(ctx, anonymous, myvars.WalletStatusFrozen, "fraud check"))
+
+	live := make(map[string]models.Wallet)
+	for _, id := range []string{owned, anonymous} {
+		w, err := repo.GetWallet(ctx, id)
+		require.NoError(t, err)
+		live[id] = w
+	}
+
+	_, err = pool.Exec(context.Background(), "DELETE FROM wallets WHERE id = ANY($1)", []string{owned, anonymous})
+	require.NoError(t, err)
+
+	_, err = events.RebuildProjections(context.Background(), repo)
+	require.NoError(t, err)
+
+	for _, id := range []string{owned, anonymous} {
+		w, err := repo.GetWallet(ctx, id)
+		require.NoError(t, err, "projection of %s is not in its tenant", id)
+		w.UpdatedAt = live[id].UpdatedAt
+		assert.Equal(t, live[id], w)
+	}
+}
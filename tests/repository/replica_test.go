@@ -0,0 +1,85 @@
+package repository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/repository"
+	"github.com/glekoz/test_itk/internal/shared/consistency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLSN(t *testing.T) {
+	lsn, err := consistency.ParseLSN("16/B374D848")
+	require.NoError(t, err)
+	assert.Equal(t, consistency.LSN(0x16_B374D848), lsn)
+	assert.Equal(t, "16/B374D848", lsn.String())
+
+	for _, bad := range []string{"", "16", "16/", "x/1", "1/100000000"} {
+		_, err := consistency.ParseLSN(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestToken(t *testing.T) {
+	var missing *consistency.Token
+	assert.Zero(t, missing.Required())
+	missing.Wrote(10)
+
+	token := consistency.NewToken(100)
+	assert.Equal(t, consistency.LSN(100), token.Required())
+	token.Wrote(50)
+	assert.Equal(t, consistency.LSN(100), token.Required())
+	token.Wrote(200)
+	token.Wrote(150)
+	assert.Equal(t, consistency.LSN(200), token.Written())
+	assert.Equal(t, consistency.LSN(200), token.Required())
+}
+
+func TestReplicaState(t *testing.T) {
+	t.Run("unchecked replica is not used", func(t *testing.T) {
+		state := repository.NewReplicaState(time.Second)
+		assert.Error(t, state.Check())
+		assert.False(t, state.Serves(0))
+	})
+
+	t.Run("healthy replica serves reads it has replayed", func(t *testing.T) {
+		state := repository.NewReplicaState(time.Second)
+		state.Update(1000, 100*time.Millisecond)
+
+		require.NoError(t, state.Check())
+		assert.True(t, state.Serves(0))
+		assert.True(t, state.Serves(1000))
+		assert.False(t, state.Serves(1001), "write from the token is not replayed yet")
+	})
+
+	t.Run("lagging replica is not used", func(t *testing.T) {
+		state := repository.NewReplicaState(time.Second)
+		state.Update(1000, 2*time.Second)
+
+		assert.ErrorContains(t, state.Check(), "exceeds")
+		assert.False(t, state.Serves(0))
+	})
+
+	t.Run("zero max lag does not limit lag", func(t *testing.T) {
+		state := repository.NewReplicaState(0)
+		state.Update(1000, time.Hour)
+
+		assert.True(t, state.Serves(1000))
+	})
+
+	t.Run("failed check disables replica until next success", func(t *testing.T) {
+		state := repository.NewReplicaState(time.Second)
+		state.Update(1000, 0)
+		down := errors.New("connection refused")
+		state.Fail(down)
+
+		assert.ErrorIs(t, state.Check(), down)
+		assert.False(t, state.Serves(0))
+
+		state.Update(2000, 0)
+		assert.True(t, state.Serves(2000))
+	})
+}
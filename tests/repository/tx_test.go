@@ -0,0 +1,138 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/metrics"
+	"github.com/glekoz/test_itk/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTx реализует только Commit и Rollback: остальные методы pgx.Tx в InTx не вызываются
+type fakeTx struct {
+	pgx.Tx
+	commitErr  error
+	committed  bool
+	rolledBack bool
+}
+
+func (t *fakeTx) Commit(ctx context.Context) error {
+	if t.commitErr != nil {
+		return t.commitErr
+	}
+	t.committed = true
+	return nil
+}
+
+func (t *fakeTx) Rollback(ctx context.Context) error {
+	if !t.committed {
+		t.rolledBack = true
+	}
+	return nil
+}
+
+// fakeBeginner выдает транзакции, у которых Commit возвращает commitErrs по порядку
+type fakeBeginner struct {
+	commitErrs []error
+	txs        []*fakeTx
+	opts       []pgx.TxOptions
+}
+
+func (b *fakeBeginner) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	tx := &fakeTx{}
+	if n := len(b.txs); n < len(b.commitErrs) {
+		tx.commitErr = b.commitErrs[n]
+	}
+	b.txs = append(b.txs, tx)
+	b.opts = append(b.opts, opts)
+	return tx, nil
+}
+
+func pgError(code string) error {
+	return &pgconn.PgError{Code: code, Message: "could not serialize access"}
+}
+
+func TestInTx(t *testing.T) {
+	policy := repository.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	serializable := pgx.TxOptions{IsoLevel: pgx.Serializable}
+
+	t.Run("serialization failure is retried", func(t *testing.T) {
+		retries := metrics.TxRetries.WithLabelValues(repository.RetrySerializationFailure)
+		before := testutil.ToFloat64(retries)
+		b := &fakeBeginner{}
+		calls := 0
+
+		err := repository.InTx(context.Background(), b, serializable, policy, func(tx pgx.Tx) error {
+			calls++
+			if calls == 1 {
+				return pgError(repository.SerializationFailureCode)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		require.Len(t, b.txs, 2)
+		assert.True(t, b.txs[0].rolledBack)
+		assert.True(t, b.txs[1].committed)
+		assert.Equal(t, []pgx.TxOptions{serializable, serializable}, b.opts)
+		assert.Equal(t, before+1, testutil.ToFloat64(retries))
+	})
+
+	t.Run("deadlock on commit is retried", func(t *testing.T) {
+		retries := metrics.TxRetries.WithLabelValues(repository.RetryDeadlock)
+		before := testutil.ToFloat64(retries)
+		b := &fakeBeginner{commitErrs: []error{pgError(repository.DeadlockDetectedCode)}}
+
+		err := repository.InTx(context.Background(), b, pgx.TxOptions{}, policy, func(tx pgx.Tx) error { return nil })
+		require.NoError(t, err)
+		require.Len(t, b.txs, 2)
+		assert.True(t, b.txs[1].committed)
+		assert.Equal(t, before+1, testutil.ToFloat64(retries))
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		b := &fakeBeginner{}
+
+		err := repository.InTx(context.Background(), b, serializable, policy, func(tx pgx.Tx) error {
+			return pgError(repository.SerializationFailureCode)
+		})
+		var errp *pgconn.PgError
+		require.ErrorAs(t, err, &errp)
+		assert.Equal(t, repository.SerializationFailureCode, errp.Code)
+		assert.Len(t, b.txs, 3)
+		for _, tx := range b.txs {
+			assert.True(t, tx.rolledBack)
+		}
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		b := &fakeBeginner{}
+		boom := errors.New("boom")
+
+		for _, fail := range []error{boom, pgError(repository.UniqueViolationCode)} {
+			b.txs = nil
+			err := repository.InTx(context.Background(), b, pgx.TxOptions{}, policy, func(tx pgx.Tx) error { return fail })
+			assert.ErrorIs(t, err, fail)
+			assert.Len(t, b.txs, 1)
+		}
+	})
+
+	t.Run("canceled context stops retries", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		b := &fakeBeginner{}
+
+		err := repository.InTx(ctx, b, pgx.TxOptions{}, repository.RetryPolicy{MaxAttempts: 5, Backoff: time.Hour}, func(tx pgx.Tx) error {
+			cancel()
+			return pgError(repository.DeadlockDetectedCode)
+		})
+		require.Error(t, err)
+		assert.Len(t, b.txs, 1)
+	})
+}
@@ -2,27 +2,52 @@ package service_test
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"os"
 
+	"github.com/glekoz/test_itk/internal/cache"
+	"github.com/glekoz/test_itk/internal/shared/consistency"
+	"github.com/glekoz/test_itk/internal/shared/models"
 	"github.com/glekoz/test_itk/internal/shared/myvars"
 )
 
 // MockRepo представляет мок для репозитория
 type MockRepo struct {
-	CreateWalletFunc func(ctx context.Context, id string) error
-	GetBalanceFunc   func(ctx context.Context, id string) (int, error)
-	DepositFunc      func(ctx context.Context, walletID, transactionID string, amount int, operationType myvars.OperationType) (int, error)
-	WithdrawFunc     func(ctx context.Context, walletID, transactionID string, amount int, operationType myvars.OperationType) (int, error)
+	CreateWalletFunc   func(ctx context.Context, id, ownerID string) error
+	GetBalanceFunc     func(ctx context.Context, id string) (int, error)
+	GetWalletOwnerFunc func(ctx context.Context, id string) (string, error)
+	DepositFunc        func(ctx context.Context, walletID, transactionID string, amount int, operationType myvars.OperationType) (int, error)
+	WithdrawFunc       func(ctx context.Context, walletID, transactionID string, amount int, operationType myvars.OperationType) (int, error)
+
+	CreateWebhookSubscriptionFunc func(ctx context.Context, sub models.WebhookSubscription) error
+	ListWebhookDeliveriesFunc     func(ctx context.Context, subscriptionID string, limit int) ([]models.WebhookDelivery, error)
+	ReplayWebhookDeliveryFunc     func(ctx context.Context, deliveryID string) error
+
+	CreateAPIKeyFunc    func(ctx context.Context, key models.APIKey, hash string) error
+	RevokeAPIKeyFunc    func(ctx context.Context, id string) error
+	RevokeAnyAPIKeyFunc func(ctx context.Context, id string) error
+
+	ListWalletsFunc            func(ctx context.Context, filter models.WalletFilter) ([]models.Wallet, error)
+	GetWalletFunc              func(ctx context.Context, id string) (models.Wallet, error)
+	ListWalletTransactionsFunc func(ctx context.Context, walletID string, limit int) ([]models.WalletTransaction, error)
+	AdjustBalanceFunc          func(ctx context.Context, walletID, transactionID string, amount int, reason string) (int, error)
+	SetWalletStatusFunc        func(ctx context.Context, walletID string, status myvars.WalletStatus, reason string) error
 }
 
-func (m *MockRepo) CreateWallet(ctx context.Context, id string) error {
+func (m *MockRepo) CreateWallet(ctx context.Context, id, ownerID string) error {
 	if m.CreateWalletFunc != nil {
-		return m.CreateWalletFunc(ctx, id)
+		return m.CreateWalletFunc(ctx, id, ownerID)
 	}
 	return nil
 }
 
+func (m *MockRepo) GetWalletOwner(ctx context.Context, id string) (string, error) {
+	if m.GetWalletOwnerFunc != nil {
+		return m.GetWalletOwnerFunc(ctx, id)
+	}
+	return "", nil
+}
+
 func (m *MockRepo) GetBalance(ctx context.Context, id string) (int, error) {
 	if m.GetBalanceFunc != nil {
 		return m.GetBalanceFunc(ctx, id)
@@ -44,42 +69,150 @@ func (m *MockRepo) Withdraw(ctx context.Context, walletID, transactionID string,
 	return 0, nil
 }
 
-// MockCache представляет мок для кэша
+func (m *MockRepo) CreateWebhookSubscription(ctx context.Context, sub models.WebhookSubscription) error {
+	if m.CreateWebhookSubscriptionFunc != nil {
+		return m.CreateWebhookSubscriptionFunc(ctx, sub)
+	}
+	return nil
+}
+
+func (m *MockRepo) ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]models.WebhookDelivery, error) {
+	if m.ListWebhookDeliveriesFunc != nil {
+		return m.ListWebhookDeliveriesFunc(ctx, subscriptionID, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockRepo) ReplayWebhookDelivery(ctx context.Context, deliveryID string) error {
+	if m.ReplayWebhookDeliveryFunc != nil {
+		return m.ReplayWebhookDeliveryFunc(ctx, deliveryID)
+	}
+	return nil
+}
+
+func (m *MockRepo) CreateAPIKey(ctx context.Context, key models.APIKey, hash string) error {
+	if m.CreateAPIKeyFunc != nil {
+		return m.CreateAPIKeyFunc(ctx, key, hash)
+	}
+	return nil
+}
+
+func (m *MockRepo) RevokeAPIKey(ctx context.Context, id string) error {
+	if m.RevokeAPIKeyFunc != nil {
+		return m.RevokeAPIKeyFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *MockRepo) RevokeAnyAPIKey(ctx context.Context, id string) error {
+	if m.RevokeAnyAPIKeyFunc != nil {
+		return m.RevokeAnyAPIKeyFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *MockRepo) ListWallets(ctx context.Context, filter models.WalletFilter) ([]models.Wallet, error) {
+	if m.ListWalletsFunc != nil {
+		return m.ListWalletsFunc(ctx, filter)
+	}
+	return nil, nil
+}
+
+func (m *MockRepo) GetWallet(ctx context.Context, id string) (models.Wallet, error) {
+	if m.GetWalletFunc != nil {
+		return m.GetWalletFunc(ctx, id)
+	}
+	return models.Wallet{}, nil
+}
+
+func (m *MockRepo) ListWalletTransactions(ctx context.Context, walletID string, limit int) ([]models.WalletTransaction, error) {
+	if m.ListWalletTransactionsFunc != nil {
+		return m.ListWalletTransactionsFunc(ctx, walletID, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockRepo) AdjustBalance(ctx context.Context, walletID, transactionID string, amount int, reason string) (int, error) {
+	if m.AdjustBalanceFunc != nil {
+		return m.AdjustBalanceFunc(ctx, walletID, transactionID, amount, reason)
+	}
+	return 0, nil
+}
+
+func (m *MockRepo) SetWalletStatus(ctx context.Context, walletID string, status myvars.WalletStatus, reason string) error {
+	if m.SetWalletStatusFunc != nil {
+		return m.SetWalletStatusFunc(ctx, walletID, status, reason)
+	}
+	return nil
+}
+
+// MockCache представляет мок для кэша; GetFunc задает свежие записи, LookupFunc - любые
 type MockCache struct {
-	AddFunc    func(walletID string, balance int) error
-	GetFunc    func(walletID string) (int, bool)
-	DeleteFunc func(walletID string)
+	AddFunc         func(tenantID, walletID string, balance int) error
+	FillFunc        func(tenantID, walletID string, balance int, gen uint64) error
+	AddNotFoundFunc func(tenantID, walletID string, gen uint64) error
+	FillLSNFunc     func(tenantID, walletID string) consistency.LSN
+	GetFunc         func(tenantID, walletID string) (int, bool)
+	LookupFunc      func(tenantID, walletID string) (cache.Entry, bool)
+	DeleteFunc      func(tenantID, walletID string)
 }
 
-func (m *MockCache) Add(walletID string, balance int) error {
+func (m *MockCache) Add(tenantID, walletID string, balance int) error {
 	if m.AddFunc != nil {
-		return m.AddFunc(walletID, balance)
+		return m.AddFunc(tenantID, walletID, balance)
 	}
 	return nil
 }
 
-func (m *MockCache) Get(walletID string) (int, bool) {
+func (m *MockCache) Generation(tenantID, walletID string) uint64 {
+	return 0
+}
+
+func (m *MockCache) FillLSN(tenantID, walletID string) consistency.LSN {
+	if m.FillLSNFunc != nil {
+		return m.FillLSNFunc(tenantID, walletID)
+	}
+	return 0
+}
+
+func (m *MockCache) Fill(tenantID, walletID string, balance int, gen uint64) error {
+	if m.FillFunc != nil {
+		return m.FillFunc(tenantID, walletID, balance, gen)
+	}
+	return nil
+}
+
+func (m *MockCache) AddNotFound(tenantID, walletID string, gen uint64) error {
+	if m.AddNotFoundFunc != nil {
+		return m.AddNotFoundFunc(tenantID, walletID, gen)
+	}
+	return nil
+}
+
+func (m *MockCache) Lookup(tenantID, walletID string) (cache.Entry, bool) {
+	if m.LookupFunc != nil {
+		return m.LookupFunc(tenantID, walletID)
+	}
 	if m.GetFunc != nil {
-		return m.GetFunc(walletID)
+		balance, ok := m.GetFunc(tenantID, walletID)
+		return cache.Entry{Balance: balance}, ok
 	}
-	return 0, false
+	return cache.Entry{}, false
 }
 
-func (m *MockCache) Delete(walletID string) {
+func (m *MockCache) Delete(tenantID, walletID string) {
 	if m.DeleteFunc != nil {
-		m.DeleteFunc(walletID)
+		m.DeleteFunc(tenantID, walletID)
 	}
 }
 
 // testHelpers содержит вспомогательные функции для тестов
 type testHelpers struct {
-	infoLog  *log.Logger
-	errorLog *log.Logger
+	logger *slog.Logger
 }
 
 func newTestHelpers() *testHelpers {
 	return &testHelpers{
-		infoLog:  log.New(os.Stdout, "TEST INFO: ", log.LstdFlags),
-		errorLog: log.New(os.Stdout, "TEST ERROR: ", log.LstdFlags),
+		logger: slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})),
 	}
 }
@@ -0,0 +1,309 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/cache"
+	"github.com/glekoz/test_itk/internal/metrics"
+	"github.com/glekoz/test_itk/internal/service"
+	"github.com/glekoz/test_itk/internal/shared/consistency"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+	"github.com/glekoz/test_itk/internal/tenant"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBalances(t *testing.T, negativeTTL int) *cache.Cache {
+	balances, err := cache.New(30, 0)
+	require.NoError(t, err)
+	balances.SetNegativeTTL(negativeTTL)
+	return balances
+}
+
+func TestService_GetBalance_Coalescing(t *testing.T) {
+	helpers := newTestHelpers()
+
+	t.Run("concurrent misses read the database once", func(t *testing.T) {
+		const readers = 50
+		coalesced := testutil.ToFloat64(metrics.CacheCoalescedLoads)
+		var calls atomic.Int32
+		release := make(chan struct{})
+		repo := &MockRepo{
+			GetBalanceFunc: func(ctx context.Context, id string) (int, error) {
+				calls.Add(1)
+				<-release
+				return 700, nil
+			},
+		}
+		s := service.New(repo, newBalances(t, 5), helpers.logger)
+
+		var started, done sync.WaitGroup
+		balances := make([]int, readers)
+		errs := make([]error, readers)
+		for i := range readers {
+			started.Add(1)
+			done.Add(1)
+			go func() {
+				defer done.Done()
+				started.Done()
+				balances[i], errs[i] = s.GetBalance(context.Background(), "hot-wallet")
+			}()
+		}
+		started.Wait()
+		// опоздавшие к общему чтению найдут баланс в кэше, поэтому запрос к базе все равно один
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		done.Wait()
+
+		assert.Equal(t, int32(1), calls.Load())
+		for i := range readers {
+			require.NoError(t, errs[i])
+			assert.Equal(t, 700, balances[i])
+		}
+		assert.Greater(t, testutil.ToFloat64(metrics.CacheCoalescedLoads), coalesced)
+	})
+
+	t.Run("canceled leader does not fail waiting readers", func(t *testing.T) {
+		entered := make(chan struct{})
+		release := make(chan struct{})
+		repo := &MockRepo{
+			GetBalanceFunc: func(ctx context.Context, id string) (int, error) {
+				close(entered)
+				<-release
+				return 300, ctx.Err()
+			},
+		}
+		s := service.New(repo, newBalances(t, 5), helpers.logger)
+
+		leaderCtx, cancel := context.WithCancel(context.Background())
+		leaderErr := make(chan error, 1)
+		go func() {
+			_, err := s.GetBalance(leaderCtx, "w-1")
+			leaderErr <- err
+		}()
+		<-entered
+
+		followerBalance := make(chan int, 1)
+		go func() {
+			balance, err := s.GetBalance(context.Background(), "w-1")
+			assert.NoError(t, err)
+			followerBalance <- balance
+		}()
+
+		cancel()
+		assert.ErrorIs(t, <-leaderErr, context.Canceled)
+		close(release)
+		assert.Equal(t, 300, <-followerBalance)
+	})
+}
+
+func TestService_GetBalance_NegativeCache(t *testing.T) {
+	helpers := newTestHelpers()
+
+	tests := []struct {
+		name          string
+		negativeTTL   int
+		expectedCalls int32
+	}{
+		{name: "missing wallet is remembered", negativeTTL: 5, expectedCalls: 1},
+		{name: "negative caching disabled", negativeTTL: 0, expectedCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			repo := &MockRepo{
+				GetBalanceFunc: func(ctx context.Context, id string) (int, error) {
+					calls.Add(1)
+					return 0, myerrors.ErrNotFound
+				},
+			}
+			s := service.New(repo, newBalances(t, tt.negativeTTL), helpers.logger)
+
+			for range 3 {
+				_, err := s.GetBalance(context.Background(), "missing")
+				require.ErrorIs(t, err, myerrors.ErrNotFound)
+			}
+			assert.Equal(t, tt.expectedCalls, calls.Load())
+		})
+	}
+
+	t.Run("deposit replaces remembered absence", func(t *testing.T) {
+		balances := newBalances(t, 5)
+		repo := &MockRepo{
+			GetBalanceFunc: func(ctx context.Context, id string) (int, error) {
+				return 0, myerrors.ErrNotFound
+			},
+			DepositFunc: func(ctx context.Context, walletID, transactionID string, amount int, operationType myvars.OperationType) (int, error) {
+				return amount, nil
+			},
+		}
+		s := service.New(repo, balances, helpers.logger)
+
+		_, err := s.GetBalance(context.Background(), "late-wallet")
+		require.ErrorIs(t, err, myerrors.ErrNotFound)
+		require.NoError(t, s.Deposit(context.Background(), "late-wallet", 40))
+
+		balance, err := s.GetBalance(context.Background(), "late-wallet")
+		require.NoError(t, err)
+		assert.Equal(t, 40, balance)
+	})
+}
+
+func TestService_GetBalance_StaleWhileRevalidate(t *testing.T) {
+	helpers := newTestHelpers()
+	refreshed := make(chan int, 1)
+	cacheMock := &MockCache{
+		LookupFunc: func(tenantID, walletID string) (cache.Entry, bool) {
+			return cache.Entry{Balance: 100, Stale: true}, true
+		},
+		FillFunc: func(tenantID, walletID string, balance int, gen uint64) error {
+			refreshed <- balance
+			return nil
+		},
+	}
+	release := make(chan struct{})
+	repo := &MockRepo{
+		GetBalanceFunc: func(ctx context.Context, id string) (int, error) {
+			<-release
+			return 150, nil
+		},
+	}
+	s := service.New(repo, cacheMock, helpers.logger)
+
+	// устаревший баланс отдается сразу, не дожидаясь базы
+	balance, err := s.GetBalance(context.Background(), "w-1")
+	require.NoError(t, err)
+	assert.Equal(t, 100, balance)
+
+	close(release)
+	select {
+	case balance := <-refreshed:
+		assert.Equal(t, 150, balance)
+	case <-time.After(time.Second):
+		t.Fatal("stale balance was not refreshed")
+	}
+}
+
+func TestService_GetBalance_ConsistencyToken(t *testing.T) {
+	helpers := newTestHelpers()
+	var calls atomic.Int32
+	repo := &MockRepo{
+		GetBalanceFunc: func(ctx context.Context, id string) (int, error) {
+			calls.Add(1)
+			return 900, nil
+		},
+	}
+	balances := newBalances(t, 5)
+	// кэш еще помнит баланс до записи, которую клиент только что сделал на другом экземпляре
+	require.NoError(t, balances.Add(tenant.DefaultID, "w-1", 100))
+	s := service.New(repo, balances, helpers.logger)
+
+	balance, err := s.GetBalance(context.Background(), "w-1")
+	require.NoError(t, err)
+	assert.Equal(t, 100, balance)
+	assert.Equal(t, int32(0), calls.Load())
+
+	ctx := consistency.WithToken(context.Background(), consistency.NewToken(0x16_B374D848))
+	balance, err = s.GetBalance(ctx, "w-1")
+	require.NoError(t, err)
+	assert.Equal(t, 900, balance)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// баланс, вытесненный по уведомлению, перечитывается не раньше позиции WAL изменения, иначе отстающая
+// реплика вернула бы старое значение в кэш на весь ttl
+func TestService_GetBalance_FillAfterNotifyEviction(t *testing.T) {
+	helpers := newTestHelpers()
+	var required consistency.LSN
+	repo := &MockRepo{
+		GetBalanceFunc: func(ctx context.Context, id string) (int, error) {
+			required = consistency.FromContext(ctx).Required()
+			return 150, nil
+		},
+	}
+	balances := newBalances(t, 5)
+	require.NoError(t, balances.Add(tenant.DefaultID, "w-1", 100))
+	balances.Evict(tenant.DefaultID, "w-1", 0x16_B374D848)
+	s := service.New(repo, balances, helpers.logger)
+
+	balance, err := s.GetBalance(context.Background(), "w-1")
+	require.NoError(t, err)
+	assert.Equal(t, 150, balance)
+	assert.Equal(t, consistency.LSN(0x16_B374D848), required)
+
+	// токен клиента новее вытеснения и остается в силе
+	_, err = s.GetBalance(consistency.WithToken(context.Background(), consistency.NewToken(0x17_00000000)), "w-1")
+	require.NoError(t, err)
+	assert.Equal(t, consistency.LSN(0x17_00000000), required)
+}
+
+func TestService_GetBalance_EvictionDuringRead(t *testing.T) {
+	helpers := newTestHelpers()
+	var calls atomic.Int32
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	repo := &MockRepo{
+		GetBalanceFunc: func(ctx context.Context, id string) (int, error) {
+			if calls.Add(1) == 1 {
+				close(entered)
+				<-release
+				return 100, nil
+			}
+			return 150, nil
+		},
+	}
+	balances := newBalances(t, 5)
+	s := service.New(repo, balances, helpers.logger)
+
+	first := make(chan int, 1)
+	go func() {
+		balance, err := s.GetBalance(context.Background(), "w-1")
+		assert.NoError(t, err)
+		first <- balance
+	}()
+	<-entered
+	// другой экземпляр изменил кошелек, пока баланс читался: прочитанное значение уже устарело
+	balances.Delete(tenant.DefaultID, "w-1")
+	close(release)
+	assert.Equal(t, 100, <-first)
+
+	balance, err := s.GetBalance(context.Background(), "w-1")
+	require.NoError(t, err)
+	assert.Equal(t, 150, balance)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestService_WaitRefreshes(t *testing.T) {
+	helpers := newTestHelpers()
+	release := make(chan struct{})
+	cacheMock := &MockCache{
+		LookupFunc: func(tenantID, walletID string) (cache.Entry, bool) {
+			return cache.Entry{Balance: 100, Stale: true}, true
+		},
+	}
+	repo := &MockRepo{
+		GetBalanceFunc: func(ctx context.Context, id string) (int, error) {
+			<-release
+			return 150, nil
+		},
+	}
+	s := service.New(repo, cacheMock, helpers.logger)
+
+	_, err := s.GetBalance(context.Background(), "w-1")
+	require.NoError(t, err)
+
+	// фоновое обновление еще читает из базы, поэтому закрывать пул рано
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, s.WaitRefreshes(ctx), context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, s.WaitRefreshes(context.Background()))
+}
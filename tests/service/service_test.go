@@ -24,7 +24,7 @@ func TestService_CreateWallet(t *testing.T) {
 		{
 			name: "successful wallet creation",
 			repoMock: &MockRepo{
-				CreateWalletFunc: func(ctx context.Context, id string) error {
+				CreateWalletFunc: func(ctx context.Context, id, ownerID string) error {
 					// Проверяем что ID передается корректно
 					assert.NotEmpty(t, id)
 					return nil
@@ -36,7 +36,7 @@ func TestService_CreateWallet(t *testing.T) {
 		{
 			name: "repository error on creation",
 			repoMock: &MockRepo{
-				CreateWalletFunc: func(ctx context.Context, id string) error {
+				CreateWalletFunc: func(ctx context.Context, id, ownerID string) error {
 					return errors.New("database connection failed")
 				},
 			},
@@ -48,7 +48,7 @@ func TestService_CreateWallet(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service := service.New(tt.repoMock, tt.cacheMock, helpers.infoLog, helpers.errorLog)
+			service := service.New(tt.repoMock, tt.cacheMock, helpers.logger)
 
 			walletID, err := service.CreateWallet(context.Background())
 
@@ -83,7 +83,7 @@ func TestService_GetBalance(t *testing.T) {
 			name:     "successful balance from cache",
 			walletID: "cached-wallet",
 			cacheMock: &MockCache{
-				GetFunc: func(walletID string) (int, bool) {
+				GetFunc: func(tenantID, walletID string) (int, bool) {
 					assert.Equal(t, "cached-wallet", walletID)
 					return 1500, true
 				},
@@ -96,7 +96,7 @@ func TestService_GetBalance(t *testing.T) {
 			name:     "successful balance from repository",
 			walletID: "db-wallet",
 			cacheMock: &MockCache{
-				GetFunc: func(walletID string) (int, bool) {
+				GetFunc: func(tenantID, walletID string) (int, bool) {
 					return 0, false // кэш пустой
 				},
 			},
@@ -113,7 +113,7 @@ func TestService_GetBalance(t *testing.T) {
 			name:     "repository error on balance retrieval",
 			walletID: "error-wallet",
 			cacheMock: &MockCache{
-				GetFunc: func(walletID string) (int, bool) {
+				GetFunc: func(tenantID, walletID string) (int, bool) {
 					return 0, false
 				},
 			},
@@ -130,7 +130,7 @@ func TestService_GetBalance(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service := service.New(tt.repoMock, tt.cacheMock, helpers.infoLog, helpers.errorLog)
+			service := service.New(tt.repoMock, tt.cacheMock, helpers.logger)
 
 			balance, err := service.GetBalance(context.Background(), tt.walletID)
 
@@ -174,7 +174,7 @@ func TestService_Deposit(t *testing.T) {
 				},
 			},
 			cacheMock: &MockCache{
-				AddFunc: func(walletID string, balance int) error {
+				AddFunc: func(tenantID, walletID string, balance int) error {
 					assert.Equal(t, "test-wallet", walletID)
 					assert.Equal(t, 1500, balance)
 					return nil
@@ -207,10 +207,10 @@ func TestService_Deposit(t *testing.T) {
 				},
 			},
 			cacheMock: &MockCache{
-				AddFunc: func(walletID string, balance int) error {
+				AddFunc: func(tenantID, walletID string, balance int) error {
 					return errors.New("cache update failed")
 				},
-				DeleteFunc: func(walletID string) {
+				DeleteFunc: func(tenantID, walletID string) {
 					assert.Equal(t, "test-wallet", walletID)
 					// Должен быть вызван при ошибке добавления в кэш
 				},
@@ -227,20 +227,20 @@ func TestService_Deposit(t *testing.T) {
 			// Сохраняем оригинальные функции и отслеживаем вызовы
 			if tt.cacheMock.AddFunc != nil {
 				originalAdd := tt.cacheMock.AddFunc
-				tt.cacheMock.AddFunc = func(walletID string, balance int) error {
+				tt.cacheMock.AddFunc = func(tenantID, walletID string, balance int) error {
 					cacheAddCalled = true
-					return originalAdd(walletID, balance)
+					return originalAdd(tenantID, walletID, balance)
 				}
 			}
 
 			if tt.cacheMock.DeleteFunc != nil {
 				originalDelete := tt.cacheMock.DeleteFunc
-				tt.cacheMock.DeleteFunc = func(walletID string) {
-					originalDelete(walletID)
+				tt.cacheMock.DeleteFunc = func(tenantID, walletID string) {
+					originalDelete(tenantID, walletID)
 				}
 			}
 
-			service := service.New(tt.repoMock, tt.cacheMock, helpers.infoLog, helpers.errorLog)
+			service := service.New(tt.repoMock, tt.cacheMock, helpers.logger)
 
 			err := service.Deposit(context.Background(), tt.walletID, tt.amount)
 
@@ -287,7 +287,7 @@ func TestService_Withdraw(t *testing.T) {
 				},
 			},
 			cacheMock: &MockCache{
-				AddFunc: func(walletID string, balance int) error {
+				AddFunc: func(tenantID, walletID string, balance int) error {
 					assert.Equal(t, "test-wallet", walletID)
 					assert.Equal(t, 500, balance)
 					return nil
@@ -320,10 +320,10 @@ func TestService_Withdraw(t *testing.T) {
 				},
 			},
 			cacheMock: &MockCache{
-				AddFunc: func(walletID string, balance int) error {
+				AddFunc: func(tenantID, walletID string, balance int) error {
 					return errors.New("cache update failed")
 				},
-				DeleteFunc: func(walletID string) {
+				DeleteFunc: func(tenantID, walletID string) {
 					assert.Equal(t, "test-wallet", walletID)
 				},
 			},
@@ -338,13 +338,13 @@ func TestService_Withdraw(t *testing.T) {
 
 			if tt.cacheMock.AddFunc != nil {
 				originalAdd := tt.cacheMock.AddFunc
-				tt.cacheMock.AddFunc = func(walletID string, balance int) error {
+				tt.cacheMock.AddFunc = func(tenantID, walletID string, balance int) error {
 					cacheAddCalled = true
-					return originalAdd(walletID, balance)
+					return originalAdd(tenantID, walletID, balance)
 				}
 			}
 
-			service := service.New(tt.repoMock, tt.cacheMock, helpers.infoLog, helpers.errorLog)
+			service := service.New(tt.repoMock, tt.cacheMock, helpers.logger)
 
 			err := service.Withdraw(context.Background(), tt.walletID, tt.amount)
 
@@ -0,0 +1,84 @@
+package service_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/glekoz/test_itk/internal/cache"
+	"github.com/glekoz/test_itk/internal/service"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+	"github.com/glekoz/test_itk/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_TenantTransferLimit(t *testing.T) {
+	helpers := newTestHelpers()
+	var deposits int
+	repo := &MockRepo{
+		DepositFunc: func(ctx context.Context, walletID, transactionID string, amount int, operationType myvars.OperationType) (int, error) {
+			deposits++
+			return amount, nil
+		},
+	}
+	s := service.New(repo, &MockCache{}, helpers.logger)
+	ctx := tenant.WithConfig(context.Background(), tenant.Config{ID: "brand-a", MaxTransferAmount: 100})
+
+	require.NoError(t, s.Deposit(ctx, "w-1", 100))
+	assert.ErrorIs(t, s.Deposit(ctx, "w-1", 101), myerrors.ErrLimitExceeded)
+	assert.ErrorIs(t, s.Withdraw(ctx, "w-1", 101), myerrors.ErrLimitExceeded)
+	assert.Equal(t, 1, deposits)
+
+	// без арендатора и с нулевым лимитом сумма не ограничена
+	require.NoError(t, s.Deposit(context.Background(), "w-1", 1000))
+	require.NoError(t, s.Deposit(tenant.WithConfig(context.Background(), tenant.Config{ID: "brand-b"}), "w-1", 1000))
+}
+
+// сумма уходит в БД как int32 и не должна переполняться в отрицательную
+func TestService_TransferAmountRange(t *testing.T) {
+	helpers := newTestHelpers()
+	var calls int
+	repo := &MockRepo{
+		DepositFunc: func(ctx context.Context, walletID, transactionID string, amount int, operationType myvars.OperationType) (int, error) {
+			calls++
+			return amount, nil
+		},
+		WithdrawFunc: func(ctx context.Context, walletID, transactionID string, amount int, operationType myvars.OperationType) (int, error) {
+			calls++
+			return 0, nil
+		},
+	}
+	s := service.New(repo, &MockCache{}, helpers.logger)
+
+	assert.ErrorIs(t, s.Deposit(context.Background(), "w-1", math.MaxInt32+1), myerrors.ErrAmountRange)
+	assert.ErrorIs(t, s.Withdraw(context.Background(), "w-1", math.MaxInt32+1), myerrors.ErrAmountRange)
+	assert.Zero(t, calls)
+	require.NoError(t, s.Deposit(context.Background(), "w-1", math.MaxInt32))
+}
+
+func TestService_TenantCacheIsolation(t *testing.T) {
+	helpers := newTestHelpers()
+	balances, err := cache.New(30, 0)
+	require.NoError(t, err)
+	repo := &MockRepo{
+		GetBalanceFunc: func(ctx context.Context, id string) (int, error) {
+			if tenant.ID(ctx) == "brand-a" {
+				return 100, nil
+			}
+			return 0, myerrors.ErrNotFound
+		},
+	}
+	s := service.New(repo, balances, helpers.logger)
+	brandA := tenant.WithConfig(context.Background(), tenant.Config{ID: "brand-a"})
+	brandB := tenant.WithConfig(context.Background(), tenant.Config{ID: "brand-b"})
+
+	balance, err := s.GetBalance(brandA, "w-1")
+	require.NoError(t, err)
+	assert.Equal(t, 100, balance)
+
+	// закэшированный баланс одного арендатора не виден другому
+	_, err = s.GetBalance(brandB, "w-1")
+	assert.ErrorIs(t, err, myerrors.ErrNotFound)
+}
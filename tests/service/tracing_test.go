@@ -0,0 +1,49 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glekoz/test_itk/internal/service"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestService_GetBalanceSpans(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	helpers := newTestHelpers()
+	repo := &MockRepo{
+		GetBalanceFunc: func(ctx context.Context, id string) (int, error) {
+			return 0, myerrors.ErrNotFound
+		},
+	}
+	cache := &MockCache{
+		GetFunc: func(tenantID, walletID string) (int, bool) { return 0, false },
+	}
+	s := service.New(repo, cache, helpers.logger)
+
+	_, err := s.GetBalance(context.Background(), "wallet-1")
+	require.ErrorIs(t, err, myerrors.ErrNotFound)
+
+	spans := rec.Ended()
+	require.Len(t, spans, 2)
+
+	cacheSpan, serviceSpan := spans[0], spans[1]
+	assert.Equal(t, "cache.Get", cacheSpan.Name())
+	assert.Contains(t, cacheSpan.Attributes(), attribute.Bool("cache.hit", false))
+	assert.Equal(t, serviceSpan.SpanContext().SpanID(), cacheSpan.Parent().SpanID())
+
+	assert.Equal(t, "service.GetBalance", serviceSpan.Name())
+	assert.Contains(t, serviceSpan.Attributes(), attribute.String("wallet.id", "wallet-1"))
+	assert.Equal(t, codes.Error, serviceSpan.Status().Code)
+}
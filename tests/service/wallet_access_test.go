@@ -0,0 +1,75 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/service"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_WalletOwnership(t *testing.T) {
+	helpers := newTestHelpers()
+	merchant := auth.WithPrincipal(context.Background(), auth.Principal{ID: "k1", OwnerID: "m-1", Scopes: []auth.Scope{auth.ScopeWithdraw}})
+
+	var ownerQueries int
+	repo := &MockRepo{
+		GetWalletOwnerFunc: func(ctx context.Context, id string) (string, error) {
+			ownerQueries++
+			if id == "own" {
+				return "m-1", nil
+			}
+			return "m-2", nil
+		},
+		WithdrawFunc: func(ctx context.Context, walletID, transactionID string, amount int, operationType myvars.OperationType) (int, error) {
+			return 50, nil
+		},
+		CreateWalletFunc: func(ctx context.Context, id, ownerID string) error {
+			assert.Equal(t, "m-1", ownerID)
+			return nil
+		},
+	}
+	s := service.New(repo, &MockCache{}, helpers.logger)
+
+	require.NoError(t, s.Withdraw(merchant, "own", 10))
+	assert.ErrorIs(t, s.Withdraw(merchant, "foreign", 10), myerrors.ErrForbidden)
+	_, err := s.GetBalance(merchant, "foreign")
+	assert.ErrorIs(t, err, myerrors.ErrForbidden)
+	_, err = s.CreateWallet(merchant)
+	require.NoError(t, err)
+
+	// без аутентификации владелец не проверяется
+	ownerQueries = 0
+	require.NoError(t, s.Withdraw(context.Background(), "foreign", 10))
+	assert.Zero(t, ownerQueries)
+}
+
+func TestService_RevokeAPIKey(t *testing.T) {
+	helpers := newTestHelpers()
+	var scoped, unscoped []string
+	repo := &MockRepo{
+		RevokeAPIKeyFunc: func(ctx context.Context, id string) error {
+			scoped = append(scoped, id)
+			return nil
+		},
+		RevokeAnyAPIKeyFunc: func(ctx context.Context, id string) error {
+			unscoped = append(unscoped, id)
+			return nil
+		},
+	}
+	s := service.New(repo, &MockCache{}, helpers.logger)
+
+	tenantAdmin := auth.WithPrincipal(context.Background(), auth.Principal{ID: "k1", TenantID: "brand-a", Scopes: []auth.Scope{auth.ScopeAdmin}})
+	platformAdmin := auth.WithPrincipal(context.Background(), auth.Principal{ID: "k2", Scopes: []auth.Scope{auth.ScopeAdmin}})
+
+	require.NoError(t, s.RevokeAPIKey(tenantAdmin, "tenant-key"))
+	// ключ платформы виден только администратору платформы
+	require.NoError(t, s.RevokeAPIKey(platformAdmin, "platform-key"))
+
+	assert.Equal(t, []string{"tenant-key"}, scoped)
+	assert.Equal(t, []string{"platform-key"}, unscoped)
+}
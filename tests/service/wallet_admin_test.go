@@ -0,0 +1,158 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/service"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditEntries возвращает записи журнала аудита из JSON-лога
+func auditEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var entry map[string]any
+		require.NoError(t, dec.Decode(&entry))
+		if entry["audit"] == true {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func TestService_AdjustBalance(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	var adjusted []int
+	repo := &MockRepo{
+		AdjustBalanceFunc: func(ctx context.Context, walletID, transactionID string, amount int, reason string) (int, error) {
+			if walletID == "missing" {
+				return 0, myerrors.ErrNotFound
+			}
+			adjusted = append(adjusted, amount)
+			return 100 + amount, nil
+		},
+	}
+	var cached int
+	cache := &MockCache{
+		AddFunc: func(tenantID, walletID string, balance int) error {
+			cached = balance
+			return nil
+		},
+	}
+	s := service.New(repo, cache, logger)
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{ID: "ops-1", Scopes: []auth.Scope{auth.ScopeOperator}})
+
+	balance, err := s.AdjustBalance(ctx, "w-1", -30, "duplicate deposit")
+	require.NoError(t, err)
+	assert.Equal(t, 70, balance)
+	assert.Equal(t, 70, cached)
+
+	_, err = s.AdjustBalance(ctx, "w-1", 0, "nothing")
+	assert.ErrorIs(t, err, myerrors.ErrInvalidInput)
+	_, err = s.AdjustBalance(ctx, "w-1", -3000000000, "chargeback")
+	assert.ErrorIs(t, err, myerrors.ErrAmountRange)
+	_, err = s.AdjustBalance(ctx, "w-1", 1<<31, "refund")
+	assert.ErrorIs(t, err, myerrors.ErrAmountRange)
+	_, err = s.AdjustBalance(ctx, "w-1", 10, "  ")
+	assert.ErrorIs(t, err, myerrors.ErrReasonRequired)
+	_, err = s.AdjustBalance(ctx, "missing", 10, "refund")
+	assert.ErrorIs(t, err, myerrors.ErrNotFound)
+	assert.Equal(t, []int{-30}, adjusted)
+
+	// неудачные попытки тоже попадают в журнал
+	entries := auditEntries(t, &buf)
+	require.Len(t, entries, 6)
+	assert.Equal(t, "ops-1", entries[0]["actor"])
+	assert.Equal(t, service.AuditAdjustBalance, entries[0]["action"])
+	assert.Equal(t, "w-1", entries[0]["wallet_id"])
+	assert.Equal(t, "duplicate deposit", entries[0]["reason"])
+	assert.EqualValues(t, -30, entries[0]["amount"])
+	assert.Nil(t, entries[0]["error"])
+	assert.Equal(t, myerrors.ErrAmountRange.Error(), entries[2]["error"])
+	assert.Equal(t, myerrors.ErrNotFound.Error(), entries[5]["error"])
+}
+
+func TestService_SetWalletStatus(t *testing.T) {
+	var statuses []myvars.WalletStatus
+	var reasons []string
+	repo := &MockRepo{
+		SetWalletStatusFunc: func(ctx context.Context, walletID string, status myvars.WalletStatus, reason string) error {
+			statuses = append(statuses, status)
+			reasons = append(reasons, reason)
+			return nil
+		},
+	}
+	s := service.New(repo, &MockCache{}, slog.Default())
+
+	require.NoError(t, s.SetWalletStatus(context.Background(), "w-1", myvars.WalletStatusFrozen, "fraud investigation"))
+	assert.ErrorIs(t, s.SetWalletStatus(context.Background(), "w-1", "closed", "reason"), myerrors.ErrInvalidInput)
+	assert.ErrorIs(t, s.SetWalletStatus(context.Background(), "w-1", myvars.WalletStatusActive, ""), myerrors.ErrReasonRequired)
+	assert.Equal(t, []myvars.WalletStatus{myvars.WalletStatusFrozen}, statuses)
+	// причина уходит в журнал аудита вместе с изменением
+	assert.Equal(t, []string{"fraud investigation"}, reasons)
+}
+
+func TestService_ExportWallets(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	const total = 1203
+	base := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	// выдача отсортирована по created_at DESC, id; у соседних кошельков совпадает время создания
+	var stored []models.Wallet
+	for i := range total {
+		stored = append(stored, models.Wallet{ID: fmt.Sprintf("w%04d", i), CreatedAt: base.Add(-time.Duration(i/2) * time.Second)})
+	}
+	var pages int
+	repo := &MockRepo{
+		ListWalletsFunc: func(ctx context.Context, filter models.WalletFilter) ([]models.Wallet, error) {
+			assert.Equal(t, myvars.WalletStatusActive, filter.Status)
+			assert.Zero(t, filter.Offset)
+			pages++
+			start := 0
+			if c := filter.After; c != nil {
+				for start < len(stored) && (stored[start].CreatedAt.After(c.CreatedAt) ||
+					stored[start].CreatedAt.Equal(c.CreatedAt) && stored[start].ID <= c.ID) {
+					start++
+				}
+			}
+			page := slices.Clone(stored[start:min(start+filter.Limit, len(stored))])
+			// кошелек, созданный во время выгрузки, попадает в начало выдачи
+			stored = append([]models.Wallet{{ID: fmt.Sprintf("new-%d", pages), CreatedAt: base.Add(time.Duration(pages) * time.Second)}}, stored...)
+			return page, nil
+		},
+	}
+	s := service.New(repo, &MockCache{}, logger)
+
+	seen := make(map[string]bool)
+	err := s.ExportWallets(context.Background(), models.WalletFilter{Status: myvars.WalletStatusActive, Limit: 10, Offset: 20}, func(w models.Wallet) error {
+		assert.False(t, seen[w.ID], "wallet %s exported twice", w.ID)
+		seen[w.ID] = true
+		return nil
+	})
+	require.NoError(t, err)
+	// Limit и Offset запроса не ограничивают выгрузку, новые кошельки не сдвигают страницы
+	assert.Len(t, seen, total)
+	assert.Equal(t, 3, pages)
+
+	// выгрузка целиком - одна запись в журнале
+	entries := auditEntries(t, &buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, service.AuditExportWallets, entries[0]["action"])
+	assert.EqualValues(t, total, entries[0]["exported"])
+	assert.Equal(t, "active", entries[0]["wallet_status"])
+}
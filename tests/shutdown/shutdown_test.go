@@ -0,0 +1,191 @@
+package shutdown_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/shutdown"
+	"github.com/glekoz/test_itk/internal/web/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readyStatus(server *web.Server) int {
+	w := httptest.NewRecorder()
+	server.Ready(w, httptest.NewRequest("GET", "/readyz", nil))
+	return w.Code
+}
+
+func TestManager_InFlightRequestCompletes(t *testing.T) {
+	server := web.New(nil, "test-host", slog.Default())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	record := func(s string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, s)
+	}
+
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			record("request")
+			_, _ = io.WriteString(w, "withdrawn")
+		}),
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	sm := shutdown.New(srv, server, 0, 5*time.Second, slog.Default())
+	sm.OnShutdown("workers", func(ctx context.Context) error {
+		record("workers")
+		return nil
+	})
+	sm.OnShutdown("database pool", func(ctx context.Context) error {
+		record("database pool")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- sm.Serve(ctx, ln)
+	}()
+	require.Eventually(t, func() bool { return readyStatus(server) == http.StatusOK }, time.Second, 10*time.Millisecond)
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/withdraw")
+		if err != nil {
+			resCh <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		resCh <- result{status: resp.StatusCode, body: string(body), err: err}
+	}()
+
+	<-started
+	cancel() // то же, что SIGTERM
+
+	require.Eventually(t, func() bool { return readyStatus(server) == http.StatusServiceUnavailable }, time.Second, 10*time.Millisecond)
+	select {
+	case <-serveErr:
+		t.Fatal("server stopped before in-flight request completed")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+
+	res := <-resCh
+	require.NoError(t, res.err)
+	assert.Equal(t, http.StatusOK, res.status)
+	assert.Equal(t, "withdrawn", res.body)
+
+	require.NoError(t, <-serveErr)
+	assert.Equal(t, []string{"request", "workers", "database pool"}, order)
+
+	_, err = net.DialTimeout("tcp", ln.Addr().String(), 100*time.Millisecond)
+	assert.Error(t, err, "listener must be closed after shutdown")
+}
+
+func TestManager_DrainTimeout(t *testing.T) {
+	server := web.New(nil, "test-host", slog.Default())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		}),
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	sm := shutdown.New(srv, server, 0, 100*time.Millisecond, slog.Default())
+	var stepCtxErr error
+	sm.OnShutdown("workers", func(ctx context.Context) error {
+		stepCtxErr = ctx.Err()
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- sm.Serve(ctx, ln)
+	}()
+	require.Eventually(t, func() bool { return readyStatus(server) == http.StatusOK }, time.Second, 10*time.Millisecond)
+
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+
+	select {
+	case err := <-serveErr:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		// истекший срок дожидания запросов не должен достаться этапам остановки
+		assert.NoError(t, stepCtxErr)
+	case <-time.After(2 * time.Second):
+		t.Fatal("shutdown did not respect drain timeout")
+	}
+}
+
+func TestManager_StepsShareDeadline(t *testing.T) {
+	server := web.New(nil, "test-host", slog.Default())
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	sm := shutdown.New(&http.Server{}, server, 0, 100*time.Millisecond, slog.Default())
+	// первый этап не укладывается в срок, второй получает уже истекший общий срок, а не новый
+	sm.OnShutdown("workers", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	var poolCtxErr error
+	sm.OnShutdown("database pool", func(ctx context.Context) error {
+		poolCtxErr = ctx.Err()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- sm.Serve(ctx, ln)
+	}()
+	require.Eventually(t, func() bool { return readyStatus(server) == http.StatusOK }, time.Second, 10*time.Millisecond)
+
+	start := time.Now()
+	cancel()
+	select {
+	case err := <-serveErr:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorIs(t, poolCtxErr, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	case <-time.After(2 * time.Second):
+		t.Fatal("shutdown steps are not bounded by a shared deadline")
+	}
+}
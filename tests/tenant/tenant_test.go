@@ -0,0 +1,48 @@
+package tenant_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glekoz/test_itk/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"tenants": [
+		{"id": "brand-a", "currency": "USD", "max_transfer_amount": 500, "wallet_rate_limit": {"rate": 1, "burst": 2}},
+		{"id": "brand-b"}
+	]}`), 0o600))
+
+	registry, err := tenant.Load(path, "RUB")
+	require.NoError(t, err)
+
+	a, ok := registry.Get("brand-a")
+	require.True(t, ok)
+	assert.Equal(t, "USD", a.Currency)
+	assert.Equal(t, 500, a.MaxTransferAmount)
+	require.NotNil(t, a.WalletRateLimit)
+	assert.Equal(t, 2, a.WalletRateLimit.Burst)
+	assert.Nil(t, a.ClientRateLimit)
+
+	b, ok := registry.Get("brand-b")
+	require.True(t, ok)
+	assert.Equal(t, "RUB", b.Currency)
+
+	def, ok := registry.Get(tenant.DefaultID)
+	require.True(t, ok)
+	assert.Equal(t, "RUB", def.Currency)
+
+	_, ok = registry.Get("brand-c")
+	assert.False(t, ok)
+}
+
+func TestNewRegistryRejectsInvalidTenants(t *testing.T) {
+	_, err := tenant.NewRegistry("RUB", tenant.Config{ID: "Brand A"})
+	assert.Error(t, err)
+	_, err = tenant.NewRegistry("RUB", tenant.Config{ID: "brand-a", MaxTransferAmount: -1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,66 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/glekoz/test_itk/internal/tracing"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+func TestQueryName(t *testing.T) {
+	tests := []struct {
+		sql      string
+		expected string
+	}{
+		{sql: "-- name: GetBalance :one\nSELECT amount FROM wallets WHERE id = $1", expected: "db.GetBalance"},
+		{sql: "begin", expected: "db.begin"},
+		{sql: "SELECT\n  version_id FROM goose_db_version", expected: "db.select"},
+		{sql: "", expected: "db.query"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tracing.QueryName(tt.sql))
+	}
+}
+
+func TestQueryTracer(t *testing.T) {
+	rec := newRecorder(t)
+	tracer := tracing.QueryTracer{}
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "service.Withdraw")
+
+	qctx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "-- name: Withdraw :one\nUPDATE wallets SET amount = amount - $2"})
+	tracer.TraceQueryEnd(qctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("UPDATE 1")})
+
+	qctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "-- name: CreateTransaction :exec\nINSERT INTO transactions"})
+	tracer.TraceQueryEnd(qctx, nil, pgx.TraceQueryEndData{Err: errors.New("lock timeout")})
+	parent.End()
+
+	spans := rec.Ended()
+	require.Len(t, spans, 3)
+
+	assert.Equal(t, "db.Withdraw", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	assert.Equal(t, "db.CreateTransaction", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "lock timeout", spans[1].Status().Description)
+}
@@ -0,0 +1,240 @@
+package web
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glekoz/test_itk/api/v1"
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+	"github.com/glekoz/test_itk/internal/web/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var adminAuthenticator = stubAuthenticator{
+	"merchant": {ID: "k1", Scopes: []auth.Scope{auth.ScopeRead, auth.ScopeDeposit, auth.ScopeWithdraw}},
+	"operator": {ID: "k2", Scopes: []auth.Scope{auth.ScopeOperator}},
+	"admin":    {ID: "k3", Scopes: []auth.Scope{auth.ScopeAdmin}},
+}
+
+func adminRequest(method, path, body, key string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(auth.APIKeyHeader, key)
+	return req
+}
+
+func TestServer_AdminRole(t *testing.T) {
+	service := &MockService{
+		ListWalletsFunc: func(ctx context.Context, filter models.WalletFilter) ([]models.Wallet, error) { return nil, nil },
+	}
+	tests := []struct {
+		name           string
+		key            string
+		path           string
+		expectedStatus int
+	}{
+		{name: "merchant", key: "merchant", path: "/admin/v1/wallets", expectedStatus: http.StatusForbidden},
+		{name: "operator", key: "operator", path: "/admin/v1/wallets", expectedStatus: http.StatusOK},
+		{name: "admin implies operator", key: "admin", path: "/admin/v1/wallets", expectedStatus: http.StatusOK},
+		// оператор не получает права администратора платформы
+		{name: "operator can't list deliveries", key: "operator", path: "/api/v1/webhooks/s-1/deliveries", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := web.New(service, "test-host", slog.Default())
+			server.SetAuthenticator(adminAuthenticator)
+			w := httptest.NewRecorder()
+			server.Routes().ServeHTTP(w, adminRequest("GET", tt.path, "", tt.key))
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestServer_AdminListWallets(t *testing.T) {
+	created := time.Date(2025, 11, 8, 12, 0, 0, 0, time.UTC)
+	var got models.WalletFilter
+	service := &MockService{
+		ListWalletsFunc: func(ctx context.Context, filter models.WalletFilter) ([]models.Wallet, error) {
+			got = filter
+			return []models.Wallet{
+				{ID: "w-1", Balance: 150, Status: myvars.WalletStatusFrozen, OwnerID: "m-1", Version: 4, CreatedAt: created, UpdatedAt: created},
+			}, nil
+		},
+	}
+	server := web.New(service, "test-host", slog.Default())
+	server.SetAuthenticator(adminAuthenticator)
+
+	w := httptest.NewRecorder()
+	server.Routes().ServeHTTP(w, adminRequest("GET", "/admin/v1/wallets?min_balance=100&max_balance=200&created_from=2025-11-01T00:00:00Z&status=frozen&limit=10&offset=20", "", "operator"))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	require.NotNil(t, got.MinBalance)
+	require.NotNil(t, got.MaxBalance)
+	require.NotNil(t, got.CreatedFrom)
+	assert.Equal(t, 100, *got.MinBalance)
+	assert.Equal(t, 200, *got.MaxBalance)
+	assert.Equal(t, time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC), *got.CreatedFrom)
+	assert.Nil(t, got.CreatedTo)
+	assert.Equal(t, myvars.WalletStatusFrozen, got.Status)
+	assert.Equal(t, 10, got.Limit)
+	assert.Equal(t, 20, got.Offset)
+
+	var body api.AdminWalletList
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	require.Len(t, body.Wallets, 1)
+	assert.Equal(t, "w-1", body.Wallets[0].Id)
+	assert.Equal(t, api.Frozen, body.Wallets[0].Status)
+	require.NotNil(t, body.Wallets[0].OwnerId)
+	assert.Equal(t, "m-1", *body.Wallets[0].OwnerId)
+
+	// все ошибки параметров возвращаются сразу
+	w = httptest.NewRecorder()
+	server.Routes().ServeHTTP(w, adminRequest("GET", "/admin/v1/wallets?min_balance=abc&created_to=yesterday&status=closed&limit=0", "", "operator"))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var e api.Error
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&e))
+	for _, param := range []string{"min_balance", "created_to", "status", "limit"} {
+		assert.Contains(t, e.Detail, param)
+	}
+}
+
+func TestServer_AdminExportWallets(t *testing.T) {
+	created := time.Date(2025, 11, 8, 12, 0, 0, 0, time.UTC)
+	service := &MockService{
+		ExportWalletsFunc: func(ctx context.Context, filter models.WalletFilter, fn func(models.Wallet) error) error {
+			for _, id := range []string{"w-1", "w-2"} {
+				if err := fn(models.Wallet{ID: id, Balance: 10, Status: myvars.WalletStatusActive, Version: 2, CreatedAt: created, UpdatedAt: created}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	server := web.New(service, "test-host", slog.Default())
+	server.SetAuthenticator(adminAuthenticator)
+
+	w := httptest.NewRecorder()
+	server.Routes().ServeHTTP(w, adminRequest("GET", "/admin/v1/wallets/export", "", "operator"))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"id", "balance", "status", "owner_id", "version", "created_at", "updated_at"}, records[0])
+	assert.Equal(t, []string{"w-1", "10", "active", "", "2", "2025-11-08T12:00:00Z", "2025-11-08T12:00:00Z"}, records[1])
+}
+
+func TestServer_AdminGetWallet(t *testing.T) {
+	service := &MockService{
+		GetWalletFunc: func(ctx context.Context, walletID string) (models.WalletDetail, error) {
+			if walletID != "w-1" {
+				return models.WalletDetail{}, myerrors.ErrNotFound
+			}
+			return models.WalletDetail{
+				Wallet: models.Wallet{ID: "w-1", Balance: 70, Status: myvars.WalletStatusActive},
+				Transactions: []models.WalletTransaction{
+					{ID: "t-2", Amount: -30, OperationType: myvars.OperationTypeAdjustment, RequestID: "req-1"},
+					{ID: "t-1", Amount: 100, OperationType: myvars.OperationTypeDeposit},
+				},
+			}, nil
+		},
+	}
+	server := web.New(service, "test-host", slog.Default())
+	server.SetAuthenticator(adminAuthenticator)
+
+	w := httptest.NewRecorder()
+	server.Routes().ServeHTTP(w, adminRequest("GET", "/admin/v1/wallets/w-1", "", "operator"))
+	require.Equal(t, http.StatusOK, w.Code)
+	var body api.AdminWalletDetail
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, 70, body.Wallet.Balance)
+	require.Len(t, body.Transactions, 2)
+	assert.Equal(t, "adjustment", body.Transactions[0].OperationType)
+	require.NotNil(t, body.Transactions[0].RequestId)
+	assert.Nil(t, body.Transactions[1].RequestId)
+
+	w = httptest.NewRecorder()
+	server.Routes().ServeHTTP(w, adminRequest("GET", "/admin/v1/wallets/w-2", "", "operator"))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestServer_AdminAdjustBalance(t *testing.T) {
+	service := &MockService{
+		AdjustBalanceFunc: func(ctx context.Context, walletID string, amount int, reason string) (int, error) {
+			switch {
+			case amount == 0:
+				return 0, myerrors.ErrInvalidInput
+			case reason == "":
+				return 0, myerrors.ErrReasonRequired
+			case amount < -100:
+				return 0, myerrors.ErrNegativeAmount
+			}
+			return 100 + amount, nil
+		},
+	}
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "credit", body: `{"amount": 50, "reason": "compensation"}`, expectedStatus: http.StatusOK},
+		{name: "debit", body: `{"amount": -50, "reason": "duplicate deposit"}`, expectedStatus: http.StatusOK},
+		{name: "zero amount", body: `{"amount": 0, "reason": "nothing"}`, expectedStatus: http.StatusBadRequest},
+		{name: "missing reason", body: `{"amount": 50}`, expectedStatus: http.StatusBadRequest},
+		{name: "overdraft", body: `{"amount": -500, "reason": "fix"}`, expectedStatus: http.StatusBadRequest},
+		{name: "invalid json", body: `{`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := web.New(service, "test-host", slog.Default())
+			server.SetAuthenticator(adminAuthenticator)
+			w := httptest.NewRecorder()
+			server.Routes().ServeHTTP(w, adminRequest("POST", "/admin/v1/wallets/w-1/adjustments", tt.body, "operator"))
+			require.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestServer_FrozenWalletTransfer(t *testing.T) {
+	var status myvars.WalletStatus
+	service := &MockService{
+		SetWalletStatusFunc: func(ctx context.Context, walletID string, s myvars.WalletStatus, reason string) error {
+			status = s
+			return nil
+		},
+		DepositFunc: func(ctx context.Context, walletID string, amount int) error {
+			if status == myvars.WalletStatusFrozen {
+				return myerrors.ErrWalletFrozen
+			}
+			return nil
+		},
+	}
+	server := web.New(service, "test-host", slog.Default())
+	server.SetAuthenticator(adminAuthenticator)
+	h := server.Routes()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, adminRequest("PUT", "/admin/v1/wallets/w-1/status", `{"status": "frozen", "reason": "fraud investigation"}`, "operator"))
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, adminRequest("POST", "/api/v1/wallet", `{"wallet_id": "w-1", "operation": "deposit", "amount": 10}`, "merchant"))
+	require.Equal(t, http.StatusConflict, w.Code)
+	var body api.Error
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	require.NotNil(t, body.Code)
+	assert.Equal(t, "wallet_frozen", *body.Code)
+}
@@ -0,0 +1,141 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/glekoz/test_itk/api/v1"
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/web/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubAuthenticator сопоставляет значение X-API-Key с клиентом
+type stubAuthenticator map[string]auth.Principal
+
+func (a stubAuthenticator) Authenticate(r *http.Request) (auth.Principal, error) {
+	key := r.Header.Get(auth.APIKeyHeader)
+	if key == "" {
+		return auth.Principal{}, auth.ErrUnauthenticated
+	}
+	p, ok := a[key]
+	if !ok {
+		return auth.Principal{}, auth.ErrInvalidCredentials
+	}
+	return p, nil
+}
+
+func TestServer_Authorization(t *testing.T) {
+	authenticator := stubAuthenticator{
+		"reader":   {ID: "k1", Scopes: []auth.Scope{auth.ScopeRead}},
+		"merchant": {ID: "k2", OwnerID: "m-1", Scopes: []auth.Scope{auth.ScopeRead, auth.ScopeDeposit, auth.ScopeWithdraw}},
+		"admin":    {ID: "k3", Scopes: []auth.Scope{auth.ScopeAdmin}},
+	}
+	service := &MockService{
+		GetBalanceFunc: func(ctx context.Context, walletID string) (int, error) {
+			if walletID == "foreign" {
+				return 0, myerrors.ErrForbidden
+			}
+			return 100, nil
+		},
+		WithdrawFunc: func(ctx context.Context, walletID string, amount int) error { return nil },
+		IssueAPIKeyFunc: func(ctx context.Context, name string, scopes []string, ownerID string) (models.APIKey, string, error) {
+			return models.APIKey{ID: "k4", Name: name, Scopes: scopes, OwnerID: ownerID}, "itk_secret", nil
+		},
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		key            string
+		expectedStatus int
+	}{
+		{name: "health is public", method: "GET", path: "/healthz", expectedStatus: http.StatusOK},
+		{name: "missing key", method: "GET", path: "/api/v1/wallets/w-1", expectedStatus: http.StatusUnauthorized},
+		{name: "unknown key", method: "GET", path: "/api/v1/wallets/w-1", key: "stolen", expectedStatus: http.StatusUnauthorized},
+		{name: "read scope", method: "GET", path: "/api/v1/wallets/w-1", key: "reader", expectedStatus: http.StatusOK},
+		{name: "withdraw without scope", method: "POST", path: "/api/v1/wallet", body: `{"wallet_id": "w-1", "operation": "withdraw", "amount": 10}`, key: "reader", expectedStatus: http.StatusForbidden},
+		{name: "withdraw with scope", method: "POST", path: "/api/v1/wallet", body: `{"wallet_id": "w-1", "operation": "withdraw", "amount": 10}`, key: "merchant", expectedStatus: http.StatusNoContent},
+		{name: "foreign wallet", method: "GET", path: "/api/v1/wallets/foreign", key: "merchant", expectedStatus: http.StatusForbidden},
+		{name: "global webhook needs admin", method: "POST", path: "/api/v1/webhooks", body: `{"url": "https://example.com/hook"}`, key: "merchant", expectedStatus: http.StatusForbidden},
+		{name: "issue key needs admin", method: "POST", path: "/api/v1/admin/keys", body: `{"name": "shop", "scopes": ["read"]}`, key: "merchant", expectedStatus: http.StatusForbidden},
+		{name: "issue key", method: "POST", path: "/api/v1/admin/keys", body: `{"name": "shop", "scopes": ["read"], "owner_id": "m-2"}`, key: "admin", expectedStatus: http.StatusCreated},
+		{name: "unknown scope", method: "POST", path: "/api/v1/admin/keys", body: `{"name": "shop", "scopes": ["root"]}`, key: "admin", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := web.New(service, "test-host", slog.Default())
+			server.SetAuthenticator(authenticator)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.key != "" {
+				req.Header.Set(auth.APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			server.Routes().ServeHTTP(w, req)
+
+			require.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if w.Code == http.StatusUnauthorized || w.Code == http.StatusForbidden {
+				var body api.Error
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+				assert.Equal(t, tt.expectedStatus, body.Status)
+			}
+		})
+	}
+}
+
+// signatureAuthenticator возвращает ошибку подписанного запроса из заголовка
+type signatureAuthenticator map[string]error
+
+func (a signatureAuthenticator) Authenticate(r *http.Request) (auth.Principal, error) {
+	return auth.Principal{}, a[r.Header.Get("X-Case")]
+}
+
+func TestServer_SignatureErrorCodes(t *testing.T) {
+	authenticator := signatureAuthenticator{
+		"skew":      auth.ErrClockSkew,
+		"replay":    auth.ErrReplay,
+		"signature": auth.ErrInvalidSignature,
+		"plain":     auth.ErrInvalidCredentials,
+	}
+	tests := []struct {
+		name         string
+		expectedCode *string
+	}{
+		{name: "skew", expectedCode: ptr("clock_skew")},
+		{name: "replay", expectedCode: ptr("replayed_request")},
+		{name: "signature", expectedCode: ptr("invalid_signature")},
+		{name: "plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := web.New(&MockService{}, "test-host", slog.Default())
+			server.SetAuthenticator(authenticator)
+			req := httptest.NewRequest("GET", "/api/v1/wallets/w-1", nil)
+			req.Header.Set("X-Case", tt.name)
+			w := httptest.NewRecorder()
+			server.Routes().ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusUnauthorized, w.Code)
+			var body api.Error
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+			assert.Equal(t, tt.expectedCode, body.Code)
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
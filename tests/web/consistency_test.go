@@ -0,0 +1,95 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glekoz/test_itk/api/v1"
+	"github.com/glekoz/test_itk/internal/shared/consistency"
+	"github.com/glekoz/test_itk/internal/web/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ConsistencyToken(t *testing.T) {
+	t.Run("write returns wal position", func(t *testing.T) {
+		service := &MockService{
+			DepositFunc: func(ctx context.Context, walletID string, amount int) error {
+				// так репозиторий отмечает коммит, когда настроена реплика
+				consistency.FromContext(ctx).Wrote(0x16_B374D848)
+				return nil
+			},
+		}
+		server := web.New(service, "test-host", slog.Default())
+
+		body, err := json.Marshal(api.Transfer{WalletId: "w-1", Amount: 100, Operation: api.Deposit})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		server.Routes().ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/wallet", bytes.NewReader(body)))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "16/B374D848", w.Header().Get(consistency.Header))
+	})
+
+	t.Run("read without replica has no token", func(t *testing.T) {
+		service := &MockService{
+			GetBalanceFunc: func(ctx context.Context, walletID string) (int, error) {
+				return 100, nil
+			},
+		}
+		server := web.New(service, "test-host", slog.Default())
+
+		w := httptest.NewRecorder()
+		server.Routes().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/wallets/w-1", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get(consistency.Header))
+	})
+
+	t.Run("read carries client token", func(t *testing.T) {
+		var required consistency.LSN
+		service := &MockService{
+			GetBalanceFunc: func(ctx context.Context, walletID string) (int, error) {
+				required = consistency.FromContext(ctx).Required()
+				return 100, nil
+			},
+		}
+		server := web.New(service, "test-host", slog.Default())
+
+		req := httptest.NewRequest("GET", "/api/v1/wallets/w-1", nil)
+		req.Header.Set(consistency.Header, "0/3000060")
+		w := httptest.NewRecorder()
+		server.Routes().ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, consistency.LSN(0x3000060), required)
+	})
+
+	t.Run("malformed token is rejected", func(t *testing.T) {
+		called := false
+		service := &MockService{
+			GetBalanceFunc: func(ctx context.Context, walletID string) (int, error) {
+				called = true
+				return 100, nil
+			},
+		}
+		server := web.New(service, "test-host", slog.Default())
+
+		req := httptest.NewRequest("GET", "/api/v1/wallets/w-1", nil)
+		req.Header.Set(consistency.Header, "not-a-position")
+		w := httptest.NewRecorder()
+		server.Routes().ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.False(t, called)
+		var resp api.Error
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Code)
+		assert.Equal(t, "invalid_consistency_token", *resp.Code)
+	})
+}
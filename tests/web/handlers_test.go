@@ -6,7 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -47,7 +47,7 @@ func TestServer_CreateWallet(t *testing.T) {
 			}
 
 			// Используем nil логгеры для тестов, или можно создать буферизованные логгеры
-			server := web.New(mockService, "test-host", log.Default(), log.Default())
+			server := web.New(mockService, "test-host", slog.Default())
 
 			req := httptest.NewRequest("POST", "/api/v1/wallets", nil)
 			w := httptest.NewRecorder()
@@ -118,7 +118,7 @@ func TestServer_GetBalance(t *testing.T) {
 				GetBalanceFunc: tt.mockFunc,
 			}
 
-			server := web.New(mockService, "test-host", log.Default(), log.Default())
+			server := web.New(mockService, "test-host", slog.Default())
 
 			url := fmt.Sprintf("/api/v1/wallets/%s/balance", tt.walletID)
 			req := httptest.NewRequest("GET", url, nil)
@@ -222,6 +222,30 @@ func TestServer_Transfer(t *testing.T) {
 			},
 			expectedStatus: http.StatusNotFound,
 		},
+		{
+			name: "deposit - wallet locked longer than lock timeout",
+			requestBody: api.Transfer{
+				WalletId:  "hot-wallet",
+				Amount:    100,
+				Operation: api.Deposit,
+			},
+			mockDeposit: func(ctx context.Context, walletID string, amount int) error {
+				return fmt.Errorf("%w: canceling statement due to lock timeout", myerrors.ErrTimeout)
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "withdraw - wallet locked longer than lock timeout",
+			requestBody: api.Transfer{
+				WalletId:  "hot-wallet",
+				Amount:    100,
+				Operation: api.Withdraw,
+			},
+			mockWithdraw: func(ctx context.Context, walletID string, amount int) error {
+				return myerrors.ErrTimeout
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
 		{
 			name:           "invalid JSON",
 			requestBody:    "invalid json",
@@ -286,7 +310,7 @@ func TestServer_Transfer(t *testing.T) {
 				WithdrawFunc: tt.mockWithdraw,
 			}
 
-			server := web.New(mockService, "test-host", log.Default(), log.Default())
+			server := web.New(mockService, "test-host", slog.Default())
 
 			var bodyBytes []byte
 			var err error
@@ -314,6 +338,9 @@ func TestServer_Transfer(t *testing.T) {
 			if resp.StatusCode != tt.expectedStatus {
 				t.Errorf("expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
 			}
+			if tt.expectedStatus == http.StatusServiceUnavailable && resp.Header.Get("Retry-After") == "" {
+				t.Errorf("expected Retry-After header for a busy wallet")
+			}
 		})
 	}
 }
@@ -0,0 +1,62 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glekoz/test_itk/internal/health"
+	"github.com/glekoz/test_itk/internal/web/v1"
+)
+
+func TestServer_Ready(t *testing.T) {
+	tests := []struct {
+		name           string
+		ready          bool
+		checkErr       error
+		expectedStatus int
+		expectedReport string
+	}{
+		{name: "ready", ready: true, expectedStatus: http.StatusOK, expectedReport: health.StatusOK},
+		{name: "database down", ready: true, checkErr: errors.New("connection refused"), expectedStatus: http.StatusServiceUnavailable, expectedReport: health.StatusFailed},
+		{name: "shutting down", ready: false, expectedStatus: http.StatusServiceUnavailable, expectedReport: health.StatusShuttingDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := web.New(&MockService{}, "test-host", slog.Default())
+			server.RegisterCheck("postgres", true, func(ctx context.Context) error { return tt.checkErr })
+			server.SetReady(tt.ready)
+
+			w := httptest.NewRecorder()
+			server.Routes().ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			var report health.Report
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if report.Status != tt.expectedReport {
+				t.Errorf("expected report status %s, got %s", tt.expectedReport, report.Status)
+			}
+		})
+	}
+}
+
+func TestServer_Healthz(t *testing.T) {
+	server := web.New(&MockService{}, "test-host", slog.Default())
+	server.RegisterCheck("postgres", true, func(ctx context.Context) error { return errors.New("down") })
+
+	w := httptest.NewRecorder()
+	server.Routes().ServeHTTP(w, httptest.NewRequest("GET", "/healthz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("liveness must not depend on dependencies, got %d", w.Code)
+	}
+}
@@ -0,0 +1,74 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/glekoz/test_itk/internal/logging"
+	"github.com/glekoz/test_itk/internal/web/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_LogsOncePerRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		mockErr       error
+		expectedLevel string
+		expectedError string
+	}{
+		{name: "success", body: `{"wallet_id": "w-1", "operation": "deposit", "amount": 150}`, expectedLevel: "INFO"},
+		{name: "server error", body: `{"wallet_id": "w-1", "operation": "deposit", "amount": 150}`, mockErr: errors.New("connection reset"), expectedLevel: "ERROR", expectedError: "connection reset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := logging.New(&buf, "info")
+			require.NoError(t, err)
+
+			service := &MockService{
+				DepositFunc: func(ctx context.Context, walletID string, amount int) error { return tt.mockErr },
+			}
+			server := web.New(service, "test-host", logger)
+
+			w := httptest.NewRecorder()
+			server.Routes().ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/wallet", strings.NewReader(tt.body)))
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			require.Len(t, lines, 1)
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+			assert.Equal(t, tt.expectedLevel, entry["level"])
+			assert.Equal(t, "POST /api/v1/wallet", entry["route"])
+			assert.Equal(t, "w-1", entry[logging.KeyWalletID])
+			assert.Equal(t, "deposit", entry[logging.KeyOperation])
+			assert.EqualValues(t, 150, entry[logging.KeyAmount])
+			assert.EqualValues(t, w.Code, entry[logging.KeyStatus])
+			assert.Contains(t, entry, logging.KeyDuration)
+			assert.NotEmpty(t, entry[logging.KeyRequestID])
+			if tt.expectedError != "" {
+				assert.Equal(t, tt.expectedError, entry[logging.KeyError])
+			} else {
+				assert.NotContains(t, entry, logging.KeyError)
+			}
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := logging.ParseLevel("warn")
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, level)
+
+	_, err = logging.ParseLevel("verbose")
+	assert.Error(t, err)
+}
@@ -0,0 +1,190 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/glekoz/test_itk/api/v1"
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/ratelimit"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/web/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingStore имитирует недоступное хранилище корзин
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func transferRequest(walletID, key, remoteAddr string) *http.Request {
+	req := httptest.NewRequest("POST", "/api/v1/wallet", strings.NewReader(`{"wallet_id": "`+walletID+`", "operation": "deposit", "amount": 10}`))
+	req.Header.Set(auth.APIKeyHeader, key)
+	req.RemoteAddr = remoteAddr
+	return req
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	// пополнение за время теста пренебрежимо мало
+	slow := func(burst int) ratelimit.Limit { return ratelimit.Limit{Rate: 0.001, Burst: burst} }
+	authenticator := stubAuthenticator{
+		"k1": {ID: "k1", Scopes: []auth.Scope{auth.ScopeDeposit}},
+		"k2": {ID: "k2", Scopes: []auth.Scope{auth.ScopeDeposit}},
+	}
+	service := &MockService{
+		DepositFunc: func(ctx context.Context, walletID string, amount int) error { return nil },
+	}
+
+	t.Run("client", func(t *testing.T) {
+		server := web.New(service, "test-host", slog.Default())
+		server.SetAuthenticator(authenticator)
+		server.SetRateLimiter(ratelimit.NewMemoryStore(), web.RateLimits{Client: slow(2)})
+		h := server.Routes()
+
+		for i, expectedRemaining := range []string{"1", "0"} {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, transferRequest("w-1", "k1", "10.0.0.1:1234"))
+			require.Equal(t, http.StatusNoContent, w.Code, "request %d", i)
+			assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+			assert.Equal(t, expectedRemaining, w.Header().Get("RateLimit-Remaining"))
+			assert.NotEmpty(t, w.Header().Get("RateLimit-Reset"))
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, transferRequest("w-1", "k1", "10.0.0.1:1234"))
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+		var body api.Error
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		require.NotNil(t, body.Code)
+		assert.Equal(t, "rate_limited", *body.Code)
+
+		// другой клиент со своей корзиной
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, transferRequest("w-1", "k2", "10.0.0.1:1234"))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("ip before authentication", func(t *testing.T) {
+		server := web.New(service, "test-host", slog.Default())
+		server.SetAuthenticator(authenticator)
+		server.SetRateLimiter(ratelimit.NewMemoryStore(), web.RateLimits{IP: slow(1)})
+		h := server.Routes()
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, transferRequest("w-1", "stolen", "10.0.0.1:1234"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, transferRequest("w-1", "k1", "10.0.0.1:4321"))
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, transferRequest("w-1", "k1", "10.0.0.2:1234"))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("wallet", func(t *testing.T) {
+		server := web.New(service, "test-host", slog.Default())
+		server.SetAuthenticator(authenticator)
+		server.SetRateLimiter(ratelimit.NewMemoryStore(), web.RateLimits{Wallet: slow(1), Client: slow(10)})
+		h := server.Routes()
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, transferRequest("w-1", "k1", "10.0.0.1:1234"))
+		require.Equal(t, http.StatusNoContent, w.Code)
+		// заголовки показывают самое строгое измерение
+		assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, transferRequest("w-1", "k2", "10.0.0.2:1234"))
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, transferRequest("w-2", "k1", "10.0.0.1:1234"))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("wallet bucket is not spent without access", func(t *testing.T) {
+		owned := &MockService{
+			DepositFunc: service.DepositFunc,
+			CheckWalletAccessFunc: func(ctx context.Context, walletID string) error {
+				if p, _ := auth.FromContext(ctx); p.ID != "k1" {
+					return myerrors.ErrForbidden
+				}
+				return nil
+			},
+		}
+		server := web.New(owned, "test-host", slog.Default())
+		server.SetAuthenticator(authenticator)
+		server.SetRateLimiter(ratelimit.NewMemoryStore(), web.RateLimits{Wallet: slow(1)})
+		h := server.Routes()
+
+		for range 3 {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, transferRequest("w-1", "k2", "10.0.0.2:1234"))
+			require.Equal(t, http.StatusForbidden, w.Code)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, transferRequest("w-1", "k1", "10.0.0.1:1234"))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("client buckets are separated by auth method", func(t *testing.T) {
+		server := web.New(service, "test-host", slog.Default())
+		server.SetAuthenticator(stubAuthenticator{
+			"key": {ID: "c-1", Method: auth.MethodAPIKey, Scopes: []auth.Scope{auth.ScopeDeposit}},
+			"jwt": {ID: "c-1", Method: auth.MethodJWT, Scopes: []auth.Scope{auth.ScopeDeposit}},
+		})
+		server.SetRateLimiter(ratelimit.NewMemoryStore(), web.RateLimits{Client: slow(1)})
+		h := server.Routes()
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, transferRequest("w-1", "key", "10.0.0.1:1234"))
+		require.Equal(t, http.StatusNoContent, w.Code)
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, transferRequest("w-1", "key", "10.0.0.1:1234"))
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+		// sub из JWT совпадает с ID ключа, но это другой клиент
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, transferRequest("w-1", "jwt", "10.0.0.1:1234"))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("limits change on reload", func(t *testing.T) {
+		server := web.New(service, "test-host", slog.Default())
+		server.SetAuthenticator(authenticator)
+		server.SetRateLimiter(ratelimit.NewMemoryStore(), web.RateLimits{Client: slow(1)})
+		h := server.Routes()
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, transferRequest("w-1", "k1", "10.0.0.1:1234"))
+		require.Equal(t, http.StatusNoContent, w.Code)
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, transferRequest("w-1", "k1", "10.0.0.1:1234"))
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+
+		server.SetRateLimits(web.RateLimits{})
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, transferRequest("w-1", "k1", "10.0.0.1:1234"))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	})
+
+	t.Run("store failure lets requests through", func(t *testing.T) {
+		server := web.New(service, "test-host", slog.Default())
+		server.SetAuthenticator(authenticator)
+		server.SetRateLimiter(failingStore{}, web.RateLimits{Client: slow(1), IP: slow(1), Wallet: slow(1)})
+		w := httptest.NewRecorder()
+		server.Routes().ServeHTTP(w, transferRequest("w-1", "k1", "10.0.0.1:1234"))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	})
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glekoz/test_itk/api/v1"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/shared/requestid"
+	"github.com/glekoz/test_itk/internal/web/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_RequestID(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		expectSame bool
+	}{
+		{name: "client id is echoed", header: "gw-7f3a.1:42", expectSame: true},
+		{name: "missing id is generated", header: ""},
+		{name: "unsafe id is replaced", header: "bad id\nwith newline"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			service := &MockService{
+				GetBalanceFunc: func(ctx context.Context, walletID string) (int, error) {
+					seen = requestid.FromContext(ctx)
+					return 0, myerrors.ErrNotFound
+				},
+			}
+			server := web.New(service, "test-host", slog.Default())
+
+			req := httptest.NewRequest("GET", "/api/v1/wallets/w-1", nil)
+			if tt.header != "" {
+				req.Header.Set(requestid.Header, tt.header)
+			}
+			w := httptest.NewRecorder()
+			server.Routes().ServeHTTP(w, req)
+
+			id := w.Header().Get(requestid.Header)
+			require.NotEmpty(t, id)
+			if tt.expectSame {
+				assert.Equal(t, tt.header, id)
+			} else {
+				assert.NotEqual(t, tt.header, id)
+			}
+			assert.Equal(t, id, seen)
+
+			var body api.Error
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+			require.NotNil(t, body.RequestId)
+			assert.Equal(t, id, *body.RequestId)
+		})
+	}
+}
+
+func TestServer_RecoverPanicReturnsRequestID(t *testing.T) {
+	service := &MockService{
+		GetBalanceFunc: func(ctx context.Context, walletID string) (int, error) {
+			panic("nil map write")
+		},
+	}
+	server := web.New(service, "test-host", slog.Default())
+
+	req := httptest.NewRequest("GET", "/api/v1/wallets/w-1", nil)
+	req.Header.Set(requestid.Header, "req-panic-1")
+	w := httptest.NewRecorder()
+	server.Routes().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "req-panic-1", w.Header().Get(requestid.Header))
+
+	var body api.Error
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	require.NotNil(t, body.RequestId)
+	assert.Equal(t, "req-panic-1", *body.RequestId)
+}
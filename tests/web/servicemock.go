@@ -3,6 +3,9 @@ package web
 import (
 	"context"
 	"errors"
+
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
 )
 
 type MockService struct {
@@ -10,6 +13,21 @@ type MockService struct {
 	GetBalanceFunc   func(ctx context.Context, walletID string) (int, error)
 	DepositFunc      func(ctx context.Context, walletID string, amount int) error
 	WithdrawFunc     func(ctx context.Context, walletID string, amount int) error
+
+	CheckWalletAccessFunc func(ctx context.Context, walletID string) error
+
+	CreateWebhookSubscriptionFunc func(ctx context.Context, walletID, url string) (models.WebhookSubscription, error)
+	ListWebhookDeliveriesFunc     func(ctx context.Context, subscriptionID string, limit int) ([]models.WebhookDelivery, error)
+	ReplayWebhookDeliveryFunc     func(ctx context.Context, deliveryID string) error
+
+	IssueAPIKeyFunc  func(ctx context.Context, name string, scopes []string, ownerID string) (models.APIKey, string, error)
+	RevokeAPIKeyFunc func(ctx context.Context, id string) error
+
+	ListWalletsFunc     func(ctx context.Context, filter models.WalletFilter) ([]models.Wallet, error)
+	ExportWalletsFunc   func(ctx context.Context, filter models.WalletFilter, fn func(models.Wallet) error) error
+	GetWalletFunc       func(ctx context.Context, walletID string) (models.WalletDetail, error)
+	AdjustBalanceFunc   func(ctx context.Context, walletID string, amount int, reason string) (int, error)
+	SetWalletStatusFunc func(ctx context.Context, walletID string, status myvars.WalletStatus, reason string) error
 }
 
 func (m *MockService) CreateWallet(ctx context.Context) (string, error) {
@@ -39,3 +57,81 @@ func (m *MockService) Withdraw(ctx context.Context, walletID string, amount int)
 	}
 	return errors.New("not implemented")
 }
+
+// CheckWalletAccess по умолчанию пускает: большинство тестов владение не проверяют
+func (m *MockService) CheckWalletAccess(ctx context.Context, walletID string) error {
+	if m.CheckWalletAccessFunc != nil {
+		return m.CheckWalletAccessFunc(ctx, walletID)
+	}
+	return nil
+}
+
+func (m *MockService) CreateWebhookSubscription(ctx context.Context, walletID, url string) (models.WebhookSubscription, error) {
+	if m.CreateWebhookSubscriptionFunc != nil {
+		return m.CreateWebhookSubscriptionFunc(ctx, walletID, url)
+	}
+	return models.WebhookSubscription{}, errors.New("not implemented")
+}
+
+func (m *MockService) ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]models.WebhookDelivery, error) {
+	if m.ListWebhookDeliveriesFunc != nil {
+		return m.ListWebhookDeliveriesFunc(ctx, subscriptionID, limit)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockService) ReplayWebhookDelivery(ctx context.Context, deliveryID string) error {
+	if m.ReplayWebhookDeliveryFunc != nil {
+		return m.ReplayWebhookDeliveryFunc(ctx, deliveryID)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockService) IssueAPIKey(ctx context.Context, name string, scopes []string, ownerID string) (models.APIKey, string, error) {
+	if m.IssueAPIKeyFunc != nil {
+		return m.IssueAPIKeyFunc(ctx, name, scopes, ownerID)
+	}
+	return models.APIKey{}, "", errors.New("not implemented")
+}
+
+func (m *MockService) RevokeAPIKey(ctx context.Context, id string) error {
+	if m.RevokeAPIKeyFunc != nil {
+		return m.RevokeAPIKeyFunc(ctx, id)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockService) ListWallets(ctx context.Context, filter models.WalletFilter) ([]models.Wallet, error) {
+	if m.ListWalletsFunc != nil {
+		return m.ListWalletsFunc(ctx, filter)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockService) ExportWallets(ctx context.Context, filter models.WalletFilter, fn func(models.Wallet) error) error {
+	if m.ExportWalletsFunc != nil {
+		return m.ExportWalletsFunc(ctx, filter, fn)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockService) GetWallet(ctx context.Context, walletID string) (models.WalletDetail, error) {
+	if m.GetWalletFunc != nil {
+		return m.GetWalletFunc(ctx, walletID)
+	}
+	return models.WalletDetail{}, errors.New("not implemented")
+}
+
+func (m *MockService) AdjustBalance(ctx context.Context, walletID string, amount int, reason string) (int, error) {
+	if m.AdjustBalanceFunc != nil {
+		return m.AdjustBalanceFunc(ctx, walletID, amount, reason)
+	}
+	return 0, errors.New("not implemented")
+}
+
+func (m *MockService) SetWalletStatus(ctx context.Context, walletID string, status myvars.WalletStatus, reason string) error {
+	if m.SetWalletStatusFunc != nil {
+		return m.SetWalletStatusFunc(ctx, walletID, status, reason)
+	}
+	return errors.New("not implemented")
+}
@@ -0,0 +1,101 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glekoz/test_itk/api/v1"
+	"github.com/glekoz/test_itk/internal/auth"
+	"github.com/glekoz/test_itk/internal/tenant"
+	"github.com/glekoz/test_itk/internal/web/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ResolveTenant(t *testing.T) {
+	authenticator := stubAuthenticator{
+		"platform": {ID: "k1", Scopes: []auth.Scope{auth.ScopeAdmin}},
+		"legacy":   {ID: "k3", Scopes: []auth.Scope{auth.ScopeRead, auth.ScopeWithdraw}},
+		"brand-a":  {ID: "k2", TenantID: "brand-a", Scopes: []auth.Scope{auth.ScopeRead}},
+	}
+	var seenTenant string
+	service := &MockService{
+		GetBalanceFunc: func(ctx context.Context, walletID string) (int, error) {
+			seenTenant = tenant.ID(ctx)
+			return 100, nil
+		},
+	}
+	registry, err := tenant.NewRegistry("RUB", tenant.Config{ID: "brand-a", Currency: "USD"}, tenant.Config{ID: "brand-b"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		key              string
+		header           string
+		expectedStatus   int
+		expectedTenant   string
+		expectedCurrency string
+	}{
+		{name: "platform key without header", key: "platform", expectedStatus: http.StatusOK, expectedTenant: tenant.DefaultID, expectedCurrency: "RUB"},
+		{name: "platform key chooses tenant", key: "platform", header: "brand-b", expectedStatus: http.StatusOK, expectedTenant: "brand-b", expectedCurrency: "RUB"},
+		{name: "non-admin key without tenant", key: "legacy", expectedStatus: http.StatusOK, expectedTenant: tenant.DefaultID, expectedCurrency: "RUB"},
+		{name: "non-admin key without tenant with default header", key: "legacy", header: tenant.DefaultID, expectedStatus: http.StatusOK, expectedTenant: tenant.DefaultID, expectedCurrency: "RUB"},
+		{name: "non-admin key without tenant can not choose tenant", key: "legacy", header: "brand-a", expectedStatus: http.StatusForbidden},
+		{name: "tenant key", key: "brand-a", expectedStatus: http.StatusOK, expectedTenant: "brand-a", expectedCurrency: "USD"},
+		{name: "tenant key with own header", key: "brand-a", header: "brand-a", expectedStatus: http.StatusOK, expectedTenant: "brand-a", expectedCurrency: "USD"},
+		{name: "tenant key with foreign header", key: "brand-a", header: "brand-b", expectedStatus: http.StatusForbidden},
+		{name: "unknown tenant", key: "platform", header: "brand-c", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seenTenant = ""
+			server := web.New(service, "test-host", slog.Default())
+			server.SetAuthenticator(authenticator)
+			server.SetTenants(registry)
+
+			req := httptest.NewRequest("GET", "/api/v1/wallets/w-1", nil)
+			req.Header.Set(auth.APIKeyHeader, tt.key)
+			if tt.header != "" {
+				req.Header.Set(tenant.Header, tt.header)
+			}
+			w := httptest.NewRecorder()
+			server.Routes().ServeHTTP(w, req)
+
+			require.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if w.Code != http.StatusOK {
+				assert.Empty(t, seenTenant)
+				return
+			}
+			assert.Equal(t, tt.expectedTenant, seenTenant)
+			var body api.Balance
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+			require.NotNil(t, body.Currency)
+			assert.Equal(t, tt.expectedCurrency, *body.Currency)
+		})
+	}
+}
+
+func TestServer_ResolveTenantWithoutRegistry(t *testing.T) {
+	server := web.New(&MockService{
+		GetBalanceFunc: func(ctx context.Context, walletID string) (int, error) { return 100, nil },
+	}, "test-host", slog.Default())
+
+	req := httptest.NewRequest("GET", "/api/v1/wallets/w-1", nil)
+	req.Header.Set(tenant.Header, "brand-a")
+	w := httptest.NewRecorder()
+	server.Routes().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req = httptest.NewRequest("GET", "/api/v1/wallets/w-1", nil)
+	w = httptest.NewRecorder()
+	server.Routes().ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var body api.Balance
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Nil(t, body.Currency)
+}
@@ -0,0 +1,87 @@
+package web
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glekoz/test_itk/internal/tracing"
+	"github.com/glekoz/test_itk/internal/web/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestServer_TracePropagation(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	var handlerSpan trace.SpanContext
+	service := &MockService{
+		GetBalanceFunc: func(ctx context.Context, walletID string) (int, error) {
+			// спан сервиса стал бы дочерним к этому
+			_, span := tracing.Tracer().Start(ctx, "service.GetBalance")
+			handlerSpan = span.SpanContext()
+			span.End()
+			return 100, nil
+		},
+	}
+	server := web.New(service, "test-host", slog.Default())
+
+	req := httptest.NewRequest("GET", "/api/v1/wallets/01970a4e-0000-7000-8000-000000000001", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	server.Routes().ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	spans := rec.Ended()
+	require.Len(t, spans, 2)
+
+	serverSpan := spans[1]
+	assert.Equal(t, "GET /api/v1/wallets/{wallet_uuid}", serverSpan.Name())
+	assert.Equal(t, trace.SpanKindServer, serverSpan.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", serverSpan.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", serverSpan.Parent().SpanID().String())
+	assert.True(t, serverSpan.Parent().IsRemote())
+	assert.Contains(t, serverSpan.Attributes(), attribute.Int("http.response.status_code", http.StatusOK))
+
+	assert.Equal(t, serverSpan.SpanContext().TraceID(), handlerSpan.TraceID())
+	assert.Equal(t, serverSpan.SpanContext().SpanID(), spans[0].Parent().SpanID())
+}
+
+func TestServer_TracePanicAsError(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prevProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prevProvider) })
+
+	service := &MockService{
+		GetBalanceFunc: func(ctx context.Context, walletID string) (int, error) {
+			panic("nil map write")
+		},
+	}
+	server := web.New(service, "test-host", slog.Default())
+
+	w := httptest.NewRecorder()
+	server.Routes().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/wallets/w-1", nil))
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), attribute.Int("http.response.status_code", http.StatusInternalServerError))
+}
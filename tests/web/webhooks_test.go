@@ -0,0 +1,121 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/glekoz/test_itk/api/v1"
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myerrors"
+	"github.com/glekoz/test_itk/internal/web/v1"
+)
+
+func TestServer_CreateWebhookSubscription(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    string
+		mockFunc       func(ctx context.Context, walletID, url string) (models.WebhookSubscription, error)
+		expectedStatus int
+	}{
+		{
+			name:        "successful creation",
+			requestBody: `{"url":"https://merchant.example/hook","wallet_id":"w1"}`,
+			mockFunc: func(ctx context.Context, walletID, url string) (models.WebhookSubscription, error) {
+				if walletID != "w1" || url != "https://merchant.example/hook" {
+					t.Errorf("unexpected arguments %q %q", walletID, url)
+				}
+				return models.WebhookSubscription{ID: "s1", WalletID: walletID, URL: url, Secret: "secret", CreatedAt: time.Now()}, nil
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "invalid url",
+			requestBody:    `{"url":"ftp://merchant.example/hook"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "metadata endpoint url",
+			requestBody:    `{"url":"http://169.254.169.254/latest/meta-data","wallet_id":"w1"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "localhost url",
+			requestBody:    `{"url":"http://localhost:5432/","wallet_id":"w1"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "wallet not found",
+			requestBody: `{"url":"https://merchant.example/hook","wallet_id":"missing"}`,
+			mockFunc: func(ctx context.Context, walletID, url string) (models.WebhookSubscription, error) {
+				return models.WebhookSubscription{}, myerrors.ErrNotFound
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := web.New(&MockService{CreateWebhookSubscriptionFunc: tt.mockFunc}, "test-host", slog.Default())
+
+			req := httptest.NewRequest("POST", "/api/v1/webhooks", bytes.NewBufferString(tt.requestBody))
+			w := httptest.NewRecorder()
+
+			server.CreateWebhookSubscription(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.expectedStatus == http.StatusCreated {
+				var sub api.WebhookSubscription
+				if err := json.NewDecoder(resp.Body).Decode(&sub); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if sub.Id != "s1" || sub.Secret != "secret" {
+					t.Errorf("unexpected body %+v", sub)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_ReplayWebhookDelivery(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockErr        error
+		expectedStatus int
+	}{
+		{name: "replayed", expectedStatus: http.StatusAccepted},
+		{name: "delivery not found", mockErr: myerrors.ErrNotFound, expectedStatus: http.StatusNotFound},
+		{name: "delivery is being sent", mockErr: myerrors.ErrDeliveryInProgress, expectedStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockService{
+				ReplayWebhookDeliveryFunc: func(ctx context.Context, deliveryID string) error {
+					return tt.mockErr
+				},
+			}
+			server := web.New(mockService, "test-host", slog.Default())
+
+			req := httptest.NewRequest("POST", "/api/v1/webhooks/deliveries/d1/replay", nil)
+			req.SetPathValue("delivery_id", "d1")
+			w := httptest.NewRecorder()
+
+			server.ReplayWebhookDelivery(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
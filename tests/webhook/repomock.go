@@ -0,0 +1,57 @@
+package webhook_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+)
+
+type failedMark struct {
+	status        myvars.DeliveryStatus
+	lastErr       string
+	nextAttemptAt time.Time
+}
+
+// MockRepo отдает заранее заданные доставки и запоминает, как они были отмечены
+type MockRepo struct {
+	mu        sync.Mutex
+	attempts  []models.WebhookAttempt
+	delivered []string
+	failed    map[string]failedMark
+
+	claimLimit int
+	claimLease time.Duration
+}
+
+func newMockRepo(attempts ...models.WebhookAttempt) *MockRepo {
+	return &MockRepo{
+		attempts: attempts,
+		failed:   make(map[string]failedMark),
+	}
+}
+
+func (m *MockRepo) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.claimLimit, m.claimLease = limit, lease
+	res := m.attempts
+	m.attempts = nil
+	return res, nil
+}
+
+func (m *MockRepo) MarkWebhookDeliveryDelivered(ctx context.Context, deliveryID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.delivered = append(m.delivered, deliveryID)
+	return nil
+}
+
+func (m *MockRepo) MarkWebhookDeliveryFailed(ctx context.Context, deliveryID string, status myvars.DeliveryStatus, lastErr string, nextAttemptAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failed[deliveryID] = failedMark{status: status, lastErr: lastErr, nextAttemptAt: nextAttemptAt}
+	return nil
+}
@@ -0,0 +1,197 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/glekoz/test_itk/internal/shared/models"
+	"github.com/glekoz/test_itk/internal/shared/myvars"
+	"github.com/glekoz/test_itk/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"type":"wallet.deposited"}`)
+	signature := webhook.Sign("secret", 1700000000, body)
+
+	assert.True(t, webhook.Verify("secret", 1700000000, body, signature))
+	assert.False(t, webhook.Verify("other-secret", 1700000000, body, signature))
+	assert.False(t, webhook.Verify("secret", 1700000001, body, signature))
+	assert.False(t, webhook.Verify("secret", 1700000000, []byte(`{}`), signature))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 5*time.Second, webhook.Backoff(1))
+	assert.Equal(t, 10*time.Second, webhook.Backoff(2))
+	assert.Equal(t, 40*time.Second, webhook.Backoff(4))
+	assert.Equal(t, time.Hour, webhook.Backoff(30))
+}
+
+func TestWorker_Tick(t *testing.T) {
+	tests := []struct {
+		name           string
+		responseStatus int
+		attempts       int
+		expectedStatus myvars.DeliveryStatus // пусто, если доставка успешна
+	}{
+		{
+			name:           "successful delivery",
+			responseStatus: http.StatusOK,
+		},
+		{
+			name:           "failed delivery is retried",
+			responseStatus: http.StatusInternalServerError,
+			attempts:       1,
+			expectedStatus: myvars.DeliveryStatusPending,
+		},
+		{
+			name:           "last failed attempt goes to dead letter",
+			responseStatus: http.StatusBadGateway,
+			attempts:       2,
+			expectedStatus: myvars.DeliveryStatusDead,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := []byte(`{"type":"wallet.withdrawn","wallet_id":"w1"}`)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.Equal(t, payload, body)
+				assert.Equal(t, "d1", r.Header.Get(webhook.HeaderDeliveryID))
+
+				ts, err := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+				require.NoError(t, err)
+				assert.True(t, webhook.Verify("secret", ts, body, r.Header.Get(webhook.HeaderSignature)))
+
+				w.WriteHeader(tt.responseStatus)
+			}))
+			defer srv.Close()
+
+			repo := newMockRepo(models.WebhookAttempt{
+				DeliveryID: "d1",
+				EventID:    1,
+				EventType:  string(myvars.EventTypeWithdrawn),
+				Payload:    payload,
+				Attempts:   tt.attempts,
+				URL:        srv.URL,
+				Secret:     "secret",
+			})
+			worker := webhook.New(repo, time.Second, 3, slog.Default())
+			worker.SetClient(srv.Client())
+
+			worker.Tick(context.Background())
+
+			if tt.expectedStatus == "" {
+				assert.Equal(t, []string{"d1"}, repo.delivered)
+				assert.Empty(t, repo.failed)
+				return
+			}
+			assert.Empty(t, repo.delivered)
+			mark, ok := repo.failed["d1"]
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedStatus, mark.status)
+			assert.Contains(t, mark.lastErr, strconv.Itoa(tt.responseStatus))
+			assert.True(t, mark.nextAttemptAt.After(time.Now()))
+		})
+	}
+}
+
+// пачка отправляется по очереди, и каждая доставка может занять весь таймаут клиента,
+// поэтому аренда должна пережить всю пачку
+func TestWorker_LeaseCoversBatch(t *testing.T) {
+	repo := newMockRepo()
+	worker := webhook.New(repo, time.Hour, 3, slog.Default())
+	worker.Tick(context.Background())
+
+	require.Positive(t, repo.claimLimit)
+	assert.Greater(t, repo.claimLease, time.Duration(repo.claimLimit)*webhook.NewClient().Timeout)
+}
+
+func TestWorker_RejectsPrivateAddresses(t *testing.T) {
+	var called bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	repo := newMockRepo(models.WebhookAttempt{
+		DeliveryID: "d1",
+		EventType:  string(myvars.EventTypeDeposited),
+		Payload:    []byte(`{}`),
+		URL:        srv.URL,
+		Secret:     "secret",
+	})
+	worker := webhook.New(repo, time.Second, 3, slog.Default())
+
+	worker.Tick(context.Background())
+
+	assert.False(t, called)
+	assert.Empty(t, repo.delivered)
+	mark, ok := repo.failed["d1"]
+	require.True(t, ok)
+	assert.Contains(t, mark.lastErr, webhook.ErrForbiddenAddress.Error())
+}
+
+func TestWorker_DoesNotFollowRedirects(t *testing.T) {
+	var followed bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		followed = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	}))
+	defer srv.Close()
+
+	repo := newMockRepo(models.WebhookAttempt{
+		DeliveryID: "d1",
+		EventType:  string(myvars.EventTypeDeposited),
+		Payload:    []byte(`{}`),
+		URL:        srv.URL,
+		Secret:     "secret",
+	})
+	worker := webhook.New(repo, time.Second, 3, slog.Default())
+	client := webhook.NewClient()
+	client.Transport = srv.Client().Transport
+	worker.SetClient(client)
+
+	worker.Tick(context.Background())
+
+	assert.False(t, followed)
+	mark, ok := repo.failed["d1"]
+	require.True(t, ok)
+	assert.Contains(t, mark.lastErr, strconv.Itoa(http.StatusFound))
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	for addr, expected := range map[string]bool{
+		"8.8.8.8":         true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"::1":             false,
+		"10.1.2.3":        false,
+		"172.16.0.10":     false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+		"::ffff:10.0.0.1": false,
+	} {
+		assert.Equal(t, expected, webhook.IsPublicAddr(netip.MustParseAddr(addr)), addr)
+	}
+}